@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sgaunet/template-api/pkg/client"
+)
+
+var authorsHeader = []string{"ID", "NAME", "BIO"}
+
+func authorRow(a *client.Author) []string {
+	return []string{strconv.FormatInt(a.ID, 10), a.Name, a.Bio}
+}
+
+// authors runs the authors commands.
+func (c *cli) authors(ctx context.Context, command string, args []string) error {
+	fs := c.newFlagSet("authors " + command)
+	switch command {
+	case "list":
+		if _, err := parseArgs(fs, args); err != nil {
+			return err
+		}
+		list := []*client.Author{}
+		var rows [][]string
+		for author, err := range c.client.ListAuthors(ctx) {
+			if err != nil {
+				return err
+			}
+			list = append(list, author)
+			rows = append(rows, authorRow(author))
+		}
+		return c.print(list, authorsHeader, rows)
+
+	case "get":
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		author, err := c.client.GetAuthor(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.print(author, authorsHeader, [][]string{authorRow(author)})
+
+	case "create":
+		var input client.AuthorInput
+		fs.StringVar(&input.Name, "name", "", "name of the author")
+		fs.StringVar(&input.Bio, "bio", "", "biography of the author")
+		if _, err := parseArgs(fs, args); err != nil {
+			return err
+		}
+		author, err := c.client.CreateAuthor(ctx, input)
+		if err != nil {
+			return err
+		}
+		return c.print(author, authorsHeader, [][]string{authorRow(author)})
+
+	case "update":
+		name := fs.String("name", "", "new name of the author")
+		bio := fs.String("bio", "", "new biography of the author")
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		// The API replaces authors, unset fields are kept.
+		author, err := c.client.GetAuthor(ctx, id)
+		if err != nil {
+			return err
+		}
+		input := client.AuthorInput{Name: author.Name, Bio: author.Bio}
+		if isSet(fs, "name") {
+			input.Name = *name
+		}
+		if isSet(fs, "bio") {
+			input.Bio = *bio
+		}
+		if author, err = c.client.UpdateAuthor(ctx, id, input); err != nil {
+			return err
+		}
+		return c.print(author, authorsHeader, [][]string{authorRow(author)})
+
+	case "delete":
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		if err := c.client.DeleteAuthor(ctx, id); err != nil {
+			return err
+		}
+		c.printMessage("author %d deleted", id)
+		return nil
+
+	case "import":
+		return c.importFile(ctx, fs, args, c.client.ImportAuthors)
+
+	case "export":
+		return c.export(ctx, fs, args, c.client.ExportAuthors)
+
+	default:
+		return fmt.Errorf("%w: authors %s", errUnknownCommand, command)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/sgaunet/template-api/pkg/client"
+)
+
+var booksHeader = []string{"ID", "TITLE", "AUTHOR ID"}
+
+func bookRow(b *client.Book) []string {
+	return []string{strconv.FormatInt(b.ID, 10), b.Title, strconv.FormatInt(b.AuthorID, 10)}
+}
+
+// books runs the books commands.
+func (c *cli) books(ctx context.Context, command string, args []string) error {
+	fs := c.newFlagSet("books " + command)
+	switch command {
+	case "list":
+		if _, err := parseArgs(fs, args); err != nil {
+			return err
+		}
+		list := []*client.Book{}
+		var rows [][]string
+		for book, err := range c.client.ListBooks(ctx) {
+			if err != nil {
+				return err
+			}
+			list = append(list, book)
+			rows = append(rows, bookRow(book))
+		}
+		return c.print(list, booksHeader, rows)
+
+	case "get":
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		book, err := c.client.GetBook(ctx, id)
+		if err != nil {
+			return err
+		}
+		return c.print(book, booksHeader, [][]string{bookRow(book)})
+
+	case "create":
+		var input client.BookInput
+		fs.StringVar(&input.Title, "title", "", "title of the book")
+		fs.Int64Var(&input.AuthorID, "author", 0, "ID of the author of the book")
+		if _, err := parseArgs(fs, args); err != nil {
+			return err
+		}
+		book, err := c.client.CreateBook(ctx, input)
+		if err != nil {
+			return err
+		}
+		return c.print(book, booksHeader, [][]string{bookRow(book)})
+
+	case "update":
+		title := fs.String("title", "", "new title of the book")
+		authorID := fs.Int64("author", 0, "ID of the new author of the book")
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		// The API replaces books, unset fields are kept.
+		book, err := c.client.GetBook(ctx, id)
+		if err != nil {
+			return err
+		}
+		input := client.BookInput{Title: book.Title, AuthorID: book.AuthorID}
+		if isSet(fs, "title") {
+			input.Title = *title
+		}
+		if isSet(fs, "author") {
+			input.AuthorID = *authorID
+		}
+		if book, err = c.client.UpdateBook(ctx, id, input); err != nil {
+			return err
+		}
+		return c.print(book, booksHeader, [][]string{bookRow(book)})
+
+	case "delete":
+		id, err := parseID(fs, args)
+		if err != nil {
+			return err
+		}
+		if err := c.client.DeleteBook(ctx, id); err != nil {
+			return err
+		}
+		c.printMessage("book %d deleted", id)
+		return nil
+
+	case "import":
+		return c.importFile(ctx, fs, args, c.client.ImportBooks)
+
+	case "export":
+		return c.export(ctx, fs, args, c.client.ExportBooks)
+
+	default:
+		return fmt.Errorf("%w: books %s", errUnknownCommand, command)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/template-api/pkg/client"
+)
+
+// newFlagSet creates the flag set of a subcommand.
+func (c *cli) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+// parseArgs parses the flags of fs wherever they are in args, e.g.
+// "update 3 -name X", and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%w: %w", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseID returns the single positional argument, an identifier.
+func parseID(fs *flag.FlagSet, args []string) (int64, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return 0, err
+	}
+	if len(positional) != 1 {
+		return 0, fmt.Errorf("%w: %s expects an ID", errUsage, fs.Name())
+	}
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid ID %q", errUsage, positional[0])
+	}
+	return id, nil
+}
+
+// isSet reports whether the flag name was given.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// formatFlag adds the -format flag of imports and exports.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", "", "csv or ndjson (default: from the file extension, ndjson otherwise)")
+}
+
+// exchangeFormat returns the format of the -format flag, or of the file
+// extension.
+func exchangeFormat(format, filename string) (client.Format, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+	switch client.Format(format) {
+	case client.FormatCSV:
+		return client.FormatCSV, nil
+	case client.FormatNDJSON, "", "json", "jsonl":
+		return client.FormatNDJSON, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported format %q", errUsage, format)
+	}
+}
+
+type importFunc func(ctx context.Context, r io.Reader, format client.Format) (*client.ImportResult, error)
+
+// importFile runs "import [-format F] FILE".
+func (c *cli) importFile(ctx context.Context, fs *flag.FlagSet, args []string, importFn importFunc) error {
+	formatName := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%w: %s expects a file", errUsage, fs.Name())
+	}
+	filename := positional[0]
+	format, err := exchangeFormat(*formatName, filename)
+	if err != nil {
+		return err
+	}
+
+	r := c.stdin
+	if filename != "-" {
+		f, err := os.Open(filename) //nolint:gosec // the file is chosen by the user
+		if err != nil {
+			return fmt.Errorf("could not open import file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	result, err := importFn(ctx, r, format)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(result.Errors))
+	for i, lineErr := range result.Errors {
+		rows[i] = []string{strconv.Itoa(lineErr.Line), string(lineErr.Error.Code), lineErr.Error.Message}
+	}
+	// Tables only list the errors.
+	if c.output != outputTable || len(rows) > 0 {
+		if err := c.print(result, []string{"LINE", "CODE", "ERROR"}, rows); err != nil {
+			return err
+		}
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%w: %d imported, %d failed", errImportFailed, result.Imported, result.Failed)
+	}
+	c.printMessage("%d imported", result.Imported)
+	return nil
+}
+
+type exportFunc func(ctx context.Context, w io.Writer, format client.Format) error
+
+// export runs "export [-format F]", writing to stdout.
+func (c *cli) export(ctx context.Context, fs *flag.FlagSet, args []string, exportFn exportFunc) error {
+	formatName := formatFlag(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("%w: %s expects no argument", errUsage, fs.Name())
+	}
+	format, err := exchangeFormat(*formatName, "")
+	if err != nil {
+		return err
+	}
+	return exportFn(ctx, c.stdout, format)
+}
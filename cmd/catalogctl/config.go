@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"github.com/sgaunet/template-api/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultURL = "http://localhost:3000"
+
+// Output formats.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var errInvalidOutput = errors.New("output must be table, json or yaml")
+
+// config is the configuration of catalogctl, read from a YAML file and
+// overridden by environment variables then flags.
+type config struct {
+	// URL is the base URL of the API (empty means default).
+	URL string `env:"CATALOGCTL_URL" yaml:"url"`
+	// Token is sent as a bearer token in the Authorization header.
+	Token string `env:"CATALOGCTL_TOKEN" yaml:"token"`
+	// Output is the output format: table (default), json or yaml.
+	Output string `env:"CATALOGCTL_OUTPUT" yaml:"output"`
+	// Timeout is the timeout of each request (0 means default).
+	Timeout time.Duration `env:"CATALOGCTL_TIMEOUT" yaml:"timeout"`
+}
+
+// defaultConfigFile returns the path of the default config file, in the
+// user configuration directory.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "catalogctl", "config.yaml")
+}
+
+// loadConfig loads filename if it exists and applies the environment
+// variables of environ, or of the process when nil.
+func loadConfig(filename string, environ map[string]string) (config, error) {
+	var cfg config
+	if filename != "" {
+		if _, err := os.Stat(filename); err == nil {
+			data, err := os.ReadFile(filename) //nolint:gosec // the file is chosen by the user
+			if err != nil {
+				return cfg, fmt.Errorf("could not read config file: %w", err)
+			}
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return cfg, fmt.Errorf("could not parse config file: %w", err)
+			}
+		}
+	}
+
+	// A nil Environment means the process environment.
+	if err := env.ParseWithOptions(&cfg, env.Options{Environment: environ}); err != nil {
+		return cfg, fmt.Errorf("error parsing environment variables: %w", err)
+	}
+	return cfg, nil
+}
+
+// override replaces the settings set by flags.
+func (c *config) override(flags config) {
+	if flags.URL != "" {
+		c.URL = flags.URL
+	}
+	if flags.Token != "" {
+		c.Token = flags.Token
+	}
+	if flags.Output != "" {
+		c.Output = flags.Output
+	}
+	if flags.Timeout > 0 {
+		c.Timeout = flags.Timeout
+	}
+}
+
+func (c *config) validate() error {
+	if c.URL == "" {
+		c.URL = defaultURL
+	}
+	if c.Output == "" {
+		c.Output = outputTable
+	}
+	if !slices.Contains([]string{outputTable, outputJSON, outputYAML}, c.Output) {
+		return fmt.Errorf("%w: %q", errInvalidOutput, c.Output)
+	}
+	return nil
+}
+
+func (c *config) newClient() (*client.Client, error) {
+	timeout := client.DefaultTimeout
+	if c.Timeout > 0 {
+		timeout = c.Timeout
+	}
+	opts := []client.Option{client.WithHTTPClient(&http.Client{Timeout: timeout})}
+	if c.Token != "" {
+		opts = append(opts, client.WithHeader("Authorization", "Bearer "+c.Token))
+	}
+	cl, err := client.New(c.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	return cl, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/client"
+)
+
+// Exit codes, distinguishing the API errors by code so that scripts can
+// react to them.
+const (
+	exitOK         = 0
+	exitError      = 1 // e.g. the API is unreachable
+	exitUsage      = 2
+	exitValidation = 3
+	exitNotFound   = 4
+	exitServer     = 5
+	exitConflict   = 6
+	exitAuth       = 7
+)
+
+// errImportFailed is returned when lines of an import are invalid.
+var errImportFailed = errors.New("some lines were not imported")
+
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage), errors.Is(err, errUnknownCommand):
+		return exitUsage
+	case errors.Is(err, errImportFailed):
+		return exitValidation
+	}
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return exitError
+	}
+	switch apiErr.Code {
+	case apperror.ErrCodeValidation, apperror.ErrCodeBadRequest:
+		return exitValidation
+	case apperror.ErrCodeNotFound:
+		return exitNotFound
+	case apperror.ErrCodeConflict:
+		return exitConflict
+	case apperror.ErrCodeUnauthorized, apperror.ErrCodeForbidden:
+		return exitAuth
+	default:
+		return exitServer
+	}
+}
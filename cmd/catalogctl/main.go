@@ -0,0 +1,128 @@
+// Command catalogctl manages the authors and books of the catalog through
+// the HTTP API.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sgaunet/template-api/pkg/client"
+)
+
+var version = "development"
+
+var (
+	errUsage          = errors.New("invalid usage")
+	errUnknownCommand = errors.New("unknown command")
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, nil)
+	stop()
+	os.Exit(code)
+}
+
+// cli holds the streams and settings of a command.
+type cli struct {
+	client *client.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	output string
+}
+
+// run runs the command of args and returns the exit code. environ
+// overrides the environment variables, nil means the process environment.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, environ map[string]string) int {
+	fs := flag.NewFlagSet("catalogctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		cfgFile     string
+		versionFlag bool
+		flags       config
+	)
+	fs.StringVar(&cfgFile, "config", defaultConfigFile(), "config file")
+	fs.StringVar(&flags.URL, "url", "", "API base URL (env CATALOGCTL_URL, default "+defaultURL+")")
+	fs.StringVar(&flags.Token, "token", "", "bearer token (env CATALOGCTL_TOKEN)")
+	fs.StringVar(&flags.Output, "o", "", "output format: table, json or yaml (env CATALOGCTL_OUTPUT, default table)")
+	fs.DurationVar(&flags.Timeout, "timeout", 0, "timeout of each request (env CATALOGCTL_TIMEOUT, default 30s)")
+	fs.BoolVar(&versionFlag, "version", false, "Print version and exit")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return exitCode(fmt.Errorf("%w: %w", errUsage, err))
+	}
+
+	if versionFlag {
+		fmt.Fprintln(stdout, version)
+		return exitOK
+	}
+
+	cfg, err := loadConfig(cfgFile, environ)
+	if err != nil {
+		fmt.Fprintf(stderr, "configuration error: %v\n", err)
+		return exitUsage
+	}
+	cfg.override(flags)
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintf(stderr, "configuration error: %v\n", err)
+		return exitUsage
+	}
+	c, err := cfg.newClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "configuration error: %v\n", err)
+		return exitUsage
+	}
+
+	cmd := &cli{client: c, stdin: stdin, stdout: stdout, stderr: stderr, output: cfg.Output}
+	if err := cmd.run(ctx, fs.Args()); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, errUnknownCommand) {
+			fs.Usage()
+		}
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stderr, "%v\n", err)
+		}
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+func (c *cli) run(ctx context.Context, args []string) error {
+	if len(args) < 2 { //nolint:mnd // resource and command
+		return fmt.Errorf("%w: expected a resource and a command", errUsage)
+	}
+	switch args[0] {
+	case "authors":
+		return c.authors(ctx, args[1], args[2:])
+	case "books":
+		return c.books(ctx, args[1], args[2:])
+	default:
+		return fmt.Errorf("%w: %s", errUnknownCommand, args[0])
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "Usage: catalogctl [flags] RESOURCE COMMAND [args]\n\n")
+	fmt.Fprintf(out, "Resources: authors, books\n\n")
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  list                           list all the items\n")
+	fmt.Fprintf(out, "  get ID                         show an item\n")
+	fmt.Fprintf(out, "  create FIELDS                  create an item\n")
+	fmt.Fprintf(out, "  update ID FIELDS               change the given fields of an item\n")
+	fmt.Fprintf(out, "  delete ID                      delete an item\n")
+	fmt.Fprintf(out, "  import [-format csv|ndjson] FILE  create items from a file (- for stdin)\n")
+	fmt.Fprintf(out, "  export [-format csv|ndjson]    write all the items to stdout\n\n")
+	fmt.Fprintf(out, "Fields:\n")
+	fmt.Fprintf(out, "  authors: -name NAME -bio BIO\n")
+	fmt.Fprintf(out, "  books:   -title TITLE -author AUTHOR_ID\n\n")
+	fmt.Fprintf(out, "Exit codes: 1 error, 2 usage, 3 validation, 4 not found, 5 server, 6 conflict, 7 unauthorized\n\n")
+	fmt.Fprintf(out, "Flags:\n")
+	fs.PrintDefaults()
+}
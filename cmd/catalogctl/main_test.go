@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeRepository is an in-memory authors.Repository.
+type fakeRepository struct {
+	authors.Repository
+
+	mu      sync.Mutex
+	authors map[int64]*authors.Author
+	nextID  int64
+}
+
+func (f *fakeRepository) Create(_ context.Context, a *authors.Author) (*authors.Author, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	created := &authors.Author{ID: f.nextID, Name: a.Name, Bio: a.Bio}
+	f.authors[created.ID] = created
+	return created, nil
+}
+
+func (f *fakeRepository) CreateBatch(ctx context.Context, list []*authors.Author) ([]*authors.Author, error) {
+	created := make([]*authors.Author, len(list))
+	for i, a := range list {
+		created[i], _ = f.Create(ctx, a)
+	}
+	return created, nil
+}
+
+func (f *fakeRepository) GetByID(_ context.Context, id int64) (*authors.Author, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a, ok := f.authors[id]
+	if !ok {
+		return nil, apperror.NewNotFoundError("Author not found")
+	}
+	return a, nil
+}
+
+func (f *fakeRepository) ListPage(_ context.Context, after pagination.Cursor, limit int) ([]*authors.Author, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var list []*authors.Author
+	for _, a := range f.authors {
+		if cmp.Or(strings.Compare(a.Name, after.Key), cmp.Compare(a.ID, after.ID)) > 0 {
+			list = append(list, a)
+		}
+	}
+	slices.SortFunc(list, func(a, b *authors.Author) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return list[:min(limit, len(list))], nil
+}
+
+func (f *fakeRepository) Stream(ctx context.Context, fn func(*authors.Author) error) error {
+	list, _ := f.ListPage(ctx, pagination.Cursor{}, len(f.authors))
+	for _, a := range list {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepository) Update(ctx context.Context, a *authors.Author) (*authors.Author, error) {
+	if _, err := f.GetByID(ctx, a.ID); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authors[a.ID] = a
+	return a, nil
+}
+
+func (f *fakeRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := f.GetByID(ctx, id); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.authors, id)
+	return nil
+}
+
+// newServer serves the real routes with in-memory authors. Requests are
+// checked to carry the token "secret".
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	repo := &fakeRepository{authors: map[int64]*authors.Author{}}
+	w, err := webserver.NewWebServer(authors.NewHandler(authors.NewService(repo)), nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			apperror.WriteError(rw, &apperror.AppError{Code: apperror.ErrCodeUnauthorized, Message: "Invalid token"})
+			return
+		}
+		w.Handler().ServeHTTP(rw, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+func runCommand(t *testing.T, environ map[string]string, stdin string, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), append([]string{"-config", ""}, args...), strings.NewReader(stdin), &stdout, &stderr, environ)
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func TestAuthors(t *testing.T) {
+	srv := newServer(t)
+	environ := map[string]string{"CATALOGCTL_URL": srv.URL, "CATALOGCTL_TOKEN": "secret"}
+
+	res := runCommand(t, environ, "", "authors", "create", "-name", "Mary Shelley", "-bio", "Frankenstein")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Equal(t, "ID  NAME          BIO\n1   Mary Shelley  Frankenstein\n", res.stdout)
+
+	res = runCommand(t, environ, "", "authors", "update", "1", "-name", "Mary W. Shelley")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	res = runCommand(t, environ, "", "-o", "json", "authors", "get", "1")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.JSONEq(t, `{"id": 1, "name": "Mary W. Shelley", "bio": "Frankenstein"}`, res.stdout, "unset fields are kept")
+
+	res = runCommand(t, environ, "name,bio\nBram Stoker,Dracula\n", "authors", "import", "-format", "csv", "-")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Equal(t, "1 imported\n", res.stdout)
+
+	environ["CATALOGCTL_OUTPUT"] = "yaml"
+	res = runCommand(t, environ, "", "authors", "list")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	var list []map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(res.stdout), &list))
+	assert.Equal(t, []map[string]any{
+		{"id": 2, "name": "Bram Stoker", "bio": "Dracula"},
+		{"id": 1, "name": "Mary W. Shelley", "bio": "Frankenstein"},
+	}, list)
+
+	res = runCommand(t, environ, "", "authors", "export", "-format", "csv")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Equal(t, "id,name,bio\n2,Bram Stoker,Dracula\n1,Mary W. Shelley,Frankenstein\n", res.stdout)
+
+	res = runCommand(t, environ, "", "-o", "table", "authors", "delete", "2")
+	require.Equal(t, exitOK, res.code, res.stderr)
+	assert.Equal(t, "author 2 deleted\n", res.stdout)
+}
+
+func TestExitCodes(t *testing.T) {
+	srv := newServer(t)
+	environ := map[string]string{"CATALOGCTL_URL": srv.URL, "CATALOGCTL_TOKEN": "secret"}
+	importFile := filepath.Join(t.TempDir(), "authors.ndjson")
+	require.NoError(t, os.WriteFile(importFile, []byte(`{"name":"Ann"}`+"\n"), 0o600))
+
+	for name, tc := range map[string]struct {
+		environ map[string]string
+		args    []string
+		code    int
+		stderr  string
+	}{
+		"help":             {args: []string{"-h"}, code: exitOK},
+		"version":          {args: []string{"-version"}, code: exitOK},
+		"missing command":  {args: []string{"authors"}, code: exitUsage},
+		"unknown resource": {args: []string{"publishers", "list"}, code: exitUsage},
+		"unknown command":  {args: []string{"authors", "rename"}, code: exitUsage},
+		"invalid id":       {args: []string{"authors", "get", "abc"}, code: exitUsage},
+		"invalid flag":     {args: []string{"authors", "create", "-title", "Dune"}, code: exitUsage},
+		"invalid output":   {args: []string{"-o", "xml", "authors", "list"}, code: exitUsage},
+		"validation": {
+			args:   []string{"authors", "create", "-name", "Ann"},
+			code:   exitValidation,
+			stderr: "VALIDATION_ERROR: Author name too short\n",
+		},
+		"import errors": {args: []string{"authors", "import", importFile}, code: exitValidation},
+		"not found": {
+			args:   []string{"authors", "get", "42"},
+			code:   exitNotFound,
+			stderr: "NOT_FOUND: Author not found\n",
+		},
+		"unauthorized": {
+			environ: map[string]string{"CATALOGCTL_URL": srv.URL, "CATALOGCTL_TOKEN": "wrong"},
+			args:    []string{"authors", "list"},
+			code:    exitAuth,
+		},
+		"server error": {
+			// The books routes have no handler: the panic is recovered as an internal error.
+			args: []string{"books", "get", "1"},
+			code: exitServer,
+		},
+		"unreachable": {
+			environ: map[string]string{"CATALOGCTL_URL": "http://127.0.0.1:1"},
+			args:    []string{"authors", "list"},
+			code:    exitError,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := environ
+			if tc.environ != nil {
+				env = tc.environ
+			}
+			res := runCommand(t, env, "", tc.args...)
+			assert.Equal(t, tc.code, res.code, res.stderr)
+			if tc.stderr != "" {
+				assert.Equal(t, tc.stderr, res.stderr)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("url: http://file:3000\ntoken: file-token\noutput: json\n"), 0o600))
+
+	cfg, err := loadConfig(file, map[string]string{"CATALOGCTL_TOKEN": "env-token"})
+	require.NoError(t, err)
+	assert.Equal(t, config{URL: "http://file:3000", Token: "env-token", Output: "json"}, cfg, "env overrides the file")
+
+	cfg.override(config{Output: "yaml"})
+	assert.Equal(t, config{URL: "http://file:3000", Token: "env-token", Output: "yaml"}, cfg, "flags override env")
+
+	cfg, err = loadConfig(filepath.Join(t.TempDir(), "missing.yaml"), map[string]string{})
+	require.NoError(t, err)
+	require.NoError(t, cfg.validate())
+	assert.Equal(t, config{URL: defaultURL, Output: outputTable}, cfg)
+}
+
+func TestPrint_JSONList(t *testing.T) {
+	var out bytes.Buffer
+	c := &cli{stdout: &out, output: outputJSON}
+	require.NoError(t, c.print([]int{}, nil, nil))
+	var list []int
+	require.NoError(t, json.Unmarshal(out.Bytes(), &list))
+	assert.Empty(t, list)
+}
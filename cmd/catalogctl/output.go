@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// print writes v in the output format. Tables show header and rows.
+func (c *cli) print(v any, header []string, rows [][]string) error {
+	switch c.output {
+	case outputJSON:
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	case outputYAML:
+		encoder := yaml.NewEncoder(c.stdout)
+		encoder.SetIndent(2) //nolint:mnd
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	default:
+		w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0) //nolint:mnd
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("could not write output: %w", err)
+		}
+	}
+	return nil
+}
+
+// printMessage writes a confirmation of a command without result, in
+// table output only.
+func (c *cli) printMessage(format string, args ...any) {
+	if c.output == outputTable {
+		fmt.Fprintf(c.stdout, format+"\n", args...)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sgaunet/template-api/pkg/config"
+)
+
+const tabPadding = 2
+
+var (
+	errMissingConfigCommand = errors.New("missing config command (print, validate)")
+	errInvalidConfigFiles   = errors.New("invalid configuration files")
+)
+
+// configCommand runs the config subcommands.
+func configCommand(cfgFile string, args []string) error {
+	if len(args) == 0 {
+		return errMissingConfigCommand
+	}
+	switch args[0] {
+	case "print":
+		return printConfig(cfgFile)
+	case "validate":
+		files := args[1:]
+		if len(files) == 0 {
+			files = []string{cfgFile}
+		}
+		return validateConfig(files)
+	default:
+		return fmt.Errorf("%w: config %s", errUnknownCommand, args[0])
+	}
+}
+
+// printConfig prints the effective configuration, with the source of each
+// value and the secrets redacted. It isn't validated, to help fixing it.
+func printConfig(cfgFile string) error {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return fmt.Errorf("error loading configuration: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, tabPadding, ' ', 0)
+	fmt.Fprintln(w, "KEY\tENV\tVALUE\tSOURCE")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Env, s.Value, s.Source)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("could not print configuration: %w", err)
+	}
+	return nil
+}
+
+// validateConfig loads and validates configuration files, with the
+// environment variables, and prints their errors. It fails if any file is
+// invalid, to be used in CI.
+func validateConfig(files []string) error {
+	var invalid int
+	for _, file := range files {
+		name := file
+		if name == "" {
+			name = "environment"
+		}
+		cfg, err := config.Load(file)
+		if err == nil {
+			err = cfg.Validate()
+		}
+		if err == nil {
+			fmt.Printf("%s: valid\n", name)
+			continue
+		}
+
+		invalid++
+		fmt.Printf("%s: invalid\n", name)
+		var validationErr *config.ValidationError
+		if !errors.As(err, &validationErr) {
+			fmt.Printf("  %s\n", strings.ReplaceAll(err.Error(), "\n", "\n  "))
+			continue
+		}
+		for _, fieldErr := range validationErr.Errors {
+			fmt.Printf("  %v\n", fieldErr)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%w: %d of %d", errInvalidConfigFiles, invalid, len(files))
+	}
+	return nil
+}
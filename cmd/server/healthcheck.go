@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// healthcheckURL is the readiness endpoint of the local server.
+	healthcheckURL     = "http://127.0.0.1:3000/ready"
+	healthcheckTimeout = 3 * time.Second
+	// healthcheckBodySize limits the response body included in errors.
+	healthcheckBodySize = 512
+)
+
+var errNotReady = errors.New("server is not ready")
+
+// healthcheck probes the readiness endpoint of the local server, so that
+// the binary can be used as its own container healthcheck: the scratch
+// image has no curl. It fails unless the server answers 200 in time.
+func healthcheck(args []string) error {
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	url := fs.String("url", healthcheckURL, "readiness endpoint")
+	timeout := fs.Duration("timeout", healthcheckTimeout, "timeout of the request")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid healthcheck url: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("healthcheck failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, healthcheckBodySize))
+		return fmt.Errorf("%w: %s: %s", errNotReady, resp.Status, body)
+	}
+	return nil
+}
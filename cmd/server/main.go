@@ -3,8 +3,11 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,10 +15,23 @@ import (
 
 	"github.com/go-redis/redis/v8"
 	"github.com/sgaunet/dsn/v2/pkg/dsn"
+	"github.com/sgaunet/template-api/internal/cache"
 	"github.com/sgaunet/template-api/internal/database"
+	"github.com/sgaunet/template-api/internal/jobs"
+	"github.com/sgaunet/template-api/internal/maintenance"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/outbox"
 	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/internal/scheduler"
+	"github.com/sgaunet/template-api/pkg/audit"
 	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
 	"github.com/sgaunet/template-api/pkg/config"
+	"github.com/sgaunet/template-api/pkg/events"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/sgaunet/template-api/pkg/graph"
+	"github.com/sgaunet/template-api/pkg/grpcserver"
+	"github.com/sgaunet/template-api/pkg/webhooks"
 	"github.com/sgaunet/template-api/pkg/webserver"
 )
 
@@ -28,6 +44,8 @@ const (
 
 var version = "development"
 
+var errUnknownCommand = errors.New("unknown command")
+
 func printVersion() {
 	fmt.Printf("%s\n", version)
 }
@@ -46,6 +64,7 @@ func run() error {
 	)
 	flag.BoolVar(&versionFlag, "version", false, "Print version and exit")
 	flag.StringVar(&cfgFile, "config", "", "config file")
+	flag.Usage = usage
 	flag.Parse()
 
 	if versionFlag {
@@ -53,12 +72,59 @@ func run() error {
 		os.Exit(0)
 	}
 
+	// serve is the default command
+	command, args := "serve", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		return serve(cfgFile)
+	case "migrate":
+		return migrate(cfgFile, args)
+	case "healthcheck":
+		return healthcheck(args)
+	case "config":
+		return configCommand(cfgFile, args)
+	default:
+		flag.Usage()
+		return fmt.Errorf("%w: %s", errUnknownCommand, command)
+	}
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [flags] [command]\n\n", os.Args[0])
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  serve                   start the API server (default)\n")
+	fmt.Fprintf(out, "  migrate up              apply pending migrations\n")
+	fmt.Fprintf(out, "  migrate down            rollback the last applied migration\n")
+	fmt.Fprintf(out, "  migrate status          show the status of migrations\n")
+	fmt.Fprintf(out, "  migrate new [-dir] NAME create a new migration file\n")
+	fmt.Fprintf(out, "  config print            print the configuration and the source of each value\n")
+	fmt.Fprintf(out, "  config validate [FILE...]\n")
+	fmt.Fprintf(out, "                          check configuration files (default -config)\n")
+	fmt.Fprintf(out, "  healthcheck [-url] [-timeout]\n")
+	fmt.Fprintf(out, "                          exit 1 unless the local server is ready\n\n")
+	fmt.Fprintf(out, "Flags:\n")
+	flag.PrintDefaults()
+}
+
+func serve(cfgFile string) error {
 	// load configuration
 	cfg, err := loadConfiguration(cfgFile)
 	if err != nil {
 		return fmt.Errorf("configuration error: %w", err)
 	}
 
+	// settings applied again when the configuration is reloaded
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(cfg.Level())
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	rateLimiter := middleware.NewRateLimiter(cfg.RateLimit, cfg.RateLimitBurst)
+	cors := middleware.NewCORS(cfg.CORSAllowedOrigins)
+
 	// init database
 	pg, err := initDB(cfg)
 	if err != nil {
@@ -70,16 +136,142 @@ func run() error {
 		}
 	}()
 
+	// route read-only queries to replicas
+	router, err := database.NewRouter(pg.GetDB(), cfg.DBReplicaDSNs, cfg.DBReplicaHealthCheckInterval,
+		dbOptions(cfg)...)
+	if err != nil {
+		return fmt.Errorf("error creating database router: %w", err)
+	}
+	defer func() {
+		if err := router.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error closing database replicas: %v\n", err)
+		}
+	}()
+
 	// init services
-	queries := repository.New(pg.GetDB())
+	queries := repository.New(router)
+
+	// feature flags of the configuration, overridden by the database ones
+	featureFlags := flags.NewStore(flags.NewRepository(queries),
+		flags.WithDefaults(webserver.Features),
+		flags.WithConfig(cfg.FeatureFlags),
+		flags.WithRefreshInterval(cfg.FlagsRefreshInterval))
+	defer startWorker(featureFlags.Run)()
+	reloader := config.NewReloader(cfgFile, cfg, func(cfg config.Config) {
+		logLevel.Set(cfg.Level())
+		rateLimiter.SetLimit(cfg.RateLimit, cfg.RateLimitBurst)
+		cors.SetOrigins(cfg.CORSAllowedOrigins)
+		featureFlags.SetConfig(cfg.FeatureFlags)
+	})
+	defer startWorker(reloader.Run)()
+
+	// publish domain events, always recorded in the outbox and relayed to the
+	// change stream and webhooks; OutboxEnabled adds the Redis stream
+	broker := events.NewBroker(
+		events.WithReplaySize(cfg.EventsReplaySize),
+		events.WithClientBuffer(cfg.EventsClientBuffer))
+	webhooksRepo := webhooks.NewRepository(queries)
+	relay, stream, closeOutbox, err := initOutbox(cfg, pg.GetDB(), webhooksRepo, broker)
+	if err != nil {
+		return err
+	}
+	defer closeOutbox()
+	tx := repository.NewTransactor(pg.GetDB())
+	authorsRepoOpts := []authors.RepositoryOption{authors.WithOutbox(tx)}
+	booksRepoOpts := []books.RepositoryOption{books.WithOutbox(tx)}
+	defer startWorker(relay.Run)()
+	if stream != nil {
+		// every instance streams the events relayed by any instance
+		defer startWorker(func(ctx context.Context) { feedBroker(ctx, stream, broker) })()
+	}
+	dispatcherOpts := []webhooks.DispatcherOption{
+		webhooks.WithMaxAttempts(cfg.WebhooksMaxAttempts),
+		webhooks.WithTimeout(cfg.WebhooksTimeout),
+	}
+	var webhooksServiceOpts []webhooks.ServiceOption
+	if cfg.WebhooksAllowPrivate {
+		dispatcherOpts = append(dispatcherOpts, webhooks.WithPrivateDestinations())
+		webhooksServiceOpts = append(webhooksServiceOpts, webhooks.WithPrivateSubscriptions())
+	}
+	if cfg.WebhooksEnabled {
+		dispatcher := webhooks.NewDispatcher(webhooksRepo, dispatcherOpts...)
+		defer startWorker(dispatcher.Run)()
+	}
+
+	// run background jobs, running jobs complete before the database is closed.
+	// The maintenance tasks can be run once on demand as jobs of their name.
+	maintenanceTasks := initMaintenanceTasks(cfg, queries)
+	jobsPool := jobs.NewPool(jobs.NewStore(queries),
+		jobs.WithConcurrency(cfg.JobsConcurrency),
+		jobs.WithPollInterval(cfg.JobsPollInterval))
+	for _, task := range maintenanceTasks {
+		jobsPool.Register(task.name, func(ctx context.Context, _ *jobs.Job) error {
+			return task.run(ctx)
+		})
+	}
+	defer startWorker(jobsPool.Run)()
+
+	// run periodic maintenance on the elected instance
+	if !cfg.SchedulerDisabled {
+		sched, err := initScheduler(pg.GetDB(), queries, maintenanceTasks)
+		if err != nil {
+			return err
+		}
+		defer startWorker(sched.Run)()
+	}
 
 	// Authors domain
-	authorsRepo := authors.NewRepository(queries)
-	authorsService := authors.NewService(authorsRepo)
+	authorsCache, closeCache, err := initCache(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeCache()
+	authorsRepo := authors.NewRepository(queries, authorsRepoOpts...)
+	if authorsCache != nil {
+		authorsRepo = authors.NewCachedRepository(authorsRepo, authorsCache, cfg.CacheTTL)
+	}
+	authorsService := authors.NewService(authorsRepo,
+		authors.WithMaxBatchSize(cfg.AuthorsBatchMaxSize))
 	authorsHandler := authors.NewHandler(authorsService)
 
+	// Books domain
+	booksRepo := books.NewRepository(queries, booksRepoOpts...)
+	booksService := books.NewService(booksRepo)
+	booksHandler := books.NewHandler(booksService)
+
+	// Webhooks
+	webhooksHandler := webhooks.NewHandler(webhooks.NewService(webhooksRepo, webhooksServiceOpts...))
+
+	// Change stream
+	eventsHandler := events.NewHandler(broker, cfg.EventsHeartbeatInterval)
+
+	// GraphQL
+	graphqlHandler := graph.NewHandler(authorsService, booksService,
+		graph.WithMaxDepth(cfg.GraphQLMaxDepth),
+		graph.WithMaxComplexity(cfg.GraphQLMaxComplexity),
+		graph.WithLoaderMaxBatch(min(graph.DefaultLoaderMaxBatch, cfg.AuthorsBatchMaxSize)),
+		graph.WithBooksFlag(webserver.FeatureBooks))
+
+	// Audit log
+	auditHandler := audit.NewHandler(audit.NewService(audit.NewRepository(queries)))
+
 	// init webserver
-	w, err := webserver.NewWebServer(authorsHandler)
+	webserverOpts := []webserver.Option{
+		webserver.WithReadinessCheck("database", pg.GetDB().PingContext),
+		webserver.WithAdminToken(cfg.AdminToken),
+		webserver.WithMiddleware(cors.Handler, rateLimiter.Handler),
+		webserver.WithFlags(featureFlags),
+		webserver.WithSchedulerRuns(scheduler.NewHandler(queries)),
+	}
+	switch {
+	case cfg.ValidateResponses:
+		webserverOpts = append(webserverOpts, webserver.WithResponseValidation())
+	case cfg.ValidateRequests:
+		webserverOpts = append(webserverOpts, webserver.WithRequestValidation())
+	}
+	w, err := webserver.NewWebServer(
+		authorsHandler, booksHandler, webhooksHandler, eventsHandler, graphqlHandler, auditHandler,
+		webserverOpts...)
 	if err != nil {
 		return fmt.Errorf("error creating webserver: %w", err)
 	}
@@ -93,14 +285,39 @@ func run() error {
 		serverErr <- w.Start()
 	}()
 
+	// gRPC server, alongside the webserver
+	var grpcServer *grpcserver.Server
+	grpcErr := make(chan error, 1)
+	if cfg.GRPCEnabled {
+		grpcServer = grpcserver.NewServer(authorsService, booksService,
+			grpcserver.WithFlags(featureFlags, webserver.FeatureBooks))
+		if cfg.GRPCListenAddr != "" {
+			grpcServer.SetListenAddr(cfg.GRPCListenAddr)
+		}
+		go func() {
+			grpcErr <- grpcServer.Start()
+		}()
+	}
+
 	select {
 	case err := <-serverErr:
 		if err != nil {
 			return fmt.Errorf("error starting webserver: %w", err)
 		}
+	case err := <-grpcErr:
+		if err != nil {
+			return fmt.Errorf("error starting grpc server: %w", err)
+		}
 	case <-sigs:
 	}
 
+	if grpcServer != nil {
+		if err := grpcServer.Shutdown(context.TODO()); err != nil {
+			return fmt.Errorf("error shutting down grpc server: %w", err)
+		}
+	}
+	// end event streams, they would keep the server from shutting down
+	broker.Close()
 	if err := w.Shutdown(context.TODO()); err != nil {
 		return fmt.Errorf("error shutting down webserver: %w", err)
 	}
@@ -108,7 +325,6 @@ func run() error {
 	return nil
 }
 
-//nolint:unused
 func initRedisConnection(redisdsn string) (*redis.Client, error) {
 	var err error
 	d, err := dsn.New(redisdsn)
@@ -117,7 +333,8 @@ func initRedisConnection(redisdsn string) (*redis.Client, error) {
 	}
 	addr := fmt.Sprintf("%s:%s", d.GetHost(), d.GetPort("6379"))
 	redisClient := redis.NewClient(&redis.Options{
-		Addr: addr,
+		Addr:     addr,
+		Password: d.GetPassword(),
 	})
 	_, err = redisClient.Ping(context.TODO()).Result()
 	if err != nil {
@@ -126,7 +343,147 @@ func initRedisConnection(redisdsn string) (*redis.Client, error) {
 	return redisClient, nil
 }
 
+// initCache creates the cache configured by CacheBackend, nil if caching is disabled.
+// The returned function releases the cache resources, it is never nil.
+func initCache(cfg config.Config) (cache.Cache, func(), error) {
+	switch cfg.CacheBackend {
+	case "memory":
+		return cache.WithMetrics(cache.NewLRU(cfg.CacheSize), cfg.CacheBackend), func() {}, nil
+	case "redis":
+		redisClient, err := initRedisConnection(cfg.RedisDSN)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error initializing redis cache: %w", err)
+		}
+		closeRedis := func() {
+			if err := redisClient.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "error closing redis: %v\n", err)
+			}
+		}
+		return cache.WithMetrics(cache.NewRedis(redisClient, "template-api:"), cfg.CacheBackend), closeRedis, nil
+	default:
+		return nil, func() {}, nil
+	}
+}
+
+// initOutbox creates the relay publishing domain events to the Redis stream,
+// to webhooks and to the change stream clients. When the Redis stream is
+// enabled, it is returned to feed the change stream of every instance.
+// The returned function releases the relay resources, it is never nil.
+func initOutbox(
+	cfg config.Config, db *sql.DB, webhooksRepo webhooks.Repository, broker *events.Broker,
+) (*outbox.Relay, *outbox.RedisStreams, func(), error) {
+	var (
+		publishers outbox.Publishers
+		stream     *outbox.RedisStreams
+	)
+	closeRedis := func() {}
+	if cfg.OutboxEnabled {
+		redisClient, err := initRedisConnection(cfg.RedisDSN)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error initializing outbox publisher: %w", err)
+		}
+		closeRedis = func() {
+			if err := redisClient.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "error closing redis: %v\n", err)
+			}
+		}
+		stream = outbox.NewRedisStreams(redisClient, cfg.OutboxStream)
+		publishers = append(publishers, stream)
+	} else {
+		publishers = append(publishers, broker)
+	}
+	if cfg.WebhooksEnabled {
+		publishers = append(publishers, webhooks.NewPublisher(webhooksRepo))
+	}
+	relay := outbox.NewRelay(repository.NewTransactor(db), publishers,
+		outbox.WithPollInterval(cfg.OutboxPollInterval),
+		outbox.WithBatchSize(cfg.OutboxBatchSize))
+	return relay, stream, closeRedis, nil
+}
+
+// feedBroker publishes the events of the Redis stream to the change stream
+// clients until ctx is cancelled.
+func feedBroker(ctx context.Context, stream *outbox.RedisStreams, broker *events.Broker) {
+	for ctx.Err() == nil {
+		err := stream.Subscribe(ctx, func(ev outbox.CloudEvent) error {
+			return broker.Publish(ctx, ev)
+		})
+		if err != nil {
+			slog.Error("change stream subscription failed", "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// maintenanceTask is a maintenance task, scheduled with spec.
+type maintenanceTask struct {
+	name, spec string
+	run        scheduler.Task
+}
+
+// initMaintenanceTasks creates the maintenance tasks.
+func initMaintenanceTasks(cfg config.Config, queries repository.Querier) []maintenanceTask {
+	tasks := maintenance.NewTasks(queries, cfg.MaintenanceRetention)
+	return []maintenanceTask{
+		{"purge-outbox", "@hourly", tasks.PurgeOutbox},
+		{"purge-jobs", "@hourly", tasks.PurgeJobs},
+		{"purge-webhook-deliveries", "@hourly", tasks.PurgeWebhookDeliveries},
+		{"purge-scheduler-runs", "@hourly", tasks.PurgeSchedulerRuns},
+		{"refresh-statistics", "30 3 * * *", tasks.RefreshStatistics},
+	}
+}
+
+// initScheduler creates the scheduler of the maintenance tasks.
+func initScheduler(db *sql.DB, queries repository.Querier, tasks []maintenanceTask) (*scheduler.Scheduler, error) {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	sched := scheduler.New(scheduler.NewPostgresElector(db, 0), scheduler.NewHistory(queries, instance))
+	for _, task := range tasks {
+		if err := sched.Register(task.name, task.spec, task.run); err != nil {
+			return nil, fmt.Errorf("error registering scheduled task: %w", err)
+		}
+	}
+	return sched, nil
+}
+
+// startWorker runs a background worker until the returned function is called.
+func startWorker(run func(ctx context.Context)) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 func initDB(cfg config.Config) (*database.Postgres, error) {
+	pg, err := connectDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DBDisableAutoMigrate {
+		return pg, nil
+	}
+	// init database (create tables, etc...)
+	if err = pg.InitDB(); err != nil {
+		if errClose := pg.Close(); errClose != nil {
+			fmt.Fprintf(os.Stderr, "Error closing database during initDB failure: %s\n", errClose)
+		}
+		return nil, fmt.Errorf("error initializing database: %w", err)
+	}
+	return pg, nil
+}
+
+func connectDB(cfg config.Config) (*database.Postgres, error) {
 	// wait for database
 	waitCtx, cancel := context.WithDeadline(context.Background(), time.Now().Add(waitForDB))
 	defer cancel()
@@ -134,20 +491,28 @@ func initDB(cfg config.Config) (*database.Postgres, error) {
 		return nil, fmt.Errorf("error waiting for database: %w", err)
 	}
 	// init database connection
-	pg, err := database.NewPostgres(cfg.DBDSN)
+	pg, err := database.NewPostgres(cfg.DBDSN, dbOptions(cfg)...)
 	if err != nil {
 		return nil, fmt.Errorf("connection to database failed: %w", err)
 	}
-	// init database (create tables, etc...)
-	if err = pg.InitDB(); err != nil {
-		if errClose := pg.Close(); errClose != nil {
-			fmt.Fprintf(os.Stderr, "Error closing database during initDB failure: %s\n", errClose)
-		}
-		return nil, fmt.Errorf("error initializing database: %w", err)
-	}
+	pg.SetMigrationLockTimeout(cfg.DBMigrationLockTimeout)
 	return pg, nil
 }
 
+// dbOptions returns the connection options shared by the primary and the replicas.
+func dbOptions(cfg config.Config) []database.Option {
+	return []database.Option{
+		database.WithDriver(database.Driver(cfg.DBDriver)),
+		database.WithPool(database.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+		}),
+		database.WithStatementTimeout(cfg.DBStatementTimeout),
+	}
+}
+
 func loadConfiguration(cfgFile string) (config.Config, error) {
 	var (
 		err error
@@ -158,7 +523,7 @@ func loadConfiguration(cfgFile string) (config.Config, error) {
 		return config.Config{}, fmt.Errorf("error loading configuration: %w", err)
 	}
 
-		if err := cfg.Validate(); err != nil {
+	if err := cfg.Validate(); err != nil {
 		return config.Config{}, fmt.Errorf("invalid configuration: %w", err)
 	}
 	return cfg, nil
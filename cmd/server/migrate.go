@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sgaunet/template-api/internal/database"
+)
+
+// migrationsDir is the default directory of new migrations, relative to the repository root.
+const migrationsDir = "internal/database/db/migrations"
+
+var (
+	errMissingMigrateCommand = errors.New("missing migrate command (up, down, status, new)")
+	errMissingMigrationName  = errors.New("missing migration name")
+)
+
+// migrate runs the migrate subcommands.
+func migrate(cfgFile string, args []string) error {
+	if len(args) == 0 {
+		return errMissingMigrateCommand
+	}
+
+	switch args[0] {
+	case "new":
+		return newMigration(args[1:])
+	case "up", "down", "status":
+	default:
+		return fmt.Errorf("%w: migrate %s", errUnknownCommand, args[0])
+	}
+
+	cfg, err := loadConfiguration(cfgFile)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	pg, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := pg.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "error closing database: %v\n", err)
+		}
+	}()
+
+	switch args[0] {
+	case "up":
+		err = pg.MigrateUp(os.Stdout)
+	case "down":
+		err = pg.MigrateDown(os.Stdout)
+	default:
+		_, err = pg.MigrationStatus(os.Stdout)
+	}
+	if err != nil {
+		return fmt.Errorf("migrate %s failed: %w", args[0], err)
+	}
+	return nil
+}
+
+// newMigration creates a new migration file. It doesn't need a database connection.
+func newMigration(args []string) error {
+	fs := flag.NewFlagSet("migrate new", flag.ContinueOnError)
+	dir := fs.String("dir", migrationsDir, "migrations directory")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("could not parse flags: %w", err)
+	}
+	if fs.NArg() != 1 {
+		return errMissingMigrationName
+	}
+	if err := database.NewMigration(*dir, fs.Arg(0), os.Stdout); err != nil {
+		return fmt.Errorf("migrate new failed: %w", err)
+	}
+	return nil
+}
@@ -17,6 +17,8 @@ const (
 	ErrCodeUnauthorized ErrorCode = "UNAUTHORIZED"
 	ErrCodeForbidden    ErrorCode = "FORBIDDEN"
 	ErrCodeBadRequest   ErrorCode = "BAD_REQUEST"
+	// ErrCodeTooManyRequests is returned to clients exceeding the rate limit.
+	ErrCodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
 )
 
 // AppError represents a structured application error.
@@ -15,33 +15,34 @@ type ErrorResponse struct {
 
 // WriteError writes a structured error response.
 func WriteError(w http.ResponseWriter, err error) {
+	response, status := NewErrorResponse(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		// Failed to encode error response, nothing we can do
+		return
+	}
+}
+
+// NewErrorResponse converts an error to its HTTP response format and status code.
+func NewErrorResponse(err error) (ErrorResponse, int) {
 	var appErr *AppError
-	var status int
-	var response ErrorResponse
 
 	// Convert to AppError if possible
 	if errors.As(err, &appErr) {
-		response = ErrorResponse{
+		return ErrorResponse{
 			Code:    appErr.Code,
 			Message: appErr.Message,
 			Details: appErr.Details,
-		}
-		status = errorCodeToHTTPStatus(appErr.Code)
-	} else {
-		// Unknown error - return 500
-		response = ErrorResponse{
-			Code:    ErrCodeInternal,
-			Message: "An unexpected error occurred",
-		}
-		status = http.StatusInternalServerError
+		}, errorCodeToHTTPStatus(appErr.Code)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		// Failed to encode error response, nothing we can do
-		return
-	}
+	// Unknown error - return 500
+	return ErrorResponse{
+		Code:    ErrCodeInternal,
+		Message: "An unexpected error occurred",
+	}, http.StatusInternalServerError
 }
 
 func errorCodeToHTTPStatus(code ErrorCode) int {
@@ -60,6 +61,8 @@ func errorCodeToHTTPStatus(code ErrorCode) int {
 		return http.StatusForbidden
 	case ErrCodeBadRequest:
 		return http.StatusBadRequest
+	case ErrCodeTooManyRequests:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,47 @@
+// Package backoff computes retry delays with exponential backoff and jitter.
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Exponential computes exponentially growing delays with full jitter:
+// the delay of an attempt is a random duration between 0 and
+// min(Max, Initial * Multiplier^attempt).
+type Exponential struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// New creates an exponential backoff doubling the delay at each attempt.
+func New(initial, maxDelay time.Duration) Exponential {
+	return Exponential{
+		Initial:    initial,
+		Max:        maxDelay,
+		Multiplier: 2, //nolint:mnd
+	}
+}
+
+// Ceiling returns the upper bound of the delay of an attempt (starting at 0), without jitter.
+func (b Exponential) Ceiling(attempt int) time.Duration {
+	ceiling := float64(b.Initial)
+	for range attempt {
+		ceiling *= b.Multiplier
+		if ceiling >= float64(b.Max) {
+			return b.Max
+		}
+	}
+	return min(time.Duration(ceiling), b.Max)
+}
+
+// Delay returns the delay to wait before retrying an attempt (starting at 0).
+func (b Exponential) Delay(attempt int) time.Duration {
+	ceiling := b.Ceiling(attempt)
+	if ceiling <= 0 {
+		return 0
+	}
+	//nolint:gosec // jitter doesn't need a cryptographically secure source
+	return rand.N(ceiling + 1)
+}
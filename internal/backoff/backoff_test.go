@@ -0,0 +1,27 @@
+package backoff_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCeiling(t *testing.T) {
+	b := backoff.New(100*time.Millisecond, time.Second)
+	assert.Equal(t, 100*time.Millisecond, b.Ceiling(0))
+	assert.Equal(t, 200*time.Millisecond, b.Ceiling(1))
+	assert.Equal(t, 800*time.Millisecond, b.Ceiling(3))
+	assert.Equal(t, time.Second, b.Ceiling(4))
+	assert.Equal(t, time.Second, b.Ceiling(1000))
+}
+
+func TestDelayWithinCeiling(t *testing.T) {
+	b := backoff.New(100*time.Millisecond, time.Second)
+	for attempt := range 10 {
+		d := b.Delay(attempt)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, b.Ceiling(attempt))
+	}
+}
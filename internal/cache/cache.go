@@ -0,0 +1,17 @@
+// Package cache provides key/value caches with expiration, backed by memory or Redis.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values for a limited time.
+type Cache interface {
+	// Get returns the value of key, found is false when the key is missing or expired.
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value under key for ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys, missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,75 @@
+package cache_test
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_GetSetDelete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10)
+
+	_, found, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	value, found, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("1"), value)
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, found, _ = c.Get(ctx, "a")
+	assert.False(t, found)
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), time.Minute))
+	_, _, _ = c.Get(ctx, "a") // b is now the least recently used
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), time.Minute))
+
+	assert.Equal(t, 2, c.Len())
+	_, found, _ := c.Get(ctx, "b")
+	assert.False(t, found)
+	_, found, _ = c.Get(ctx, "a")
+	assert.True(t, found)
+}
+
+func TestLRU_Expires(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(2)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, found, _ := c.Get(ctx, "a")
+	assert.False(t, found)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestWithMetrics(t *testing.T) {
+	ctx := context.Background()
+	c := cache.WithMetrics(cache.NewLRU(2), "test")
+
+	_, _, _ = c.Get(ctx, "a")
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	_, _, _ = c.Get(ctx, "a")
+	_, _, _ = c.Get(ctx, "a")
+
+	assert.Equal(t, int64(1), c.Stat("misses"))
+	assert.Equal(t, int64(2), c.Stat("hits"))
+	assert.Equal(t, int64(0), c.Stat("errors"))
+	published, ok := expvar.Get("cache").(*expvar.Map)
+	require.True(t, ok)
+	assert.Equal(t, "2", published.Get("test_hits").String())
+}
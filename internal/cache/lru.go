@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultLRUSize is the default maximum number of entries of an LRU cache.
+const DefaultLRUSize = 10000
+
+// LRU is an in-memory cache evicting the least recently used entries once full.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+	now      func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates a new LRU cache holding at most capacity entries.
+// A capacity lower or equal to zero means DefaultLRUSize.
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = DefaultLRUSize
+	}
+	return &LRU{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value of key.
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := elem.Value.(*lruEntry) //nolint:forcetypeassert // only *lruEntry are stored
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false, nil
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for ttl.
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*lruEntry) //nolint:forcetypeassert // only *lruEntry are stored
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return nil
+	}
+
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	if c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+	return nil
+}
+
+// Delete removes keys.
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.removeElement(elem)
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries, expired ones included.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).key) //nolint:forcetypeassert // only *lruEntry are stored
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"time"
+)
+
+// stats publishes the cache counters with expvar under "cache".
+var stats = expvar.NewMap("cache")
+
+// Instrumented counts the lookups of a cache.
+type Instrumented struct {
+	Cache
+	hits, misses, errors expvar.Int
+}
+
+// WithMetrics wraps c to count its hits, misses and errors under name.
+// Counters are published by expvar as cache.<name>_hits, cache.<name>_misses and cache.<name>_errors,
+// those of a later cache with the same name replace them.
+func WithMetrics(c Cache, name string) *Instrumented {
+	i := &Instrumented{Cache: c}
+	stats.Set(name+"_hits", &i.hits)
+	stats.Set(name+"_misses", &i.misses)
+	stats.Set(name+"_errors", &i.errors)
+	return i
+}
+
+// Get returns the value of key and records the outcome of the lookup.
+func (c *Instrumented) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, found, err := c.Cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		c.errors.Add(1)
+	case found:
+		c.hits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+	return value, found, err //nolint:wrapcheck // transparent decorator
+}
+
+// Set stores value under key and records failures.
+func (c *Instrumented) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	err := c.Cache.Set(ctx, key, value, ttl)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return err //nolint:wrapcheck // transparent decorator
+}
+
+// Stat returns the value of a counter (hits, misses or errors).
+func (c *Instrumented) Stat(counter string) int64 {
+	switch counter {
+	case "hits":
+		return c.hits.Value()
+	case "misses":
+		return c.misses.Value()
+	case "errors":
+		return c.errors.Value()
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis is a cache stored in Redis. Keys are prefixed to share a Redis instance.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis creates a new Redis cache.
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// Get returns the value of key.
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get %s from redis: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// Set stores value under key for ttl.
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, c.prefix+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("could not set %s in redis: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes keys.
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	if err := c.client.Del(ctx, prefixed...).Err(); err != nil {
+		return fmt.Errorf("could not delete keys from redis: %w", err)
+	}
+	return nil
+}
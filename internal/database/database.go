@@ -6,71 +6,260 @@ import (
 	"database/sql"
 	"embed"
 	"fmt"
+	"io"
+	"log/slog"
 	"net"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/amacneil/dbmate/v2/pkg/dbmate"
 	_ "github.com/amacneil/dbmate/v2/pkg/driver/postgres" // dbmate postgres driver
 	"github.com/sgaunet/dsn/v2/pkg/dsn"
+	"github.com/sgaunet/template-api/internal/backoff"
 )
 
 //go:embed db/migrations/*.sql
 var fs embed.FS
 
+// Delays between two connection attempts of WaitForDB.
+const (
+	waitInitialDelay = 100 * time.Millisecond
+	waitMaxDelay     = 5 * time.Second
+)
+
 // Postgres is the database connection.
 type Postgres struct {
-	DB               *sql.DB
-	pgDataSourceName dsn.DSN
+	DB                   *sql.DB
+	pgDataSourceName     dsn.DSN
+	migrationLockTimeout time.Duration
+	closePool            func()
+}
+
+// PoolConfig configures the connection pool. Zero values keep the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Option configures a Postgres connection.
+type Option func(*options)
+
+type options struct {
+	driver           Driver
+	pool             PoolConfig
+	statementTimeout time.Duration
+}
+
+// WithPool configures the connection pool.
+func WithPool(pool PoolConfig) Option {
+	return func(o *options) {
+		o.pool = pool
+	}
+}
+
+// WithStatementTimeout aborts statements running longer than timeout.
+// It overrides the statement_timeout parameter of the DSN.
+func WithStatementTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.statementTimeout = timeout
+	}
 }
 
 // NewPostgres creates a new Postgres database connection.
-func NewPostgres(pgdsn string) (*Postgres, error) {
+func NewPostgres(pgdsn string, opts ...Option) (*Postgres, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	d, err := dsn.New(pgdsn)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse dsn: %w", err)
 	}
-	db, err := sql.Open("postgres", d.GetPostgresUri())
+	db, closePool, err := openDB(d, o)
 	if err != nil {
-		return nil, fmt.Errorf("could not open database: %w", err)
+		return nil, err
 	}
 	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		closePool()
 		return nil, fmt.Errorf("could not ping database: %w", err)
 	}
-	p := &Postgres{DB: db, pgDataSourceName: d}
+	p := &Postgres{
+		DB:                   db,
+		pgDataSourceName:     d,
+		migrationLockTimeout: DefaultMigrationLockTimeout,
+		closePool:            closePool,
+	}
 	return p, nil
 }
 
-// InitDB initializes the database.
+func applyPoolConfig(db *sql.DB, pool PoolConfig) {
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// SetMigrationLockTimeout sets the maximum time to wait for the migration lock
+// held by another replica. Values lower or equal to zero are ignored.
+func (p *Postgres) SetMigrationLockTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		p.migrationLockTimeout = timeout
+	}
+}
+
+// InitDB initializes the database (creates it if needed and applies pending migrations).
+// Migrations are guarded by an advisory lock so that concurrent replicas don't race:
+// a replica waiting for the lock returns as soon as the schema matches its embedded
+// migrations, which happens when the lock holder has applied them.
 func (p *Postgres) InitDB() error {
-	u, err := url.Parse(genDbmateURI(p.pgDataSourceName))
+	db, err := p.newDbmate(io.Discard)
 	if err != nil {
-		return fmt.Errorf("could not parse dbmate uri: %w", err)
+		return err
 	}
-	db := dbmate.New(u)
-	db.FS = fs
-	db.AutoDumpSchema = false
 
 	if err = db.Wait(); err != nil {
 		return fmt.Errorf("could not wait for database: %w", err)
 	}
-	fmt.Println("Migrations:")
+
+	upToDate := func() (bool, error) {
+		pending, err := pendingMigrations(db)
+		return pending == 0, err
+	}
+	unlock, err := p.lockMigrations(context.Background(), upToDate)
+	if err != nil {
+		return err
+	}
+	if unlock == nil {
+		slog.Info("migrations applied by another replica")
+		return nil
+	}
+	defer unlock()
+
 	migrations, err := db.FindMigrations()
 	if err != nil {
 		return fmt.Errorf("could not find migrations: %w", err)
 	}
 	for _, m := range migrations {
-		fmt.Println(m.Version, m.FilePath)
+		slog.Info("migration found", "version", m.Version, "file", m.FilePath, "applied", m.Applied)
 	}
-	fmt.Println("\nApplying...")
 	if err := db.CreateAndMigrate(); err != nil {
 		return fmt.Errorf("could not create and migrate database: %w", err)
 	}
+	slog.Info("migrations applied")
+	return nil
+}
+
+// MigrateUp creates the database if needed and applies pending migrations.
+// Progress is written to out.
+func (p *Postgres) MigrateUp(out io.Writer) error {
+	db, err := p.newDbmate(out)
+	if err != nil {
+		return err
+	}
+	unlock, err := p.lockMigrations(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := db.CreateAndMigrate(); err != nil {
+		return fmt.Errorf("could not migrate database: %w", err)
+	}
+	return nil
+}
+
+// MigrateDown rolls back the most recently applied migration.
+// Progress is written to out.
+func (p *Postgres) MigrateDown(out io.Writer) error {
+	db, err := p.newDbmate(out)
+	if err != nil {
+		return err
+	}
+	unlock, err := p.lockMigrations(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := db.Rollback(); err != nil {
+		return fmt.Errorf("could not rollback database: %w", err)
+	}
+	return nil
+}
+
+// MigrationStatus writes the status of every embedded migration to out
+// and returns the number of pending migrations.
+func (p *Postgres) MigrationStatus(out io.Writer) (int, error) {
+	db, err := p.newDbmate(out)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := db.Status(false)
+	if err != nil {
+		return 0, fmt.Errorf("could not get migration status: %w", err)
+	}
+	return pending, nil
+}
+
+// NewMigration creates a new migration file in dir.
+// Migrations are embedded, so the binary has to be rebuilt to apply it.
+func NewMigration(dir, name string, out io.Writer) error {
+	db := dbmate.New(&url.URL{})
+	db.MigrationsDir = []string{dir}
+	db.Log = out
+	if err := db.NewMigration(name); err != nil {
+		return fmt.Errorf("could not create migration: %w", err)
+	}
 	return nil
 }
 
+// pendingMigrations returns the number of embedded migrations not applied yet.
+func pendingMigrations(db *dbmate.DB) (int, error) {
+	migrations, err := db.FindMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("could not find migrations: %w", err)
+	}
+	pending := 0
+	for _, m := range migrations {
+		if !m.Applied {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// newDbmate creates a dbmate instance using the embedded migrations.
+func (p *Postgres) newDbmate(out io.Writer) (*dbmate.DB, error) {
+	u, err := url.Parse(genDbmateURI(p.pgDataSourceName))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse dbmate uri: %w", err)
+	}
+	db := dbmate.New(u)
+	db.FS = fs
+	db.AutoDumpSchema = false
+	db.Log = out
+	return db, nil
+}
+
 // Close closes the database connection.
 func (p *Postgres) Close() error {
-	if err := p.DB.Close(); err != nil {
+	err := p.DB.Close()
+	if p.closePool != nil {
+		p.closePool()
+	}
+	if err != nil {
 		return fmt.Errorf("could not close database connection: %w", err)
 	}
 	return nil
@@ -90,23 +279,60 @@ func genDbmateURI(d dsn.DSN) string {
 		d.GetDBName())
 }
 
+// genConnString generates a keyword/value connection string understood by lib/pq and pgx.
+// Unknown keys are sent by both drivers as session parameters, which is how
+// statement_timeout is applied to every connection of the pool.
+func genConnString(d dsn.DSN, o options) string {
+	params := []string{
+		connParam("host", d.GetHost()),
+		connParam("port", d.GetPort("5432")),
+		connParam("user", d.GetUser()),
+		connParam("password", d.GetPassword()),
+		connParam("dbname", d.GetDBName()),
+	}
+	for _, key := range []string{"sslmode", "search_path"} {
+		if value := d.GetParameter(key); value != "" {
+			params = append(params, connParam(key, value))
+		}
+	}
+	statementTimeout := d.GetParameter("statement_timeout")
+	if o.statementTimeout > 0 {
+		statementTimeout = strconv.FormatInt(o.statementTimeout.Milliseconds(), 10)
+	}
+	if statementTimeout != "" {
+		params = append(params, connParam("statement_timeout", statementTimeout))
+	}
+	return strings.Join(params, " ")
+}
+
+// connParam formats a quoted keyword/value connection parameter.
+func connParam(key, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return key + "='" + value + "'"
+}
+
 // WaitForDB waits for the database to be ready.
+// Connection attempts are spaced with an exponential backoff with jitter.
 func WaitForDB(ctx context.Context, pgdsn string) error {
 	chDBReady := make(chan struct{})
 	go func() {
-		for {
+		b := backoff.New(waitInitialDelay, waitMaxDelay)
+		for attempt := 0; ; attempt++ {
 			pg, err := NewPostgres(pgdsn)
+			if err == nil {
+				if err := pg.Close(); err != nil {
+					slog.Warn("could not close pg connection in wait loop", "error", err)
+				}
+				close(chDBReady)
+				return
+			}
+			delay := b.Delay(attempt)
+			slog.Info("database not ready", "attempt", attempt+1, "retry_in", delay, "error", err)
 			select {
 			case <-ctx.Done():
 				return
-			default:
-				if err == nil {
-					if err := pg.Close(); err != nil {
-						fmt.Printf("could not close pg connection in wait loop: %v\n", err)
-					}
-					close(chDBReady)
-					return
-				}
+			case <-time.After(delay):
 			}
 		}
 	}()
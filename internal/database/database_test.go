@@ -2,6 +2,8 @@ package database_test
 
 import (
 	"context"
+	"io"
+	"os"
 	"testing"
 	"time"
 
@@ -65,3 +67,57 @@ func TestGetDB(t *testing.T) {
 	db := pg.GetDB()
 	assert.NotNil(t, db)
 }
+
+func TestMigrateUpAndDown(t *testing.T) {
+	testdb = dbtest.NewTestDB(true)
+	defer testdb.Teardown()
+	pg, err := database.NewPostgres(testdb.GetDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+
+	err = pg.MigrateUp(io.Discard)
+	assert.Nil(t, err)
+	pending, err := pg.MigrationStatus(io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, pending)
+
+	err = pg.MigrateDown(io.Discard)
+	assert.Nil(t, err)
+	pending, err = pg.MigrationStatus(io.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pending)
+}
+
+func TestNewMigration(t *testing.T) {
+	dir := t.TempDir()
+	err := database.NewMigration(dir, "add_table", io.Discard)
+	assert.Nil(t, err)
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 1)
+}
+
+// TestInitDBConcurrent checks that replicas starting together don't race on migrations
+func TestInitDBConcurrent(t *testing.T) {
+	testdb = dbtest.NewTestDB(true)
+	defer testdb.Teardown()
+
+	const replicas = 3
+	errs := make(chan error, replicas)
+	for range replicas {
+		go func() {
+			pg, err := database.NewPostgres(testdb.GetDSN())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer pg.Close()
+			errs <- pg.InitDB()
+		}()
+	}
+	for range replicas {
+		assert.Nil(t, <-errs)
+	}
+}
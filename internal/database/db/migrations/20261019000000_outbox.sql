@@ -0,0 +1,18 @@
+-- migrate:up
+
+CREATE TABLE outbox
+(
+    id             BIGSERIAL PRIMARY KEY,
+    event_id       UUID NOT NULL UNIQUE,
+    aggregate_type VARCHAR(32) NOT NULL,
+    aggregate_id   VARCHAR(64) NOT NULL,
+    event_type     VARCHAR(64) NOT NULL,
+    payload        JSONB NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at   TIMESTAMPTZ
+);
+
+CREATE INDEX ON outbox (id) WHERE published_at IS NULL;
+
+-- migrate:down
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,22 @@
+-- migrate:up
+
+CREATE TABLE jobs
+(
+    id           BIGSERIAL PRIMARY KEY,
+    kind         VARCHAR(64) NOT NULL,
+    payload      JSONB NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX ON jobs (run_at) WHERE status = 'pending';
+CREATE INDEX ON jobs (locked_until) WHERE status = 'running';
+
+-- migrate:down
+DROP TABLE IF EXISTS jobs;
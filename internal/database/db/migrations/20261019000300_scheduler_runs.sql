@@ -0,0 +1,17 @@
+-- migrate:up
+
+CREATE TABLE scheduler_runs
+(
+    id           BIGSERIAL PRIMARY KEY,
+    task         VARCHAR(64) NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    instance     VARCHAR(255) NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'running',
+    error        TEXT,
+    started_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at  TIMESTAMPTZ,
+    UNIQUE (task, scheduled_at)
+);
+
+-- migrate:down
+DROP TABLE IF EXISTS scheduler_runs;
@@ -0,0 +1,16 @@
+-- migrate:up
+
+CREATE TABLE feature_flags
+(
+    name        VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    enabled     BOOLEAN NOT NULL,
+    percentage  SMALLINT NOT NULL DEFAULT 100 CHECK (percentage BETWEEN 0 AND 100),
+    identities  TEXT[] NOT NULL DEFAULT '{}',
+    headers     JSONB NOT NULL DEFAULT '{}',
+    updated_by  TEXT NOT NULL DEFAULT '',
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- migrate:down
+DROP TABLE IF EXISTS feature_flags;
@@ -0,0 +1,23 @@
+-- migrate:up
+
+CREATE TABLE audit_log
+(
+    id          BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id   VARCHAR(64) NOT NULL,
+    action      VARCHAR(16) NOT NULL,
+    actor       TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL DEFAULT '',
+    ip          TEXT NOT NULL DEFAULT '',
+    -- JSON null before a creation and after a deletion
+    before      JSONB NOT NULL DEFAULT 'null',
+    after       JSONB NOT NULL DEFAULT 'null',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX ON audit_log (entity_type, entity_id, id);
+CREATE INDEX ON audit_log (actor, id);
+CREATE INDEX ON audit_log (created_at);
+
+-- migrate:down
+DROP TABLE IF EXISTS audit_log;
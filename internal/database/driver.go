@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+	"github.com/lib/pq"
+	"github.com/sgaunet/dsn/v2/pkg/dsn"
+)
+
+// Driver is the client library used to talk to Postgres.
+// Every driver is exposed through database/sql so that the sqlc-generated
+// repository.Querier is the same whatever the driver.
+type Driver string
+
+// Supported drivers.
+const (
+	// DriverPQ uses lib/pq (default).
+	DriverPQ Driver = "pq"
+	// DriverPgx uses the pgx database/sql driver.
+	DriverPgx Driver = "pgx"
+	// DriverPgxPool uses a pgxpool.Pool, database/sql doesn't keep idle connections.
+	DriverPgxPool Driver = "pgxpool"
+)
+
+// CodeForeignKeyViolation is the Postgres error code of a foreign key violation.
+const CodeForeignKeyViolation = "23503"
+
+// ErrUnknownDriver is returned when the configured driver is not supported.
+var ErrUnknownDriver = errors.New("unknown database driver")
+
+// WithDriver selects the driver, lib/pq is used by default.
+func WithDriver(driver Driver) Option {
+	return func(o *options) {
+		o.driver = driver
+	}
+}
+
+// openDB opens a database/sql handle with the configured driver.
+// The returned function releases the resources not owned by the handle, it is never nil.
+func openDB(d dsn.DSN, o options) (*sql.DB, func(), error) {
+	connString := genConnString(d, o)
+	switch o.driver {
+	case "", DriverPQ:
+		db, err := sql.Open("postgres", connString)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open database: %w", err)
+		}
+		applyPoolConfig(db, o.pool)
+		return db, func() {}, nil
+	case DriverPgx:
+		db, err := sql.Open("pgx", connString)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not open database: %w", err)
+		}
+		applyPoolConfig(db, o.pool)
+		return db, func() {}, nil
+	case DriverPgxPool:
+		cfg, err := pgxpool.ParseConfig(connString)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse pgxpool config: %w", err)
+		}
+		if o.pool.MaxOpenConns > 0 && o.pool.MaxOpenConns <= math.MaxInt32 {
+			cfg.MaxConns = int32(o.pool.MaxOpenConns)
+		}
+		if o.pool.MaxIdleConns > 0 && o.pool.MaxIdleConns <= math.MaxInt32 {
+			cfg.MinIdleConns = int32(o.pool.MaxIdleConns)
+		}
+		if o.pool.ConnMaxLifetime > 0 {
+			cfg.MaxConnLifetime = o.pool.ConnMaxLifetime
+		}
+		if o.pool.ConnMaxIdleTime > 0 {
+			cfg.MaxConnIdleTime = o.pool.ConnMaxIdleTime
+		}
+		pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not create pgxpool: %w", err)
+		}
+		// Idle connections are managed by the pgxpool
+		return stdlib.OpenDBFromPool(pool), pool.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownDriver, o.driver)
+	}
+}
+
+// PgErrorCode returns the SQLSTATE code of a Postgres error, whatever the driver.
+// It returns an empty string if err doesn't come from Postgres.
+func PgErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
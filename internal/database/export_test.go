@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+
+	"github.com/sgaunet/dsn/v2/pkg/dsn"
+)
+
+// IsReadOnlyQuery exports isReadOnlyQuery to the external tests.
+var IsReadOnlyQuery = isReadOnlyQuery
+
+// NewTestRouter creates a router over unhealthy replicas named by hosts,
+// without connections nor health checks.
+func NewTestRouter(hosts ...string) *Router {
+	r := &Router{}
+	for _, host := range hosts {
+		r.replicas = append(r.replicas, &replica{host: host})
+	}
+	return r
+}
+
+// SetReplicaHealth sets the health of the replica of host.
+func (r *Router) SetReplicaHealth(host string, healthy bool) {
+	for _, rep := range r.replicas {
+		if rep.host == host {
+			rep.healthy.Store(healthy)
+		}
+	}
+}
+
+// Pick returns the host of the replica query runs on, empty for the primary.
+func (r *Router) Pick(ctx context.Context, query string) string {
+	if rep := r.pick(ctx, query); rep != nil {
+		return rep.host
+	}
+	return ""
+}
+
+// GenConnString returns the connection string of d with opts.
+func GenConnString(d dsn.DSN, opts ...Option) string {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return genConnString(d, o)
+}
+
+// OpenDB opens d with opts, without connecting, and closes it.
+func OpenDB(d dsn.DSN, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	db, release, err := openDB(d, o)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return db.Close()
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+const (
+	// migrationLockID is the key of the Postgres advisory lock guarding migrations.
+	// It fits in 32 bits so that it is reported as objid in pg_locks.
+	migrationLockID int64 = 749166233
+	// DefaultMigrationLockTimeout is the default maximum time to wait for the migration lock.
+	DefaultMigrationLockTimeout = time.Minute
+	// migrationLockPollInterval is the time between two attempts to take the migration lock.
+	migrationLockPollInterval = time.Second
+)
+
+// ErrMigrationLockTimeout is returned when the migration lock could not be taken in time.
+var ErrMigrationLockTimeout = errors.New("timeout waiting for migration lock")
+
+// lockHolder describes the session holding the migration lock.
+type lockHolder struct {
+	PID             int64
+	ApplicationName string
+	ClientAddr      string
+	Since           time.Time
+}
+
+const selectLockHolder = `SELECT a.pid,
+       coalesce(a.application_name, ''),
+       coalesce(host(a.client_addr), ''),
+       coalesce(a.backend_start, now())
+FROM pg_locks l
+JOIN pg_stat_activity a ON a.pid = l.pid
+WHERE l.locktype = 'advisory'
+  AND l.granted
+  AND l.classid = 0
+  AND l.objid = $1
+  AND l.objsubid = 1
+LIMIT 1`
+
+// lockMigrations takes the migration advisory lock on a dedicated session.
+// While waiting, ready is polled: when it reports true the lock is not needed
+// anymore and lockMigrations returns a nil release function.
+// The lock is released by calling the returned function.
+func (p *Postgres) lockMigrations(ctx context.Context, ready func() (bool, error)) (func(), error) {
+	ctx, cancel := context.WithTimeout(ctx, p.migrationLockTimeout)
+	defer cancel()
+
+	conn, err := p.DB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get connection for migration lock: %w", err)
+	}
+	// Name the session so that other replicas can tell who holds the lock
+	hostname, _ := os.Hostname()
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)",
+		"template-api migrations@"+hostname); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("could not set application name: %w", err)
+	}
+
+	for {
+		var acquired bool
+		err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&acquired)
+		if err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("could not take migration lock: %w", err)
+		}
+		if acquired {
+			slog.Info("migration lock acquired")
+			return func() { releaseMigrationLock(conn) }, nil
+		}
+
+		if ready != nil {
+			ok, err := ready()
+			if err != nil {
+				_ = conn.Close()
+				return nil, err
+			}
+			if ok {
+				_ = conn.Close()
+				return nil, nil
+			}
+		}
+
+		logLockHolder(ctx, conn)
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+			return nil, fmt.Errorf("%w after %s", ErrMigrationLockTimeout, p.migrationLockTimeout)
+		case <-time.After(migrationLockPollInterval):
+		}
+	}
+}
+
+func releaseMigrationLock(conn *sql.Conn) {
+	// Closing the session releases the lock anyway, unlock explicitly to return the
+	// connection to the pool in a clean state.
+	if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+		slog.Warn("could not release migration lock", "error", err)
+	}
+	if _, err := conn.ExecContext(context.Background(), "RESET application_name"); err != nil {
+		slog.Warn("could not reset application name", "error", err)
+	}
+	if err := conn.Close(); err != nil {
+		slog.Warn("could not close migration lock connection", "error", err)
+	}
+	slog.Info("migration lock released")
+}
+
+func logLockHolder(ctx context.Context, conn *sql.Conn) {
+	var h lockHolder
+	err := conn.QueryRowContext(ctx, selectLockHolder, migrationLockID).
+		Scan(&h.PID, &h.ApplicationName, &h.ClientAddr, &h.Since)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Released in the meantime, next attempt should succeed
+		slog.Info("waiting for migration lock")
+	case err != nil:
+		slog.Warn("waiting for migration lock, could not find holder", "error", err)
+	default:
+		slog.Info("waiting for migration lock",
+			"holder_pid", h.PID,
+			"holder_application", h.ApplicationName,
+			"holder_addr", h.ClientAddr,
+			"holder_since", h.Since)
+	}
+}
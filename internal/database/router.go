@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sgaunet/dsn/v2/pkg/dsn"
+)
+
+const (
+	// DefaultHealthCheckInterval is the default interval between two replica health checks.
+	DefaultHealthCheckInterval = 5 * time.Second
+	// healthCheckTimeout is the maximum duration of a replica health check.
+	healthCheckTimeout = 2 * time.Second
+	// sqlcNamePrefix starts every sqlc-generated query.
+	sqlcNamePrefix = "-- name: "
+)
+
+// readOnlyQueryPrefixes are the prefixes of the sqlc query names sent to replicas.
+var readOnlyQueryPrefixes = []string{"Get", "List", "Stream"}
+
+type primaryKey struct{}
+
+// WithPrimary returns a context forcing reads to the primary, typically after a
+// write so that the caller reads its own writes.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary reports whether reads are forced to the primary.
+func usePrimary(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
+
+// replica is a read replica and its health.
+type replica struct {
+	db        *sql.DB
+	closePool func()
+	host      string
+	healthy   atomic.Bool
+}
+
+// Router routes read-only sqlc queries (Get*, List*, Stream*) to healthy replicas
+// in a round-robin fashion, everything else goes to the primary. When no replica
+// is healthy, or when a replica query fails, reads fall back to the primary.
+// Router implements the sqlc DBTX interface.
+type Router struct {
+	primary  *sql.DB
+	replicas []*replica
+	next     atomic.Uint64
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewRouter creates a new router and starts the replica health checks.
+// Replicas don't need to be reachable, unhealthy ones are skipped until they recover.
+func NewRouter(primary *sql.DB, replicaDSNs []string, healthCheckInterval time.Duration, opts ...Option) (*Router, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if healthCheckInterval <= 0 {
+		healthCheckInterval = DefaultHealthCheckInterval
+	}
+
+	r := &Router{primary: primary}
+	for _, replicaDSN := range replicaDSNs {
+		d, err := dsn.New(replicaDSN)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("could not parse replica dsn: %w", err)
+		}
+		db, closePool, err := openDB(d, o)
+		if err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("could not open replica: %w", err)
+		}
+		r.replicas = append(r.replicas, &replica{db: db, closePool: closePool, host: d.GetHost()})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.checkReplicas(ctx)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.checkReplicas(ctx)
+			}
+		}
+	}()
+	return r, nil
+}
+
+// Close stops the health checks and closes the replica connections.
+// The primary connection is not closed.
+func (r *Router) Close() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("could not close replica %s: %w", rep.host, err))
+		}
+		rep.closePool()
+	}
+	return errors.Join(errs...)
+}
+
+// ExecContext executes a statement on the primary.
+func (r *Router) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...) //nolint:wrapcheck // transparent proxy
+}
+
+// PrepareContext prepares a statement on the primary.
+func (r *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.primary.PrepareContext(ctx, query) //nolint:wrapcheck // transparent proxy
+}
+
+// QueryContext runs a query on a replica if it is read-only, on the primary otherwise.
+func (r *Router) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if rep := r.pick(ctx, query); rep != nil {
+		rows, err := rep.db.QueryContext(ctx, query, args...)
+		if err == nil || ctx.Err() != nil {
+			return rows, err //nolint:wrapcheck // transparent proxy
+		}
+		slog.Warn("replica query failed, falling back to primary", "replica", rep.host, "error", err)
+	}
+	return r.primary.QueryContext(ctx, query, args...) //nolint:wrapcheck // transparent proxy
+}
+
+// QueryRowContext runs a single row query on a replica if it is read-only, on the primary otherwise.
+// Errors are only known when scanning, so there is no fallback besides health checks.
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if rep := r.pick(ctx, query); rep != nil {
+		return rep.db.QueryRowContext(ctx, query, args...)
+	}
+	return r.primary.QueryRowContext(ctx, query, args...)
+}
+
+// pick returns the replica to run query on, nil if it must run on the primary.
+func (r *Router) pick(ctx context.Context, query string) *replica {
+	if len(r.replicas) == 0 || usePrimary(ctx) || !isReadOnlyQuery(query) {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := range uint64(len(r.replicas)) {
+		rep := r.replicas[(start+i)%uint64(len(r.replicas))]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+func (r *Router) checkReplicas(ctx context.Context) {
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := rep.db.PingContext(pingCtx)
+		cancel()
+		healthy := err == nil
+		if previous := rep.healthy.Swap(healthy); previous != healthy {
+			if healthy {
+				slog.Info("replica is healthy", "replica", rep.host)
+			} else {
+				slog.Warn("replica is unhealthy", "replica", rep.host, "error", err)
+			}
+		}
+	}
+}
+
+// isReadOnlyQuery reports whether a sqlc query is read-only, based on its name.
+func isReadOnlyQuery(query string) bool {
+	name, ok := strings.CutPrefix(query, sqlcNamePrefix)
+	if !ok {
+		return false
+	}
+	for _, prefix := range readOnlyQueryPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	assert.True(t, database.IsReadOnlyQuery("-- name: GetAuthor :one\nSELECT id FROM authors"))
+	assert.True(t, database.IsReadOnlyQuery("-- name: ListAuthors :many\nSELECT id FROM authors"))
+	assert.True(t, database.IsReadOnlyQuery("-- name: StreamBooks :many\nSELECT id FROM books"))
+	assert.False(t, database.IsReadOnlyQuery("-- name: CreateAuthor :one\nINSERT INTO authors"))
+	assert.False(t, database.IsReadOnlyQuery("SELECT pg_try_advisory_lock($1)"))
+}
+
+func TestRouterPick(t *testing.T) {
+	r := database.NewTestRouter("unhealthy", "healthy")
+	r.SetReplicaHealth("healthy", true)
+	ctx := context.Background()
+	read := "-- name: ListAuthors :many\nSELECT id FROM authors"
+
+	for range 4 {
+		assert.Equal(t, "healthy", r.Pick(ctx, read))
+	}
+	assert.Empty(t, r.Pick(ctx, "-- name: DeleteAuthor :exec\nDELETE FROM authors"))
+	assert.Empty(t, r.Pick(database.WithPrimary(ctx), read))
+
+	r.SetReplicaHealth("healthy", false)
+	assert.Empty(t, r.Pick(ctx, read))
+}
@@ -0,0 +1,2 @@
+// Package exchange provides streaming CSV and NDJSON encoding used to import and export data.
+package exchange
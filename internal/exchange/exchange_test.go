@@ -0,0 +1,118 @@
+package exchange_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+var mapping = exchange.CSVMapping[item]{
+	Header: []string{"name", "value"},
+	Marshal: func(i item) []string {
+		return []string{i.Name, i.Value}
+	},
+	Unmarshal: func(fields []string) (item, error) {
+		return item{Name: fields[0], Value: fields[1]}, nil
+	},
+}
+
+func readAll(t *testing.T, r *exchange.Reader[item]) []exchange.Row[item] {
+	t.Helper()
+	var rows []exchange.Row[item]
+	for {
+		row, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	assert.Equal(t, exchange.FormatNDJSON, exchange.NegotiateFormat(""))
+	assert.Equal(t, exchange.FormatNDJSON, exchange.NegotiateFormat("*/*"))
+	assert.Equal(t, exchange.FormatCSV, exchange.NegotiateFormat("text/csv"))
+	assert.Equal(t, exchange.FormatCSV, exchange.NegotiateFormat("text/html, text/csv;q=0.9"))
+	assert.Equal(t, exchange.FormatNDJSON, exchange.NegotiateFormat("application/x-ndjson"))
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	format, err := exchange.FormatFromContentType("text/csv; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, exchange.FormatCSV, format)
+
+	_, err = exchange.FormatFromContentType("application/xml")
+	assert.Error(t, err)
+}
+
+func TestWriter_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	w := exchange.NewWriter(&buf, exchange.FormatCSV, mapping)
+	require.NoError(t, w.Write(item{Name: "a", Value: "x,y"}))
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "name,value\na,\"x,y\"\n", buf.String())
+}
+
+func TestWriter_CSVHeaderOnlyWhenEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w := exchange.NewWriter(&buf, exchange.FormatCSV, mapping)
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "name,value\n", buf.String())
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := exchange.NewWriter(&buf, exchange.FormatNDJSON, mapping)
+	require.NoError(t, w.Write(item{Name: "a", Value: "x"}))
+	require.NoError(t, w.Write(item{Name: "b", Value: "y"}))
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "{\"name\":\"a\",\"value\":\"x\"}\n{\"name\":\"b\",\"value\":\"y\"}\n", buf.String())
+}
+
+func TestReader_CSV(t *testing.T) {
+	input := "value,extra,NAME\nx,1,a\n\"bad,2,b\n"
+	rows := readAll(t, exchange.NewReader(strings.NewReader(input), exchange.FormatCSV, mapping))
+	require.Len(t, rows, 2)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, item{Name: "a", Value: "x"}, rows[0].Value)
+
+	assert.Equal(t, 3, rows[1].Line)
+	assert.True(t, apperror.IsValidationError(rows[1].Err))
+}
+
+func TestReader_NDJSON(t *testing.T) {
+	input := "{\"name\":\"a\",\"value\":\"x\"}\n\n{broken\n{\"name\":\"b\"}\n"
+	rows := readAll(t, exchange.NewReader(strings.NewReader(input), exchange.FormatNDJSON, mapping))
+	require.Len(t, rows, 3)
+
+	assert.NoError(t, rows[0].Err)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, 3, rows[1].Line)
+	assert.True(t, apperror.IsValidationError(rows[1].Err))
+	assert.Equal(t, 4, rows[2].Line)
+	assert.Equal(t, item{Name: "b"}, rows[2].Value)
+}
+
+func TestImportResult_CapsErrors(t *testing.T) {
+	result := &exchange.ImportResult{}
+	for i := range exchange.MaxReportedErrors + 10 {
+		result.AddError(i+1, apperror.NewBadRequestError("bad"))
+	}
+	assert.Equal(t, exchange.MaxReportedErrors+10, result.Failed)
+	assert.Len(t, result.ToResponse().Errors, exchange.MaxReportedErrors)
+}
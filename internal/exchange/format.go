@@ -0,0 +1,65 @@
+package exchange
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// Format is a data exchange format.
+type Format int
+
+// Supported data exchange formats.
+const (
+	FormatNDJSON Format = iota
+	FormatCSV
+)
+
+// Content types of the supported formats.
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+	ContentTypeCSV    = "text/csv"
+)
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return ContentTypeCSV
+	}
+	return ContentTypeNDJSON
+}
+
+// NegotiateFormat picks the export format from an Accept header.
+// NDJSON is used when the header is empty or doesn't ask for a supported format.
+func NegotiateFormat(accept string) Format {
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ContentTypeCSV:
+			return FormatCSV
+		case ContentTypeNDJSON:
+			return FormatNDJSON
+		}
+	}
+	return FormatNDJSON
+}
+
+// FormatFromContentType returns the import format matching a Content-Type header.
+func FormatFromContentType(contentType string) (Format, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, apperror.NewBadRequestError("Invalid Content-Type")
+	}
+	switch mediaType {
+	case ContentTypeCSV:
+		return FormatCSV, nil
+	case ContentTypeNDJSON:
+		return FormatNDJSON, nil
+	default:
+		return 0, apperror.NewBadRequestError("Unsupported Content-Type, expected text/csv or application/x-ndjson")
+	}
+}
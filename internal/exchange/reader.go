@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// maxLineSize is the maximum size of a NDJSON line.
+const maxLineSize = 1024 * 1024
+
+// Row is a decoded input line. Err is set when the line could not be decoded.
+type Row[T any] struct {
+	Line  int
+	Value T
+	Err   error
+}
+
+// Reader streams values from CSV rows or NDJSON lines.
+type Reader[T any] struct {
+	format  Format
+	mapping CSVMapping[T]
+	csv     *csv.Reader
+	scanner *bufio.Scanner
+	line    int
+	columns []int
+}
+
+// NewReader creates a new reader. CSV input must start with a header row,
+// columns are matched by name and unknown ones are ignored.
+func NewReader[T any](r io.Reader, format Format, mapping CSVMapping[T]) *Reader[T] {
+	reader := &Reader[T]{
+		format:  format,
+		mapping: mapping,
+	}
+	if format == FormatCSV {
+		reader.csv = csv.NewReader(r)
+		reader.csv.FieldsPerRecord = -1
+		reader.csv.ReuseRecord = true
+	} else {
+		reader.scanner = bufio.NewScanner(r)
+		reader.scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	}
+	return reader
+}
+
+// Next returns the next row. It returns io.EOF once the input is exhausted.
+// Any other error means the input can't be read any further.
+func (r *Reader[T]) Next() (Row[T], error) {
+	if r.format == FormatCSV {
+		return r.nextCSV()
+	}
+	return r.nextNDJSON()
+}
+
+func (r *Reader[T]) nextNDJSON() (Row[T], error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		row := Row[T]{Line: r.line}
+		if err := json.Unmarshal(line, &row.Value); err != nil {
+			row.Err = malformedLineError(r.line, err)
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return Row[T]{}, apperror.NewBadRequestError(
+				fmt.Sprintf("Line %d exceeds %d bytes", r.line+1, maxLineSize))
+		}
+		return Row[T]{}, fmt.Errorf("could not read ndjson input: %w", err)
+	}
+	return Row[T]{}, io.EOF
+}
+
+func (r *Reader[T]) nextCSV() (Row[T], error) {
+	if r.columns == nil {
+		if err := r.readHeader(); err != nil {
+			return Row[T]{}, err
+		}
+	}
+
+	record, err := r.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return Row[T]{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return Row[T]{Line: parseErr.StartLine, Err: malformedLineError(parseErr.StartLine, parseErr.Err)}, nil
+	}
+	if err != nil {
+		return Row[T]{}, fmt.Errorf("could not read csv input: %w", err)
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	fields := make([]string, len(r.columns))
+	for i, idx := range r.columns {
+		if idx >= 0 && idx < len(record) {
+			fields[i] = record[idx]
+		}
+	}
+	row := Row[T]{Line: line}
+	row.Value, row.Err = r.mapping.Unmarshal(fields)
+	return row, nil
+}
+
+func (r *Reader[T]) readHeader() error {
+	header, err := r.csv.Read()
+	if errors.Is(err, io.EOF) {
+		return io.EOF
+	}
+	if err != nil {
+		return apperror.NewBadRequestError("Invalid CSV header")
+	}
+
+	r.columns = make([]int, len(r.mapping.Header))
+	for i, name := range r.mapping.Header {
+		r.columns[i] = -1
+		for j, col := range header {
+			if strings.EqualFold(strings.TrimSpace(col), name) {
+				r.columns[i] = j
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func malformedLineError(line int, err error) error {
+	return apperror.NewValidationError(
+		"Malformed line",
+		map[string]string{
+			"line":  strconv.Itoa(line),
+			"error": err.Error(),
+		},
+	)
+}
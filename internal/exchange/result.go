@@ -0,0 +1,54 @@
+package exchange
+
+import "github.com/sgaunet/template-api/internal/apperror"
+
+// MaxReportedErrors caps the number of line errors kept by an ImportResult.
+const MaxReportedErrors = 1000
+
+// LineError is an error attached to an input line.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+// ImportResult summarizes an import.
+type ImportResult struct {
+	Imported int
+	Failed   int
+	Errors   []LineError
+}
+
+// AddError records a failed line. Only the first MaxReportedErrors errors are kept.
+func (r *ImportResult) AddError(line int, err error) {
+	r.Failed++
+	if len(r.Errors) < MaxReportedErrors {
+		r.Errors = append(r.Errors, LineError{Line: line, Err: err})
+	}
+}
+
+// LineErrorResponse is the response format of a line error.
+type LineErrorResponse struct {
+	Line  int                    `json:"line"`
+	Error apperror.ErrorResponse `json:"error"`
+}
+
+// ImportResponse is the response format of an import.
+type ImportResponse struct {
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Errors   []LineErrorResponse `json:"errors"`
+}
+
+// ToResponse converts an import result to response.
+func (r *ImportResult) ToResponse() *ImportResponse {
+	resp := &ImportResponse{
+		Imported: r.Imported,
+		Failed:   r.Failed,
+		Errors:   make([]LineErrorResponse, len(r.Errors)),
+	}
+	for i, lineErr := range r.Errors {
+		errResp, _ := apperror.NewErrorResponse(lineErr.Err)
+		resp.Errors[i] = LineErrorResponse{Line: lineErr.Line, Error: errResp}
+	}
+	return resp
+}
@@ -0,0 +1,79 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// CSVMapping maps a value to CSV columns.
+// Marshal is used by writers, Unmarshal by readers.
+type CSVMapping[T any] struct {
+	Header    []string
+	Marshal   func(T) []string
+	Unmarshal func(fields []string) (T, error)
+}
+
+// Writer streams values as CSV rows or NDJSON lines.
+type Writer[T any] struct {
+	format        Format
+	mapping       CSVMapping[T]
+	csv           *csv.Writer
+	json          *json.Encoder
+	headerWritten bool
+}
+
+// NewWriter creates a new writer. The CSV header is written with the first value.
+func NewWriter[T any](w io.Writer, format Format, mapping CSVMapping[T]) *Writer[T] {
+	return &Writer[T]{
+		format:  format,
+		mapping: mapping,
+		csv:     csv.NewWriter(w),
+		json:    json.NewEncoder(w),
+	}
+}
+
+// Write writes a single value.
+func (w *Writer[T]) Write(v T) error {
+	if w.format == FormatNDJSON {
+		if err := w.json.Encode(v); err != nil {
+			return fmt.Errorf("could not encode ndjson line: %w", err)
+		}
+		return nil
+	}
+
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	if err := w.csv.Write(w.mapping.Marshal(v)); err != nil {
+		return fmt.Errorf("could not write csv row: %w", err)
+	}
+	return nil
+}
+
+// Flush flushes buffered data. The CSV header is written even if no value was.
+func (w *Writer[T]) Flush() error {
+	if w.format == FormatNDJSON {
+		return nil
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("could not flush csv: %w", err)
+	}
+	return nil
+}
+
+func (w *Writer[T]) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	if err := w.csv.Write(w.mapping.Header); err != nil {
+		return fmt.Errorf("could not write csv header: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// Package jobs runs asynchronous work from a queue stored in Postgres.
+//
+// Jobs are enqueued with the queries of the caller, within its transaction
+// if any, so that a job exists if and only if the change requesting it is
+// committed. Workers claim due jobs with FOR UPDATE SKIP LOCKED, so that
+// any number of workers and instances share the queue without contention.
+// Claimed jobs are leased: a job whose worker died is claimed again when
+// its lease expires, and only the worker holding the lease records the
+// outcome.
+// Failed jobs are retried with exponential backoff until their maximum
+// number of attempts.
+package jobs
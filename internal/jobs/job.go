@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// DefaultMaxAttempts is the default number of attempts of a job.
+const DefaultMaxAttempts = 5
+
+// Job is a unit of asynchronous work.
+type Job struct {
+	ID   int64
+	Kind string
+	// Payload is the JSON encoded argument of the job.
+	Payload     json.RawMessage
+	Attempts    int
+	MaxAttempts int
+	// LeaseUntil is the end of the lease of the worker running the job, the
+	// outcome is only recorded while the lease is held.
+	LeaseUntil time.Time
+}
+
+// Decode decodes the payload of the job into v.
+func (j *Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("could not decode payload of %s job %d: %w", j.Kind, j.ID, err)
+	}
+	return nil
+}
+
+// enqueueOptions are the options of a job.
+type enqueueOptions struct {
+	runAt       time.Time
+	maxAttempts int
+}
+
+// EnqueueOption configures an enqueued job.
+type EnqueueOption func(*enqueueOptions)
+
+// At schedules the job at t instead of now.
+func At(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = t
+	}
+}
+
+// After delays the job by d.
+func After(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = time.Now().Add(d)
+	}
+}
+
+// WithMaxAttempts sets the number of attempts before the job fails.
+// Values lower or equal to zero are ignored.
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		if attempts > 0 {
+			o.maxAttempts = attempts
+		}
+	}
+}
+
+// Enqueue adds a job of kind with payload encoded in JSON and returns its
+// identifier. q should be bound to the caller's transaction, so that the job
+// is only enqueued when it commits.
+func Enqueue(ctx context.Context, q repository.Querier, kind string, payload any, opts ...EnqueueOption) (int64, error) {
+	o := enqueueOptions{
+		runAt:       time.Now(),
+		maxAttempts: DefaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("could not encode payload of %s job: %w", kind, err)
+	}
+	id, err := q.EnqueueJob(ctx, repository.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: int32(o.maxAttempts), //nolint:gosec // small
+		RunAt:       o.runAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("could not enqueue %s job: %w", kind, err)
+	}
+	return id, nil
+}
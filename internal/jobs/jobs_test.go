@@ -0,0 +1,273 @@
+package jobs_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/jobs"
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTransient = errors.New("transient failure")
+
+// fakeStore is an in-memory queue implementing both the store and the
+// enqueue query, the embedded Querier is nil and panics on other queries.
+type fakeStore struct {
+	repository.Querier
+	mu   sync.Mutex
+	jobs []*repository.Job
+}
+
+func (s *fakeStore) EnqueueJob(_ context.Context, arg repository.EnqueueJobParams) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := int64(len(s.jobs) + 1)
+	s.jobs = append(s.jobs, &repository.Job{
+		ID:          id,
+		Kind:        arg.Kind,
+		Payload:     arg.Payload,
+		Status:      "pending",
+		MaxAttempts: arg.MaxAttempts,
+		RunAt:       arg.RunAt,
+	})
+	return id, nil
+}
+
+func (s *fakeStore) Claim(_ context.Context, leaseUntil time.Time) (*jobs.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Status == "pending" && !j.RunAt.After(time.Now()) {
+			j.Status = "running"
+			j.Attempts++
+			j.LockedUntil = sql.NullTime{Time: leaseUntil, Valid: true}
+			return &jobs.Job{
+				ID:          j.ID,
+				Kind:        j.Kind,
+				Payload:     j.Payload,
+				Attempts:    int(j.Attempts),
+				MaxAttempts: int(j.MaxAttempts),
+				LeaseUntil:  leaseUntil,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// leased returns the job if its lease is held, s.mu being held.
+func (s *fakeStore) leased(job *jobs.Job) (*repository.Job, error) {
+	j := s.jobs[job.ID-1]
+	if j.Status != "running" || !j.LockedUntil.Time.Equal(job.LeaseUntil) {
+		return nil, jobs.ErrLeaseLost
+	}
+	j.LockedUntil = sql.NullTime{}
+	return j, nil
+}
+
+func (s *fakeStore) Complete(_ context.Context, job *jobs.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, err := s.leased(job)
+	if err != nil {
+		return err
+	}
+	j.Status = "succeeded"
+	return nil
+}
+
+func (s *fakeStore) Retry(_ context.Context, job *jobs.Job, runAt time.Time, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, err := s.leased(job)
+	if err != nil {
+		return err
+	}
+	j.Status = "pending"
+	j.RunAt = runAt
+	j.LastError.String = reason
+	return nil
+}
+
+func (s *fakeStore) Fail(_ context.Context, job *jobs.Job, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, err := s.leased(job)
+	if err != nil {
+		return err
+	}
+	j.Status = "failed"
+	j.LastError.String = reason
+	return nil
+}
+
+// reclaim simulates the expiry of the lease of a running job and its claim
+// by another worker.
+func (s *fakeStore) reclaim(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[id-1].Attempts++
+	s.jobs[id-1].LockedUntil.Time = s.jobs[id-1].LockedUntil.Time.Add(time.Minute)
+}
+
+func (s *fakeStore) job(id int64) repository.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.jobs[id-1]
+}
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+func TestEnqueue(t *testing.T) {
+	store := &fakeStore{}
+	runAt := time.Now().Add(time.Hour)
+
+	id, err := jobs.Enqueue(context.Background(), store, "greet", greeting{Name: "Ada"},
+		jobs.At(runAt), jobs.WithMaxAttempts(2))
+	require.NoError(t, err)
+
+	j := store.job(id)
+	assert.Equal(t, "greet", j.Kind)
+	assert.JSONEq(t, `{"name":"Ada"}`, string(j.Payload))
+	assert.Equal(t, int32(2), j.MaxAttempts)
+	assert.Equal(t, runAt, j.RunAt)
+}
+
+func TestRunOnce(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store)
+	var greeted string
+	pool.Register("greet", func(_ context.Context, job *jobs.Job) error {
+		var g greeting
+		if err := job.Decode(&g); err != nil {
+			return err
+		}
+		greeted = g.Name
+		return nil
+	})
+
+	ran, err := pool.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ran, "empty queue")
+
+	id, err := jobs.Enqueue(context.Background(), store, "greet", greeting{Name: "Ada"})
+	require.NoError(t, err)
+	ran, err = pool.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, "Ada", greeted)
+	assert.Equal(t, "succeeded", store.job(id).Status)
+}
+
+func TestRunOnce_Delayed(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store)
+	pool.Register("greet", func(context.Context, *jobs.Job) error { return nil })
+
+	_, err := jobs.Enqueue(context.Background(), store, "greet", nil, jobs.After(time.Hour))
+	require.NoError(t, err)
+	ran, err := pool.RunOnce(context.Background())
+	require.NoError(t, err)
+	assert.False(t, ran)
+}
+
+func TestRunOnce_Retries(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, jobs.WithRetryBackoff(time.Nanosecond, time.Nanosecond))
+	var attempts int
+	pool.Register("flaky", func(context.Context, *jobs.Job) error {
+		attempts++
+		return errTransient
+	})
+
+	id, err := jobs.Enqueue(context.Background(), store, "flaky", nil, jobs.WithMaxAttempts(3))
+	require.NoError(t, err)
+	for range 5 {
+		_, err := pool.RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, attempts)
+	j := store.job(id)
+	assert.Equal(t, "failed", j.Status)
+	assert.Equal(t, errTransient.Error(), j.LastError.String)
+}
+
+func TestRunOnce_Failures(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store)
+	pool.Register("panic", func(context.Context, *jobs.Job) error { panic("boom") })
+
+	unknown, err := jobs.Enqueue(context.Background(), store, "unknown", nil)
+	require.NoError(t, err)
+	panicked, err := jobs.Enqueue(context.Background(), store, "panic", nil)
+	require.NoError(t, err)
+	for range 2 {
+		_, err := pool.RunOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "failed", store.job(unknown).Status, "unknown kinds are not retried")
+	assert.Equal(t, "pending", store.job(panicked).Status)
+	assert.Contains(t, store.job(panicked).LastError.String, "boom")
+}
+
+func TestRunOnce_LeaseLost(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store)
+	pool.Register("slow", func(_ context.Context, job *jobs.Job) error {
+		// the job outlives its lease and is claimed again
+		store.reclaim(job.ID)
+		return nil
+	})
+
+	id, err := jobs.Enqueue(context.Background(), store, "slow", nil)
+	require.NoError(t, err)
+	ran, err := pool.RunOnce(context.Background())
+	assert.True(t, ran)
+	require.ErrorIs(t, err, jobs.ErrLeaseLost)
+	assert.Equal(t, "running", store.job(id).Status, "the outcome belongs to the new claim")
+}
+
+func TestRun_GracefulShutdown(t *testing.T) {
+	store := &fakeStore{}
+	pool := jobs.NewPool(store, jobs.WithConcurrency(2), jobs.WithPollInterval(time.Millisecond))
+	started := make(chan struct{}, 2)
+	var finished atomic.Int32
+	pool.Register("slow", func(ctx context.Context, _ *jobs.Job) error {
+		started <- struct{}{}
+		<-ctx.Done()
+		finished.Add(1)
+		return ctx.Err()
+	})
+
+	for range 2 {
+		_, err := jobs.Enqueue(context.Background(), store, "slow", nil, jobs.WithMaxAttempts(1))
+		require.NoError(t, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Run(ctx)
+	}()
+	<-started
+	<-started
+	cancel()
+	<-done
+
+	assert.Equal(t, int32(2), finished.Load(), "running jobs see the shutdown")
+	for id := range int64(2) {
+		job := store.job(id + 1)
+		assert.Equal(t, "pending", job.Status, "interrupted jobs are released, whatever their attempts")
+		assert.False(t, job.RunAt.After(time.Now()), "interrupted jobs run again right away")
+	}
+}
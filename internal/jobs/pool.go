@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/backoff"
+)
+
+// Pool defaults.
+const (
+	DefaultConcurrency  = 4
+	DefaultPollInterval = time.Second
+	DefaultTimeout      = 5 * time.Minute
+	DefaultRetryInitial = 5 * time.Second
+	DefaultRetryMax     = time.Hour
+	// leaseMargin leaves time to record the outcome of a job before its
+	// lease expires and another worker claims it again.
+	leaseMargin = 30 * time.Second
+	// maxErrorLength truncates recorded errors.
+	maxErrorLength = 1024
+)
+
+var errUnknownKind = errors.New("no handler registered for job kind")
+
+// Handler runs a job. A job returning an error is retried until its maximum
+// number of attempts.
+type Handler func(ctx context.Context, job *Job) error
+
+// Pool is a pool of workers running jobs.
+type Pool struct {
+	store        Store
+	handlers     map[string]Handler
+	concurrency  int
+	pollInterval time.Duration
+	timeout      time.Duration
+	backoff      backoff.Exponential
+}
+
+// PoolOption configures the pool.
+type PoolOption func(*Pool)
+
+// WithConcurrency sets the number of jobs run concurrently.
+// Values lower or equal to zero are ignored.
+func WithConcurrency(n int) PoolOption {
+	return func(p *Pool) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithPollInterval sets the interval between two claims of an idle worker.
+// Values lower or equal to zero are ignored.
+func WithPollInterval(interval time.Duration) PoolOption {
+	return func(p *Pool) {
+		if interval > 0 {
+			p.pollInterval = interval
+		}
+	}
+}
+
+// WithTimeout sets the maximum duration of a job.
+// Values lower or equal to zero are ignored.
+func WithTimeout(timeout time.Duration) PoolOption {
+	return func(p *Pool) {
+		if timeout > 0 {
+			p.timeout = timeout
+		}
+	}
+}
+
+// WithRetryBackoff sets the delays between attempts.
+// Values lower or equal to zero are ignored.
+func WithRetryBackoff(initial, maxDelay time.Duration) PoolOption {
+	return func(p *Pool) {
+		if initial > 0 && maxDelay > 0 {
+			p.backoff = backoff.New(initial, maxDelay)
+		}
+	}
+}
+
+// NewPool creates a new pool of workers.
+func NewPool(store Store, opts ...PoolOption) *Pool {
+	p := &Pool{
+		store:        store,
+		handlers:     map[string]Handler{},
+		concurrency:  DefaultConcurrency,
+		pollInterval: DefaultPollInterval,
+		timeout:      DefaultTimeout,
+		backoff:      backoff.New(DefaultRetryInitial, DefaultRetryMax),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Register sets the handler of a job kind. Handlers must be registered
+// before Run.
+func (p *Pool) Register(kind string, h Handler) {
+	p.handlers[kind] = h
+}
+
+// Run runs jobs until ctx is cancelled, then waits for running jobs.
+// The context of running jobs is cancelled with ctx, so that handlers stop
+// on shutdown: the jobs they fail are released to run again right away,
+// rather than waiting for the backoff or failing for good.
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range p.concurrency {
+		wg.Go(func() {
+			p.work(ctx)
+		})
+	}
+	wg.Wait()
+}
+
+// work runs jobs one at a time, waiting for the poll interval when the queue
+// is empty.
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := p.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("job worker failed", "error", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// RunOnce claims and runs a due job, it returns false if there was none.
+func (p *Pool) RunOnce(ctx context.Context) (bool, error) {
+	job, err := p.store.Claim(ctx, time.Now().Add(p.timeout+leaseMargin))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	jobErr := p.run(ctx, job)
+	stopping := ctx.Err() != nil
+	// The outcome is recorded even if the pool is stopping.
+	ctx = context.WithoutCancel(ctx)
+	if jobErr == nil {
+		return true, p.store.Complete(ctx, job)
+	}
+
+	reason := jobErr.Error()
+	if len(reason) > maxErrorLength {
+		reason = reason[:maxErrorLength]
+	}
+	if stopping {
+		slog.Info("job interrupted by shutdown", "id", job.ID, "kind", job.Kind)
+		return true, p.store.Retry(ctx, job, time.Now(), reason)
+	}
+	if job.Attempts >= job.MaxAttempts || errors.Is(jobErr, errUnknownKind) {
+		slog.Warn("job failed", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", jobErr)
+		return true, p.store.Fail(ctx, job, reason)
+	}
+	return true, p.store.Retry(ctx, job, time.Now().Add(p.backoff.Delay(job.Attempts-1)), reason)
+}
+
+// run runs the handler of a job, recovering from panics.
+func (p *Pool) run(ctx context.Context, job *Job) (err error) {
+	h, ok := p.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("%w %q", errUnknownKind, job.Kind)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r) //nolint:err113
+		}
+	}()
+	return h(ctx, job)
+}
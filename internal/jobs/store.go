@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// ErrLeaseLost is returned when recording the outcome of a job whose lease
+// expired, and which may have been claimed again by another worker.
+var ErrLeaseLost = errors.New("job lease lost")
+
+// Store is the storage of the queue used by workers. The outcome of a job
+// is recorded with the lease of its claim, ErrLeaseLost is returned if it
+// isn't held anymore.
+type Store interface {
+	// Claim returns the next due job leased until leaseUntil, nil if none.
+	Claim(ctx context.Context, leaseUntil time.Time) (*Job, error)
+	Complete(ctx context.Context, job *Job) error
+	Retry(ctx context.Context, job *Job, runAt time.Time, reason string) error
+	Fail(ctx context.Context, job *Job, reason string) error
+}
+
+// queriesStore is a store over sqlc-generated queries.
+type queriesStore struct {
+	queries repository.Querier
+}
+
+// NewStore creates a new store.
+func NewStore(queries repository.Querier) Store {
+	return &queriesStore{queries: queries}
+}
+
+func (s *queriesStore) Claim(ctx context.Context, leaseUntil time.Time) (*Job, error) {
+	dbJob, err := s.queries.ClaimJob(ctx, leaseUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil //nolint:nilnil // no due job
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not claim job: %w", err)
+	}
+	return &Job{
+		ID:          dbJob.ID,
+		Kind:        dbJob.Kind,
+		Payload:     dbJob.Payload,
+		Attempts:    int(dbJob.Attempts),
+		MaxAttempts: int(dbJob.MaxAttempts),
+		LeaseUntil:  dbJob.LockedUntil.Time,
+	}, nil
+}
+
+func (s *queriesStore) Complete(ctx context.Context, job *Job) error {
+	updated, err := s.queries.CompleteJob(ctx, repository.CompleteJobParams{
+		ID:         job.ID,
+		LeaseUntil: job.LeaseUntil,
+	})
+	return outcomeError("complete", job, updated, err)
+}
+
+func (s *queriesStore) Retry(ctx context.Context, job *Job, runAt time.Time, reason string) error {
+	updated, err := s.queries.RetryJob(ctx, repository.RetryJobParams{
+		ID:         job.ID,
+		LeaseUntil: job.LeaseUntil,
+		RunAt:      runAt,
+		LastError:  sql.NullString{String: reason, Valid: true},
+	})
+	return outcomeError("retry", job, updated, err)
+}
+
+func (s *queriesStore) Fail(ctx context.Context, job *Job, reason string) error {
+	updated, err := s.queries.FailJob(ctx, repository.FailJobParams{
+		ID:         job.ID,
+		LeaseUntil: job.LeaseUntil,
+		LastError:  sql.NullString{String: reason, Valid: true},
+	})
+	return outcomeError("fail", job, updated, err)
+}
+
+// outcomeError returns the error of recording the outcome of job, which
+// updated rows.
+func outcomeError(action string, job *Job, updated int64, err error) error {
+	switch {
+	case err != nil:
+		return fmt.Errorf("could not %s job %d: %w", action, job.ID, err)
+	case updated == 0:
+		return fmt.Errorf("could not %s job %d: %w", action, job.ID, ErrLeaseLost)
+	default:
+		return nil
+	}
+}
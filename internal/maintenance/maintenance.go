@@ -0,0 +1,66 @@
+// Package maintenance provides the periodic maintenance tasks of the database.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// DefaultRetention is the default time processed rows are kept.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Tasks are the maintenance tasks.
+type Tasks struct {
+	queries   repository.Querier
+	retention time.Duration
+}
+
+// NewTasks creates the maintenance tasks, processed rows older than
+// retention are purged (0 means default).
+func NewTasks(queries repository.Querier, retention time.Duration) *Tasks {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	return &Tasks{queries: queries, retention: retention}
+}
+
+// PurgeOutbox deletes published outbox events.
+func (t *Tasks) PurgeOutbox(ctx context.Context) error {
+	return t.purge(ctx, "outbox", t.queries.PurgePublishedOutboxEvents)
+}
+
+// PurgeJobs deletes succeeded and failed jobs.
+func (t *Tasks) PurgeJobs(ctx context.Context) error {
+	return t.purge(ctx, "jobs", t.queries.PurgeFinishedJobs)
+}
+
+// PurgeWebhookDeliveries deletes delivered and dead webhook deliveries.
+func (t *Tasks) PurgeWebhookDeliveries(ctx context.Context) error {
+	return t.purge(ctx, "webhook_deliveries", t.queries.PurgeFinishedWebhookDeliveries)
+}
+
+// PurgeSchedulerRuns deletes the finished runs of the scheduled tasks.
+func (t *Tasks) PurgeSchedulerRuns(ctx context.Context) error {
+	return t.purge(ctx, "scheduler_runs", t.queries.PurgeSchedulerRuns)
+}
+
+// RefreshStatistics updates the planner statistics of the catalog tables.
+func (t *Tasks) RefreshStatistics(ctx context.Context) error {
+	if err := t.queries.AnalyzeCatalog(ctx); err != nil {
+		return fmt.Errorf("could not analyze catalog tables: %w", err)
+	}
+	return nil
+}
+
+func (t *Tasks) purge(ctx context.Context, table string, query func(context.Context, time.Time) (int64, error)) error {
+	deleted, err := query(ctx, time.Now().Add(-t.retention))
+	if err != nil {
+		return fmt.Errorf("could not purge %s: %w", table, err)
+	}
+	slog.Info("purged processed rows", "table", table, "deleted", deleted)
+	return nil
+}
@@ -0,0 +1,33 @@
+package maintenance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/maintenance"
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueries records purge cutoffs, the embedded Querier is nil and panics
+// on other queries.
+type fakeQueries struct {
+	repository.Querier
+	before time.Time
+}
+
+func (q *fakeQueries) PurgePublishedOutboxEvents(_ context.Context, before time.Time) (int64, error) {
+	q.before = before
+	return 3, nil
+}
+
+func TestPurgeRetention(t *testing.T) {
+	q := &fakeQueries{}
+	require.NoError(t, maintenance.NewTasks(q, time.Hour).PurgeOutbox(context.Background()))
+	assert.WithinDuration(t, time.Now().Add(-time.Hour), q.before, time.Minute)
+
+	require.NoError(t, maintenance.NewTasks(q, 0).PurgeOutbox(context.Background()))
+	assert.WithinDuration(t, time.Now().Add(-maintenance.DefaultRetention), q.before, time.Minute)
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// AdminToken restricts routes to the operators presenting token in an
+// "Authorization: Bearer" header. Other requests get a 401 UNAUTHORIZED
+// error, every request when token is empty.
+func AdminToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				apperror.WriteError(w, &apperror.AppError{
+					Code:    apperror.ErrCodeUnauthorized,
+					Message: "Admin token required",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	request := func(token, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		middleware.AdminToken(token)(ok).ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, request("s3cret", "Bearer s3cret").Code)
+
+	rec := request("s3cret", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, rec.Body.String(), "UNAUTHORIZED")
+	assert.Equal(t, http.StatusUnauthorized, request("s3cret", "Bearer other").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("s3cret", "Basic s3cret").Code)
+	// no token, no access
+	assert.Equal(t, http.StatusUnauthorized, request("", "Bearer ").Code)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+)
+
+// CallerHeader identifies the caller of a request: a user or service ID set
+// by the authenticating gateway in front of the server. The server doesn't
+// authenticate requests, the header must not be accepted from clients.
+const CallerHeader = "X-Caller-ID"
+
+// Caller returns the identity of the caller of r, empty when anonymous.
+func Caller(r *http.Request) string {
+	return r.Header.Get(CallerHeader)
+}
+
+// ClientIP returns the IP address of the client of r. Behind a proxy, it is
+// the address of the proxy.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
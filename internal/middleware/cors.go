@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// corsMaxAge is the time browsers can cache the response of a preflight
+// request, in seconds.
+const corsMaxAge = 600
+
+// corsAllowAll allows any origin.
+const corsAllowAll = "*"
+
+// CORS allows browsers to call the API from other origins (Cross-Origin
+// Resource Sharing). The allowed origins can be changed while serving
+// requests.
+type CORS struct {
+	origins atomic.Pointer[[]string]
+}
+
+// NewCORS allows the origins, e.g. https://example.com, or any origin with
+// "*". CORS requests are not allowed when origins is empty.
+func NewCORS(origins []string) *CORS {
+	c := &CORS{}
+	c.SetOrigins(origins)
+	return c
+}
+
+// SetOrigins changes the allowed origins, see NewCORS.
+func (c *CORS) SetOrigins(origins []string) {
+	origins = slices.Clone(origins)
+	c.origins.Store(&origins)
+}
+
+// Handler adds the CORS headers to the responses to allowed origins, and
+// answers their preflight requests.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		origins := *c.origins.Load()
+		if origin == "" || len(origins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := slices.Contains(origins, origin) || slices.Contains(origins, corsAllowAll)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Link, Retry-After")
+		}
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+		}
+		// Without the headers, browsers block the request of a disallowed origin.
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
@@ -0,0 +1,63 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	cors := middleware.NewCORS([]string{"https://app.example.com"})
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(method, origin string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/authors", nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	preflight := http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"Content-Type, Authorization"},
+	}
+
+	rec := request(http.MethodGet, "https://app.example.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Link, Retry-After", rec.Header().Get("Access-Control-Expose-Headers"))
+
+	rec = request(http.MethodOptions, "https://app.example.com", preflight)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, PUT, DELETE", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", rec.Header().Get("Access-Control-Allow-Headers"))
+
+	rec = request(http.MethodGet, "https://evil.example.com", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	rec = request(http.MethodOptions, "https://evil.example.com", preflight)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+
+	rec = request(http.MethodGet, "", nil)
+	assert.Empty(t, rec.Header().Get("Vary"), "same-origin requests are untouched")
+
+	cors.SetOrigins([]string{"*"})
+	rec = request(http.MethodGet, "https://evil.example.com", nil)
+	assert.Equal(t, "https://evil.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	cors.SetOrigins(nil)
+	rec = request(http.MethodOptions, "https://app.example.com", preflight)
+	assert.Equal(t, http.StatusOK, rec.Code, "preflight requests are passed through when CORS is disabled")
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,286 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/openapi"
+)
+
+const contentTypeJSON = "application/json"
+
+// JSON pointer roots of the parts of a request or response in violations.
+const (
+	pointerPath   = "/path"
+	pointerQuery  = "/query"
+	pointerHeader = "/header"
+	pointerBody   = "/body"
+	pointerStatus = "/status"
+)
+
+var errInvalidResponse = errors.New("response does not match the API description")
+
+// ValidationOption configures OpenAPIValidation.
+type ValidationOption func(*validator)
+
+// WithResponseValidation also validates JSON responses. Responses are
+// buffered to be checked before being sent, which is meant for tests:
+// an invalid response is replaced by an internal error listing the
+// violations. Streamed responses (exports, events) aren't validated.
+func WithResponseValidation() ValidationOption {
+	return func(v *validator) {
+		v.responses = true
+	}
+}
+
+type validator struct {
+	doc       *openapi.Document
+	responses bool
+}
+
+// OpenAPIValidation validates requests against the operations of doc:
+// path parameters, query parameters, headers and JSON bodies. Violations
+// are returned as a validation error whose details map the JSON pointer
+// of each invalid value (e.g. /query/limit or /body/name) to the reason.
+// Requests to undocumented routes are passed through.
+func OpenAPIValidation(doc *openapi.Document, opts ...ValidationOption) func(http.Handler) http.Handler {
+	v := &validator{doc: doc}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, params := v.doc.FindOperation(r.Method, r.URL.Path)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			violations, err := v.validateRequest(r, op, params)
+			if err != nil {
+				apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+				return
+			}
+			if len(violations) > 0 {
+				apperror.WriteError(w, apperror.NewValidationError(
+					"Request does not match the API description", violationDetails(violations)))
+				return
+			}
+
+			if !v.responses || isStreamed(op) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			rec := &responseRecorder{header: w.Header().Clone(), status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			v.writeResponse(w, r, op, rec)
+		})
+	}
+}
+
+// validateRequest returns the violations of r. The JSON body is read and
+// replaced so that handlers can decode it.
+func (v *validator) validateRequest(r *http.Request, op *openapi.Operation, params map[string]string) ([]openapi.Violation, error) {
+	var violations []openapi.Violation
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		var (
+			raw     string
+			present bool
+			pointer string
+		)
+		switch p.In {
+		case openapi.InPath:
+			raw, present = params[p.Name]
+			pointer = openapi.Pointer(pointerPath, p.Name)
+		case openapi.InQuery:
+			present = query.Has(p.Name)
+			raw = query.Get(p.Name)
+			pointer = openapi.Pointer(pointerQuery, p.Name)
+		case openapi.InHeader:
+			values := r.Header.Values(p.Name)
+			present = len(values) > 0
+			raw = strings.Join(values, ",")
+			pointer = openapi.Pointer(pointerHeader, http.CanonicalHeaderKey(p.Name))
+		}
+		if !present {
+			if p.Required {
+				violations = append(violations, openapi.Violation{Pointer: pointer, Message: "is required"})
+			}
+			continue
+		}
+		violations = append(violations, v.doc.ValidateParameter(p, raw, pointer)...)
+	}
+
+	if op.RequestBody == nil {
+		return violations, nil
+	}
+	mediaType := contentTypeJSON
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+	}
+	media, ok := op.RequestBody.Content[mediaType]
+	if !ok {
+		return append(violations, openapi.Violation{
+			Pointer: openapi.Pointer(pointerHeader, "Content-Type"),
+			Message: "must be one of " + strings.Join(mediaTypes(op.RequestBody.Content), ", "),
+		}), nil
+	}
+	if mediaType != contentTypeJSON {
+		// Imports are streamed, their lines are validated by the handlers.
+		return violations, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			violations = append(violations, openapi.Violation{Pointer: pointerBody, Message: "is required"})
+		}
+		return violations, nil
+	}
+	value, err := decodeJSON(body)
+	if err != nil {
+		return append(violations, openapi.Violation{Pointer: pointerBody, Message: "must be valid JSON"}), nil
+	}
+	return append(violations, v.doc.Validate(media.Schema, value, pointerBody)...), nil
+}
+
+// writeResponse sends the recorded response if it matches the operation,
+// an internal error otherwise.
+func (v *validator) writeResponse(w http.ResponseWriter, r *http.Request, op *openapi.Operation, rec *responseRecorder) {
+	violations := v.validateResponse(op, rec)
+	if len(violations) > 0 {
+		details := violationDetails(violations)
+		slog.Error("invalid response",
+			"method", r.Method, "path", r.URL.Path, "status", rec.status, "violations", details)
+		apperror.WriteError(w, &apperror.AppError{
+			Code:    apperror.ErrCodeInternal,
+			Message: errInvalidResponse.Error(),
+			Details: details,
+			Err:     errInvalidResponse,
+		})
+		return
+	}
+
+	for key, values := range rec.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(rec.status)
+	_, _ = w.Write(rec.body.Bytes())
+}
+
+func (v *validator) validateResponse(op *openapi.Operation, rec *responseRecorder) []openapi.Violation {
+	response, ok := op.Responses[strconv.Itoa(rec.status)]
+	if !ok {
+		return []openapi.Violation{{Pointer: pointerStatus, Message: "is not documented"}}
+	}
+	if len(response.Content) == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(rec.header.Get("Content-Type"))
+	media, ok := response.Content[mediaType]
+	if !ok {
+		return []openapi.Violation{{
+			Pointer: openapi.Pointer(pointerHeader, "Content-Type"),
+			Message: "must be one of " + strings.Join(mediaTypes(response.Content), ", "),
+		}}
+	}
+	if mediaType != contentTypeJSON {
+		return nil
+	}
+	value, err := decodeJSON(rec.body.Bytes())
+	if err != nil {
+		return []openapi.Violation{{Pointer: pointerBody, Message: "must be valid JSON"}}
+	}
+	return v.doc.Validate(media.Schema, value, pointerBody)
+}
+
+// isStreamed reports whether the successful responses of op are streamed
+// instead of JSON documents.
+func isStreamed(op *openapi.Operation) bool {
+	for status, response := range op.Responses {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		for mediaType := range response.Content {
+			if mediaType != contentTypeJSON {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	if decoder.More() {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return value, nil
+}
+
+// violationDetails maps the pointers of violations to their messages.
+func violationDetails(violations []openapi.Violation) map[string]string {
+	details := make(map[string]string, len(violations))
+	for _, violation := range violations {
+		if msg, ok := details[violation.Pointer]; ok {
+			details[violation.Pointer] = msg + "; " + violation.Message
+			continue
+		}
+		details[violation.Pointer] = violation.Message
+	}
+	return details
+}
+
+func mediaTypes(content map[string]*openapi.MediaType) []string {
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// responseRecorder buffers a response to validate it before sending it.
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b) //nolint:wrapcheck
+}
@@ -0,0 +1,136 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type itemRequest struct {
+	Name string `json:"name"`
+}
+
+type itemResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func newDocument() *openapi.Document {
+	doc := openapi.New(openapi.Info{Title: "test", Version: "1"})
+	doc.Register("ItemRequest", itemRequest{}).Properties["name"].MinLength = openapi.Ptr(3)
+	doc.Register("ItemResponse", itemResponse{})
+	jsonContent := func(schema *openapi.Schema) map[string]*openapi.MediaType {
+		return map[string]*openapi.MediaType{"application/json": {Schema: schema}}
+	}
+	doc.AddOperation(http.MethodPost, "/items/{id}", &openapi.Operation{
+		OperationID: "putItem",
+		Parameters: []*openapi.Parameter{
+			{Name: "id", In: openapi.InPath, Required: true, Schema: &openapi.Schema{Type: openapi.TypeInteger}},
+			{Name: "dry", In: openapi.InQuery, Schema: &openapi.Schema{Type: openapi.TypeBoolean}},
+			{Name: "X-Tenant", In: openapi.InHeader, Required: true, Schema: &openapi.Schema{Type: openapi.TypeString}},
+		},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent(openapi.Ref("ItemRequest"))},
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "Item", Content: jsonContent(openapi.Ref("ItemResponse"))},
+		},
+	})
+	return doc
+}
+
+// echo answers with the name of the request, and the id given by the test.
+func echo(id any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req itemRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": req.Name})
+	}
+}
+
+func serve(t *testing.T, handler http.Handler, target, body string, header http.Header) (*httptest.ResponseRecorder, apperror.ErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var errResp apperror.ErrorResponse
+	if rec.Code != http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	}
+	return rec, errResp
+}
+
+func TestOpenAPIValidation_Request(t *testing.T) {
+	handler := middleware.OpenAPIValidation(newDocument())(echo(1))
+	header := http.Header{"X-Tenant": {"acme"}}
+
+	rec, _ := serve(t, handler, "/items/1?dry=true", `{"name":"abc"}`, header)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":1,"name":"abc"}`, rec.Body.String(), "the body is still readable by the handler")
+
+	rec, errResp := serve(t, handler, "/items/one?dry=maybe", `{"name":"ab","extra":[]}`, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, apperror.ErrCodeValidation, errResp.Code)
+	assert.Equal(t, map[string]string{
+		"/path/id":         "must be of type integer",
+		"/query/dry":       "must be of type boolean",
+		"/header/X-Tenant": "is required",
+		"/body/name":       "must be at least 3 characters",
+	}, errResp.Details)
+
+	rec, errResp = serve(t, handler, "/items/1", `{"name":`, header)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, map[string]string{"/body": "must be valid JSON"}, errResp.Details)
+
+	rec, errResp = serve(t, handler, "/items/1", `name=abc`,
+		http.Header{"X-Tenant": {"acme"}, "Content-Type": {"application/x-www-form-urlencoded"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, map[string]string{"/header/Content-Type": "must be one of application/json"}, errResp.Details)
+}
+
+func TestOpenAPIValidation_Undocumented(t *testing.T) {
+	handler := middleware.OpenAPIValidation(newDocument())(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+}
+
+func TestOpenAPIValidation_Response(t *testing.T) {
+	header := http.Header{"X-Tenant": {"acme"}}
+
+	handler := middleware.OpenAPIValidation(newDocument(), middleware.WithResponseValidation())(echo(1))
+	rec, _ := serve(t, handler, "/items/1", `{"name":"abc"}`, header)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id":1,"name":"abc"}`, rec.Body.String())
+
+	handler = middleware.OpenAPIValidation(newDocument(), middleware.WithResponseValidation())(echo("1"))
+	rec, errResp := serve(t, handler, "/items/1", `{"name":"abc"}`, header)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, apperror.ErrCodeInternal, errResp.Code)
+	assert.Equal(t, map[string]string{"/body/id": "must be of type integer"}, errResp.Details)
+
+	handler = middleware.OpenAPIValidation(newDocument(), middleware.WithResponseValidation())(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = io.WriteString(w, "later")
+		}))
+	rec, errResp = serve(t, handler, "/items/1", `{"name":"abc"}`, header)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, map[string]string{"/status": "is not documented"}, errResp.Details)
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/database"
+)
+
+// ReadPrimaryHeader lets clients force reads to the primary database,
+// e.g. to read their own writes right after a mutating request.
+const ReadPrimaryHeader = "X-Read-Primary"
+
+// PrimaryReads forces database reads to the primary for mutating requests
+// and for requests asking for it with the X-Read-Primary header.
+func PrimaryReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forced, _ := strconv.ParseBool(r.Header.Get(ReadPrimaryHeader))
+		if forced || !isSafeMethod(r.Method) {
+			r = r.WithContext(database.WithPrimary(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
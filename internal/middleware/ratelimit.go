@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// sweepInterval is the interval between two removals of the buckets of
+// idle clients.
+const sweepInterval = time.Minute
+
+// RateLimiter limits the requests of each client, identified by its remote
+// IP address, with a token bucket: a client can send burst requests at
+// once, then rate requests per second. The limit can be changed while
+// serving requests.
+type RateLimiter struct {
+	limit atomic.Pointer[rateLimit]
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type rateLimit struct {
+	rate  float64
+	burst float64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter of rate requests per second per
+// client. A rate <= 0 disables rate limiting, a burst <= 0 means the rate
+// rounded up.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	l := &RateLimiter{buckets: map[string]*bucket{}}
+	l.SetLimit(rate, burst)
+	return l
+}
+
+// SetLimit changes the limit of every client, see NewRateLimiter.
+func (l *RateLimiter) SetLimit(rate float64, burst int) {
+	if rate <= 0 {
+		l.limit.Store(nil)
+		return
+	}
+	if burst <= 0 {
+		burst = int(math.Ceil(rate))
+	}
+	l.limit.Store(&rateLimit{rate: rate, burst: float64(burst)})
+}
+
+// Handler rejects the requests of clients exceeding the limit with a
+// TOO_MANY_REQUESTS error and a Retry-After header.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.allow(ClientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			apperror.WriteError(w, &apperror.AppError{
+				Code:    apperror.ErrCodeTooManyRequests,
+				Message: "Rate limit exceeded",
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token of the bucket of client, or returns the time until
+// the next token.
+func (l *RateLimiter) allow(client string) (bool, time.Duration) {
+	limit := l.limit.Load()
+	if limit == nil {
+		return true, 0
+	}
+
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now, limit)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: limit.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = min(limit.burst, b.tokens+now.Sub(b.last).Seconds()*limit.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / limit.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep removes the buckets refilled since their last request, they are
+// the same as new ones.
+func (l *RateLimiter) sweep(now time.Time, limit *rateLimit) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(limit.burst / limit.rate * float64(time.Second))
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, client)
+		}
+	}
+}
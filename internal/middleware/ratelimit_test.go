@@ -0,0 +1,46 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := middleware.NewRateLimiter(1, 2)
+	handler := limiter.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/authors", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// the burst is allowed, then the client waits for the next token
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1235").Code)
+	rec := request("192.0.2.1:1236")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"code": "TOO_MANY_REQUESTS", "message": "Rate limit exceeded"}`, rec.Body.String())
+
+	// clients are limited separately
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.2:1234").Code)
+
+	// buckets refill at the new rate
+	limiter.SetLimit(1000, 0)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1237").Code)
+
+	limiter.SetLimit(0, 0)
+	for range 10 {
+		assert.Equal(t, http.StatusNoContent, request("192.0.2.1:1238").Code, "a zero rate disables the limit")
+	}
+}
@@ -0,0 +1,5 @@
+// Package openapi describes HTTP APIs with OpenAPI 3.1 documents.
+//
+// It covers the subset of the specification used by this API, and derives
+// JSON schemas from Go types so that documented payloads follow the code.
+package openapi
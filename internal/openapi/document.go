@@ -0,0 +1,220 @@
+package openapi
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Version is the OpenAPI version of documents.
+const Version = "3.1.0"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of an API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds reusable schemas and security schemes.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how operations are authenticated.
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the security schemes required by an operation,
+// with their scopes.
+type SecurityRequirement map[string][]string
+
+// PathItem describes the operations of a path.
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+}
+
+// Operation describes an API operation.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter locations.
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+)
+
+// Parameter describes an operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request by content type.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response by content type.
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header.
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType describes the payload of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// New creates an empty document.
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+}
+
+// AddOperation documents the operation of method on path. Paths use the
+// {param} syntax shared by OpenAPI and chi.
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+}
+
+// Operation returns the operation of method on path, nil if undocumented.
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	switch method {
+	case http.MethodGet:
+		return item.Get
+	case http.MethodPut:
+		return item.Put
+	case http.MethodPost:
+		return item.Post
+	case http.MethodDelete:
+		return item.Delete
+	case http.MethodPatch:
+		return item.Patch
+	default:
+		return nil
+	}
+}
+
+// Routes returns the documented "METHOD path" pairs, sorted.
+func (d *Document) Routes() []string {
+	var routes []string
+	for path := range d.Paths {
+		for _, method := range []string{
+			http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete, http.MethodPatch,
+		} {
+			if d.Operation(method, path) != nil {
+				routes = append(routes, method+" "+path)
+			}
+		}
+	}
+	slices.SortFunc(routes, strings.Compare)
+	return routes
+}
+
+// FindOperation returns the operation of method matching the request path,
+// with the values of its path parameters. Literal segments take precedence
+// over parameters, as in chi. It returns nil if no operation matches.
+func (d *Document) FindOperation(method, path string) (*Operation, map[string]string) {
+	segments := strings.Split(path, "/")
+
+	var (
+		found    *Operation
+		params   map[string]string
+		bestRank = -1
+	)
+	for template := range d.Paths {
+		op := d.Operation(method, template)
+		if op == nil {
+			continue
+		}
+		values, rank, ok := matchPath(strings.Split(template, "/"), segments)
+		if ok && rank > bestRank {
+			found, params, bestRank = op, values, rank
+		}
+	}
+	return found, params
+}
+
+// matchPath matches path segments against template segments, ranking the
+// match by its number of literal segments.
+func matchPath(template, segments []string) (map[string]string, int, bool) {
+	if len(template) != len(segments) {
+		return nil, 0, false
+	}
+	values := map[string]string{}
+	rank := 0
+	for i, t := range template {
+		if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			values[t[1:len(t)-1]] = segments[i]
+			continue
+		}
+		if t != segments[i] {
+			return nil, 0, false
+		}
+		rank++
+	}
+	return values, rank, true
+}
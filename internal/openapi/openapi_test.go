@@ -0,0 +1,131 @@
+package openapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   int64    `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+func newDocument() *openapi.Document {
+	doc := openapi.New(openapi.Info{Title: "test", Version: "1"})
+	schema := doc.Register("Item", item{})
+	schema.Properties["name"].MinLength = openapi.Ptr(2)
+	schema.Properties["name"].MaxLength = openapi.Ptr(4)
+	doc.AddOperation(http.MethodGet, "/items/{id}", &openapi.Operation{OperationID: "getItem"})
+	doc.AddOperation(http.MethodPost, "/items:batch", &openapi.Operation{OperationID: "createItems"})
+	doc.AddOperation(http.MethodGet, "/items/export", &openapi.Operation{OperationID: "exportItems"})
+	return doc
+}
+
+func decode(t *testing.T, data string) any {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var v any
+	require.NoError(t, decoder.Decode(&v))
+	return v
+}
+
+func TestRegister(t *testing.T) {
+	doc := newDocument()
+	schema := doc.Components.Schemas["Item"]
+	require.NotNil(t, schema)
+	assert.Equal(t, []string{"id", "name"}, schema.Required)
+	assert.Equal(t, "int64", schema.Properties["id"].Format)
+	assert.Equal(t, openapi.TypeArray, schema.Properties["tags"].Type)
+
+	ref := doc.SchemaOf([]item{})
+	assert.Equal(t, openapi.RefPrefix+"Item", ref.Items.Ref)
+	assert.Same(t, schema, doc.Resolve(ref.Items))
+}
+
+func TestValidate(t *testing.T) {
+	doc := newDocument()
+	schema := doc.SchemaOf([]item{})
+
+	tests := []struct {
+		name string
+		data string
+		want []openapi.Violation
+	}{
+		{name: "valid", data: `[{"id":1,"name":"abc","tags":["a"]}]`},
+		{
+			name: "wrong type",
+			data: `{"id":1}`,
+			want: []openapi.Violation{{Pointer: "/body", Message: "must be of type array"}},
+		},
+		{
+			name: "missing and invalid properties",
+			data: `[{"id":1.5,"name":"abcdef"},{"name":"a/b","tags":[1]}]`,
+			want: []openapi.Violation{
+				{Pointer: "/body/0/id", Message: "must be of type integer"},
+				{Pointer: "/body/0/name", Message: "must be at most 4 characters"},
+				{Pointer: "/body/1/id", Message: "is required"},
+				{Pointer: "/body/1/tags/0", Message: "must be of type string"},
+			},
+		},
+		{
+			name: "integer overflow",
+			data: `[{"id":9223372036854775808,"name":"abc"}]`,
+			want: []openapi.Violation{{Pointer: "/body/0/id", Message: "must be a 64-bit integer"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, doc.Validate(schema, decode(t, tt.data), "/body"))
+		})
+	}
+}
+
+func TestValidateParameter(t *testing.T) {
+	doc := newDocument()
+	limit := &openapi.Parameter{
+		Name:   "limit",
+		In:     openapi.InQuery,
+		Schema: &openapi.Schema{Type: openapi.TypeInteger, Minimum: openapi.Ptr(1.0), Maximum: openapi.Ptr(10.0)},
+	}
+
+	assert.Empty(t, doc.ValidateParameter(limit, "5", "/query/limit"))
+	assert.Equal(t, []openapi.Violation{{Pointer: "/query/limit", Message: "must be of type integer"}},
+		doc.ValidateParameter(limit, "five", "/query/limit"))
+	assert.Equal(t, []openapi.Violation{{Pointer: "/query/limit", Message: "must be less than or equal to 10"}},
+		doc.ValidateParameter(limit, "11", "/query/limit"))
+}
+
+func TestPointer(t *testing.T) {
+	assert.Equal(t, "/header/a~1b~0c", openapi.Pointer("/header", "a/b~c"))
+}
+
+func TestFindOperation(t *testing.T) {
+	doc := newDocument()
+
+	op, params := doc.FindOperation(http.MethodGet, "/items/42")
+	require.NotNil(t, op)
+	assert.Equal(t, "getItem", op.OperationID)
+	assert.Equal(t, map[string]string{"id": "42"}, params)
+
+	// Literal segments take precedence over parameters.
+	op, _ = doc.FindOperation(http.MethodGet, "/items/export")
+	require.NotNil(t, op)
+	assert.Equal(t, "exportItems", op.OperationID)
+
+	op, _ = doc.FindOperation(http.MethodPost, "/items:batch")
+	require.NotNil(t, op)
+	assert.Equal(t, "createItems", op.OperationID)
+
+	op, _ = doc.FindOperation(http.MethodDelete, "/items/42")
+	assert.Nil(t, op)
+	op, _ = doc.FindOperation(http.MethodGet, "/items/")
+	assert.Nil(t, op)
+}
@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSON schema types.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+)
+
+// Schema is a JSON schema (draft 2020-12, as used by OpenAPI 3.1).
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+
+	// goType is the Go type the schema is derived from, to reference it.
+	goType reflect.Type
+}
+
+// Ptr returns a pointer to v, to set optional constraints.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// RefPrefix is the prefix of references to component schemas.
+const RefPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Register adds the schema of the type of v to the components under name.
+// Schemas derived afterwards reference it instead of inlining it.
+func (d *Document) Register(name string, v any) *Schema {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	schema := d.schemaOf(t, false)
+	d.Components.Schemas[name] = schema
+	return schema
+}
+
+// SchemaOf derives the schema of the type of v. Registered types are
+// referenced.
+func (d *Document) SchemaOf(v any) *Schema {
+	return d.schemaOf(reflect.TypeOf(v), true)
+}
+
+// Ref returns a reference to the component schema name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: RefPrefix + name}
+}
+
+// Resolve returns the component schema referenced by s, s otherwise.
+func (d *Document) Resolve(s *Schema) *Schema {
+	if s == nil || s.Ref == "" {
+		return s
+	}
+	return d.Components.Schemas[strings.TrimPrefix(s.Ref, RefPrefix)]
+}
+
+func (d *Document) schemaOf(t reflect.Type, useRefs bool) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if useRefs {
+		for name, schema := range d.Components.Schemas {
+			if schema.goType == t {
+				return Ref(name)
+			}
+		}
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: TypeString, Format: "date-time"}
+	case t == rawMessageType:
+		// Any JSON value.
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: TypeInteger}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: TypeInteger, Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: TypeNumber}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: TypeArray, Items: d.schemaOf(t.Elem(), true)}
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: d.schemaOf(t.Elem(), true)}
+	case reflect.Struct:
+		return d.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema derives the schema of a struct from its JSON encoding:
+// fields without omitempty are required.
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: TypeObject, Properties: map[string]*Schema{}, goType: t}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = d.schemaOf(field.Type, true)
+		if !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
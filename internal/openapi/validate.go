@@ -0,0 +1,196 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is a value not matching its schema.
+type Violation struct {
+	// Pointer locates the value with a JSON pointer (RFC 6901).
+	Pointer string
+	Message string
+}
+
+// Pointer appends the reference tokens to the JSON pointer base,
+// escaping them.
+func Pointer(base string, tokens ...string) string {
+	var b strings.Builder
+	b.WriteString(base)
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// Validate checks a value decoded from JSON against s. Numbers are
+// expected as json.Number (json.Decoder.UseNumber) to check integers
+// without loss of precision.
+func (d *Document) Validate(s *Schema, v any, pointer string) []Violation {
+	s = d.Resolve(s)
+	if s == nil {
+		return nil
+	}
+
+	var violations []Violation
+	invalid := func(format string, args ...any) {
+		violations = append(violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !matchesType(s.Type, v) {
+		invalid("must be of type %s", s.Type)
+		return violations
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(v) }) {
+		invalid("must be one of %s", joinEnum(s.Enum))
+	}
+
+	switch v := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			invalid("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			invalid("must be at most %d characters", *s.MaxLength)
+		}
+		if msg := checkFormat(s.Format, v); msg != "" {
+			invalid("%s", msg)
+		}
+	case json.Number:
+		if s.Type == TypeInteger {
+			if _, err := strconv.ParseInt(v.String(), 10, 64); err != nil {
+				invalid("must be a 64-bit integer")
+				return violations
+			}
+		}
+		n, _ := v.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			invalid("must be greater than or equal to %v", *s.Minimum)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			invalid("must be less than or equal to %v", *s.Maximum)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			invalid("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			invalid("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			violations = append(violations, d.Validate(s.Items, item, Pointer(pointer, strconv.Itoa(i)))...)
+		}
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				violations = append(violations, Violation{Pointer: Pointer(pointer, name), Message: "is required"})
+			}
+		}
+		// Sorted, so that violations are reported in a stable order.
+		for _, name := range sortedKeys(v) {
+			property, ok := s.Properties[name]
+			if !ok {
+				property = s.AdditionalProperties
+			}
+			violations = append(violations, d.Validate(property, v[name], Pointer(pointer, name))...)
+		}
+	}
+	return violations
+}
+
+// ValidateParameter checks the raw value of a parameter, converted to the
+// type of its schema.
+func (d *Document) ValidateParameter(p *Parameter, raw, pointer string) []Violation {
+	schema := d.Resolve(p.Schema)
+	if schema == nil {
+		return nil
+	}
+
+	var v any = raw
+	switch schema.Type {
+	case TypeInteger, TypeNumber:
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return []Violation{{Pointer: pointer, Message: "must be of type " + schema.Type}}
+		}
+		v = json.Number(raw)
+	case TypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []Violation{{Pointer: pointer, Message: "must be of type boolean"}}
+		}
+		v = b
+	}
+	return d.Validate(schema, v, pointer)
+}
+
+func matchesType(typ string, v any) bool {
+	switch typ {
+	case "":
+		return true
+	case TypeString:
+		_, ok := v.(string)
+		return ok
+	case TypeInteger:
+		n, ok := v.(json.Number)
+		return ok && !strings.ContainsAny(n.String(), ".eE")
+	case TypeNumber:
+		_, ok := v.(json.Number)
+		return ok
+	case TypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case TypeArray:
+		_, ok := v.([]any)
+		return ok
+	case TypeObject:
+		_, ok := v.(map[string]any)
+		return ok
+	default:
+		return false
+	}
+}
+
+// checkFormat returns why v doesn't match format, empty when it does or
+// when the format isn't checked.
+func checkFormat(format, v string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "uri":
+		if u, err := url.Parse(v); err != nil || !u.IsAbs() || u.Host == "" {
+			return "must be an absolute URI"
+		}
+	case "uint64":
+		if _, err := strconv.ParseUint(v, 10, 64); err != nil {
+			return "must be an unsigned 64-bit integer"
+		}
+	}
+	return ""
+}
+
+func joinEnum(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		values[i] = fmt.Sprint(e)
+	}
+	return strings.Join(values, ", ")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
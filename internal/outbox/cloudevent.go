@@ -0,0 +1,42 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+const (
+	// SpecVersion is the CloudEvents specification version of envelopes.
+	SpecVersion = "1.0"
+	// DefaultSource is the default source of events.
+	DefaultSource = "template-api"
+)
+
+// CloudEvent is the CloudEvents JSON envelope of a published event.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// newCloudEvent wraps an outbox row in a CloudEvents envelope.
+// The subject identifies the aggregate, e.g. author/42.
+func newCloudEvent(source string, row repository.Outbox) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              row.EventID.String(),
+		Source:          source,
+		Type:            row.EventType,
+		Subject:         row.AggregateType + "/" + row.AggregateID,
+		Time:            row.CreatedAt.UTC(),
+		DataContentType: "application/json",
+		Data:            row.Payload,
+	}
+}
@@ -0,0 +1,12 @@
+// Package outbox publishes domain events through a transactional outbox.
+//
+// Events are inserted in the outbox table within the transaction of the
+// mutation they describe, so an event is recorded if and only if the
+// mutation is committed. A relay then publishes pending events, as
+// CloudEvents JSON envelopes, and marks them published.
+//
+// Delivery is at-least-once: an event may be published again if the relay
+// stops between publishing and marking it. Events are published in insertion
+// order by a single relay at a time, which preserves the order of events
+// of the same aggregate.
+package outbox
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Event is a domain event about an aggregate.
+type Event struct {
+	AggregateType string
+	AggregateID   string
+	Type          string
+	// Data is encoded in JSON as the payload of the event.
+	Data any
+}
+
+// Insert records events in the outbox. q should be bound to the transaction
+// of the mutation the events describe.
+func Insert(ctx context.Context, q repository.Querier, events ...Event) error {
+	now := time.Now().UTC()
+	for _, ev := range events {
+		payload, err := json.Marshal(ev.Data)
+		if err != nil {
+			return fmt.Errorf("could not encode %s event: %w", ev.Type, err)
+		}
+		err = q.InsertOutboxEvent(ctx, repository.InsertOutboxEventParams{
+			EventID:       uuid.New(),
+			AggregateType: ev.AggregateType,
+			AggregateID:   ev.AggregateID,
+			EventType:     ev.Type,
+			Payload:       payload,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return fmt.Errorf("could not insert %s event in outbox: %w", ev.Type, err)
+		}
+	}
+	return nil
+}
+
+// Record runs a mutation returning the events it describes. When tx is not
+// nil, the mutation runs in a transaction which also records the events.
+// Otherwise the mutation runs on queries and events are dropped.
+func Record(ctx context.Context, tx repository.Transactor, queries repository.Querier,
+	fn func(q repository.Querier) ([]Event, error),
+) error {
+	if tx == nil {
+		_, err := fn(queries)
+		return err
+	}
+	return tx.InTx(ctx, func(q repository.Querier) error {
+		events, err := fn(q)
+		if err != nil {
+			return err
+		}
+		return Insert(ctx, q, events...)
+	})
+}
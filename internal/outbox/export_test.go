@@ -0,0 +1,4 @@
+package outbox
+
+// NewCloudEvent exports newCloudEvent to the external tests.
+var NewCloudEvent = newCloudEvent
@@ -0,0 +1,209 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBroker = errors.New("broker unavailable")
+
+// fakeOutbox is an in-memory outbox, the embedded Querier is nil and panics
+// on queries unused by the outbox.
+type fakeOutbox struct {
+	repository.Querier
+	rows   []repository.Outbox
+	locked bool
+}
+
+func (f *fakeOutbox) InTx(_ context.Context, fn func(q repository.Querier) error) error {
+	return fn(f)
+}
+
+func (f *fakeOutbox) InsertOutboxEvent(_ context.Context, arg repository.InsertOutboxEventParams) error {
+	f.rows = append(f.rows, repository.Outbox{
+		ID:            int64(len(f.rows) + 1),
+		EventID:       arg.EventID,
+		AggregateType: arg.AggregateType,
+		AggregateID:   arg.AggregateID,
+		EventType:     arg.EventType,
+		Payload:       arg.Payload,
+		CreatedAt:     arg.CreatedAt,
+	})
+	return nil
+}
+
+func (f *fakeOutbox) TryOutboxRelayLock(_ context.Context, _ int64) (bool, error) {
+	return !f.locked, nil
+}
+
+func (f *fakeOutbox) ClaimOutboxEvents(_ context.Context, limit int32) ([]repository.Outbox, error) {
+	var rows []repository.Outbox
+	for _, row := range f.rows {
+		if !row.PublishedAt.Valid && len(rows) < int(limit) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeOutbox) MarkOutboxEventsPublished(_ context.Context, ids []int64) error {
+	for _, id := range ids {
+		f.rows[id-1].PublishedAt.Valid = true
+	}
+	return nil
+}
+
+type fakePublisher struct {
+	events []outbox.CloudEvent
+	failAt int
+}
+
+func (p *fakePublisher) Publish(_ context.Context, ev outbox.CloudEvent) error {
+	if p.failAt > 0 && len(p.events)+1 == p.failAt {
+		return errBroker
+	}
+	p.events = append(p.events, ev)
+	return nil
+}
+
+func insertEvents(t *testing.T, f *fakeOutbox, n int) {
+	t.Helper()
+	for i := range n {
+		err := outbox.Insert(context.Background(), f, outbox.Event{
+			AggregateType: "author",
+			AggregateID:   "1",
+			Type:          "AuthorCreated",
+			Data:          map[string]int{"seq": i},
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestRelayOnce(t *testing.T) {
+	f := &fakeOutbox{}
+	insertEvents(t, f, 3)
+	publisher := &fakePublisher{}
+	relay := outbox.NewRelay(f, publisher, outbox.WithBatchSize(2), outbox.WithSource("test"))
+
+	published, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	published, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+
+	require.Len(t, publisher.events, 3)
+	for i, ev := range publisher.events {
+		assert.Equal(t, outbox.SpecVersion, ev.SpecVersion)
+		assert.Equal(t, "test", ev.Source)
+		assert.Equal(t, "AuthorCreated", ev.Type)
+		assert.Equal(t, "author/1", ev.Subject)
+		assert.Equal(t, f.rows[i].EventID.String(), ev.ID)
+		assert.JSONEq(t, fmt.Sprintf(`{"seq":%d}`, i), string(ev.Data))
+	}
+}
+
+func TestRelayOnceStopsAtFirstFailure(t *testing.T) {
+	f := &fakeOutbox{}
+	insertEvents(t, f, 3)
+	publisher := &fakePublisher{failAt: 2}
+	relay := outbox.NewRelay(f, publisher)
+
+	published, err := relay.RelayOnce(context.Background())
+	require.ErrorIs(t, err, errBroker)
+	assert.Equal(t, 1, published)
+	assert.True(t, f.rows[0].PublishedAt.Valid)
+	assert.False(t, f.rows[1].PublishedAt.Valid)
+	assert.False(t, f.rows[2].PublishedAt.Valid)
+
+	// The failed event is published again first, preserving the order.
+	publisher.failAt = 0
+	published, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	require.Len(t, publisher.events, 3)
+	assert.Equal(t, f.rows[1].EventID.String(), publisher.events[1].ID)
+}
+
+func TestRelayOnceLocked(t *testing.T) {
+	f := &fakeOutbox{locked: true}
+	insertEvents(t, f, 1)
+	publisher := &fakePublisher{}
+
+	published, err := outbox.NewRelay(f, publisher).RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Empty(t, publisher.events)
+}
+
+func TestCloudEventJSON(t *testing.T) {
+	id := uuid.New()
+	ev := outbox.NewCloudEvent(outbox.DefaultSource, repository.Outbox{
+		EventID:       id,
+		AggregateType: "book",
+		AggregateID:   "7",
+		EventType:     "BookCreated",
+		Payload:       json.RawMessage(`{"id":7}`),
+		CreatedAt:     time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	})
+
+	b, err := json.Marshal(ev)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "`+id.String()+`",
+		"source": "template-api",
+		"type": "BookCreated",
+		"subject": "book/7",
+		"time": "2026-10-19T12:00:00Z",
+		"datacontenttype": "application/json",
+		"data": {"id": 7}
+	}`, string(b))
+}
+
+func TestRecord(t *testing.T) {
+	created := []outbox.Event{{AggregateType: "book", AggregateID: "7", Type: "BookCreated", Data: nil}}
+
+	t.Run("with outbox", func(t *testing.T) {
+		f := &fakeOutbox{}
+		err := outbox.Record(context.Background(), f, nil, func(_ repository.Querier) ([]outbox.Event, error) {
+			return created, nil
+		})
+		require.NoError(t, err)
+		require.Len(t, f.rows, 1)
+		assert.Equal(t, "BookCreated", f.rows[0].EventType)
+		assert.JSONEq(t, "null", string(f.rows[0].Payload))
+	})
+
+	t.Run("failed mutation", func(t *testing.T) {
+		f := &fakeOutbox{}
+		err := outbox.Record(context.Background(), f, nil, func(_ repository.Querier) ([]outbox.Event, error) {
+			return nil, errBroker
+		})
+		require.ErrorIs(t, err, errBroker)
+		assert.Empty(t, f.rows)
+	})
+
+	t.Run("without outbox", func(t *testing.T) {
+		f := &fakeOutbox{}
+		var used repository.Querier
+		err := outbox.Record(context.Background(), nil, f, func(q repository.Querier) ([]outbox.Event, error) {
+			used = q
+			return created, nil
+		})
+		require.NoError(t, err)
+		assert.Same(t, f, used)
+		assert.Empty(t, f.rows)
+	})
+}
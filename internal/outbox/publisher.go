@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultStream is the default Redis stream events are published to.
+const DefaultStream = "catalog-events"
+
+// subscribeBlock is the maximum time a stream read waits for new events.
+const subscribeBlock = 5 * time.Second
+
+// Publisher publishes events to a message broker.
+type Publisher interface {
+	Publish(ctx context.Context, ev CloudEvent) error
+}
+
+// RedisStreams publishes events to a Redis stream.
+type RedisStreams struct {
+	client *redis.Client
+	stream string
+}
+
+// NewRedisStreams creates a new Redis Streams publisher.
+func NewRedisStreams(client *redis.Client, stream string) *RedisStreams {
+	if stream == "" {
+		stream = DefaultStream
+	}
+	return &RedisStreams{client: client, stream: stream}
+}
+
+// Publish appends ev to the stream. The type and subject are duplicated
+// as entry fields so consumers can filter without decoding the envelope.
+func (p *RedisStreams) Publish(ctx context.Context, ev CloudEvent) error {
+	envelope, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("could not encode event %s: %w", ev.ID, err)
+	}
+	err = p.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: p.stream,
+		Values: map[string]any{
+			"type":    ev.Type,
+			"subject": ev.Subject,
+			"event":   envelope,
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("could not publish event %s to redis stream %s: %w", ev.ID, p.stream, err)
+	}
+	return nil
+}
+
+// Publishers publishes events to several publishers in order. An event is
+// published again to every publisher when one fails, publishers must
+// tolerate duplicates.
+type Publishers []Publisher
+
+// Publish publishes ev to each publisher, stopping at the first failure.
+func (p Publishers) Publish(ctx context.Context, ev CloudEvent) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe calls fn with the events appended to the stream from now on,
+// until ctx is cancelled or fn fails. Every instance of a deployment can
+// subscribe to receive all events, whichever instance relays them.
+func (p *RedisStreams) Subscribe(ctx context.Context, fn func(CloudEvent) error) error {
+	lastID := "$"
+	for {
+		streams, err := p.client.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{p.stream, lastID},
+			Block:   subscribeBlock,
+		}).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("could not read redis stream %s: %w", p.stream, err)
+		}
+		for _, stream := range streams {
+			for _, msg := range stream.Messages {
+				lastID = msg.ID
+				var ev CloudEvent
+				envelope, _ := msg.Values["event"].(string)
+				if err := json.Unmarshal([]byte(envelope), &ev); err != nil {
+					slog.Warn("skipping malformed event", "stream", p.stream, "id", msg.ID, "error", err)
+					continue
+				}
+				if err := fn(ev); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
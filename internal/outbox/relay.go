@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+const (
+	// DefaultPollInterval is the default interval between two relay runs.
+	DefaultPollInterval = time.Second
+	// DefaultBatchSize is the default maximum number of events published per run.
+	DefaultBatchSize = 100
+	// relayLockID is the advisory lock electing the single relay of a cluster.
+	relayLockID = 749166234
+)
+
+// Relay publishes pending outbox events.
+type Relay struct {
+	tx           repository.Transactor
+	publisher    Publisher
+	source       string
+	pollInterval time.Duration
+	batchSize    int
+}
+
+// RelayOption configures the relay.
+type RelayOption func(*Relay)
+
+// WithPollInterval sets the interval between two runs.
+// Values lower or equal to zero are ignored.
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		if interval > 0 {
+			r.pollInterval = interval
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of events published per run.
+// Values lower or equal to zero are ignored.
+func WithBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		if size > 0 {
+			r.batchSize = size
+		}
+	}
+}
+
+// WithSource sets the source of the published CloudEvents.
+// Empty values are ignored.
+func WithSource(source string) RelayOption {
+	return func(r *Relay) {
+		if source != "" {
+			r.source = source
+		}
+	}
+}
+
+// NewRelay creates a new relay publishing the events of the outbox to publisher.
+func NewRelay(tx repository.Transactor, publisher Publisher, opts ...RelayOption) *Relay {
+	r := &Relay{
+		tx:           tx,
+		publisher:    publisher,
+		source:       DefaultSource,
+		pollInterval: DefaultPollInterval,
+		batchSize:    DefaultBatchSize,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run publishes pending events until ctx is cancelled. Runs are chained
+// without waiting while full batches are published.
+func (r *Relay) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		published, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("outbox relay failed", "published", published, "error", err)
+		}
+		if err == nil && published == r.batchSize {
+			timer.Reset(0)
+			continue
+		}
+		timer.Reset(r.pollInterval)
+	}
+}
+
+// RelayOnce publishes a batch of pending events and returns the number of
+// published events. It does nothing when another relay is running.
+//
+// Events are published in order and publishing stops at the first failure,
+// so a failed event is never overtaken by a later event of its aggregate.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	var published []int64
+	var publishErr error
+	err := r.tx.InTx(ctx, func(q repository.Querier) error {
+		acquired, err := q.TryOutboxRelayLock(ctx, relayLockID)
+		if err != nil {
+			return fmt.Errorf("could not lock outbox relay: %w", err)
+		}
+		if !acquired {
+			return nil
+		}
+		rows, err := q.ClaimOutboxEvents(ctx, int32(r.batchSize)) //nolint:gosec // bounded by configuration
+		if err != nil {
+			return fmt.Errorf("could not claim outbox events: %w", err)
+		}
+		for _, row := range rows {
+			if publishErr = r.publisher.Publish(ctx, newCloudEvent(r.source, row)); publishErr != nil {
+				break
+			}
+			published = append(published, row.ID)
+		}
+		if len(published) == 0 {
+			return nil
+		}
+		if err := q.MarkOutboxEventsPublished(ctx, published); err != nil {
+			return fmt.Errorf("could not mark outbox events published: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Published events were not marked, they will be published again.
+		return 0, err
+	}
+	return len(published), publishErr
+}
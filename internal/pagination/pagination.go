@@ -0,0 +1,153 @@
+// Package pagination provides keyset pagination of list endpoints.
+//
+// Pages are requested with the limit and cursor query parameters. The
+// cursor is opaque to clients: it encodes the sort key and identifier of
+// the last item of the previous page, so that pages stay consistent when
+// items are inserted or deleted between requests. The URL of the next page
+// is returned in a Link header (RFC 8288), omitted on the last page.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// Query parameters of paginated requests.
+const (
+	ParamLimit  = "limit"
+	ParamCursor = "cursor"
+)
+
+// Page size constraints.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Cursor is the position of the last item of a page in the list order:
+// its sort key and identifier, the identifier breaking ties between equal
+// keys.
+type Cursor struct {
+	Key string `json:"k"`
+	ID  int64  `json:"id"`
+}
+
+// Encode returns the opaque representation of c sent to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c) //nolint:errchkjson // a string and an integer always marshal
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(data, &c) != nil {
+		return Cursor{}, apperror.NewBadRequestError("Invalid cursor")
+	}
+	return c, nil
+}
+
+// Page selects up to Limit items following After. The zero After selects
+// the first page.
+type Page struct {
+	Limit int
+	After Cursor
+}
+
+// FromRequest parses the pagination parameters of r. ok is false when none
+// is set, the whole list being requested.
+func FromRequest(r *http.Request) (Page, bool, error) {
+	query := r.URL.Query()
+	if !query.Has(ParamLimit) && !query.Has(ParamCursor) {
+		return Page{}, false, nil
+	}
+
+	page := Page{Limit: DefaultLimit}
+	if limitStr := query.Get(ParamLimit); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > MaxLimit {
+			return Page{}, false, apperror.NewBadRequestError("Invalid limit")
+		}
+		page.Limit = limit
+	}
+	if cursor := query.Get(ParamCursor); cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return Page{}, false, err
+		}
+		page.After = after
+	}
+	return page, true, nil
+}
+
+// Trim returns the items of page and the cursor of the next page, nil on
+// the last page. items are expected to be read with a limit of
+// page.Limit+1, the extra item telling that another page follows.
+func Trim[T any](items []T, page Page, cursor func(T) Cursor) ([]T, *Cursor) {
+	if len(items) <= page.Limit {
+		return items, nil
+	}
+	items = items[:page.Limit]
+	next := cursor(items[len(items)-1])
+	return items, &next
+}
+
+// SetNextLink sets the Link header of w to the URL of the page following
+// page, when next isn't nil. The URL is relative to the request.
+func SetNextLink(w http.ResponseWriter, r *http.Request, page Page, next *Cursor) {
+	if next == nil {
+		return
+	}
+	query := r.URL.Query()
+	query.Set(ParamLimit, strconv.Itoa(page.Limit))
+	query.Set(ParamCursor, next.Encode())
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", "<"+link.String()+`>; rel="next"`)
+}
+
+// NextLink returns the URL of the next page from the Link header of a
+// response, resolved against the URL of the request. ok is false on the
+// last page.
+func NextLink(header http.Header, base *url.URL) (*url.URL, bool) {
+	for _, value := range header.Values("Link") {
+		for link := range strings.SplitSeq(value, ",") {
+			target, params, found := strings.Cut(strings.TrimSpace(link), ";")
+			if !found || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			if !isNextRel(params) {
+				continue
+			}
+			ref, err := url.Parse(target[1 : len(target)-1])
+			if err != nil {
+				return nil, false
+			}
+			return base.ResolveReference(ref), true
+		}
+	}
+	return nil, false
+}
+
+func isNextRel(params string) bool {
+	for param := range strings.SplitSeq(params, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if !strings.EqualFold(name, "rel") {
+			continue
+		}
+		// rel may hold several space separated relation types.
+		if slices.ContainsFunc(strings.Fields(strings.Trim(value, `"`)), func(rel string) bool {
+			return strings.EqualFold(rel, "next")
+		}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package pagination_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := pagination.Cursor{Key: "Anne Brontë, \"Agnes\"", ID: 42}
+	decoded, err := pagination.DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+	assert.Equal(t, url.QueryEscape(cursor.Encode()), cursor.Encode(), "cursors are URL safe")
+
+	for _, invalid := range []string{"%%%", "bm90IGpzb24"} {
+		_, err := pagination.DecodeCursor(invalid)
+		require.Error(t, err)
+		var appErr *apperror.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperror.ErrCodeBadRequest, appErr.Code)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	cursor := pagination.Cursor{Key: "Dune", ID: 3}
+	for name, tc := range map[string]struct {
+		query     string
+		page      pagination.Page
+		paginated bool
+		invalid   bool
+	}{
+		"whole list":    {query: ""},
+		"default limit": {query: "cursor=", page: pagination.Page{Limit: pagination.DefaultLimit}, paginated: true},
+		"limit":         {query: "limit=10", page: pagination.Page{Limit: 10}, paginated: true},
+		"cursor": {
+			query:     "limit=10&cursor=" + cursor.Encode(),
+			page:      pagination.Page{Limit: 10, After: cursor},
+			paginated: true,
+		},
+		"zero limit":     {query: "limit=0", invalid: true},
+		"limit too high": {query: "limit=1001", invalid: true},
+		"invalid cursor": {query: "cursor=abc", invalid: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			page, paginated, err := pagination.FromRequest(httptest.NewRequest(http.MethodGet, "/books?"+tc.query, nil))
+			if tc.invalid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.paginated, paginated)
+			assert.Equal(t, tc.page, page)
+		})
+	}
+}
+
+func TestTrim(t *testing.T) {
+	key := func(s string) pagination.Cursor { return pagination.Cursor{Key: s, ID: int64(len(s))} }
+	page := pagination.Page{Limit: 2}
+
+	items, next := pagination.Trim([]string{"a", "bb", "ccc"}, page, key)
+	assert.Equal(t, []string{"a", "bb"}, items)
+	assert.Equal(t, &pagination.Cursor{Key: "bb", ID: 2}, next)
+
+	items, next = pagination.Trim([]string{"a", "bb"}, page, key)
+	assert.Equal(t, []string{"a", "bb"}, items)
+	assert.Nil(t, next)
+}
+
+func TestNextLink(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/authors?limit=2&sort=name", nil)
+	page := pagination.Page{Limit: 2}
+	cursor := pagination.Cursor{Key: "Homer", ID: 7}
+
+	rec := httptest.NewRecorder()
+	pagination.SetNextLink(rec, r, page, &cursor)
+	base, _ := url.Parse("https://api.example.com/v1/authors?limit=2&sort=name")
+	next, ok := pagination.NextLink(rec.Header(), base)
+	require.True(t, ok)
+	assert.Equal(t, "api.example.com", next.Host)
+	assert.Equal(t, "/authors", next.Path)
+	assert.Equal(t, "name", next.Query().Get("sort"), "other parameters are kept")
+	nextPage, paginated, err := pagination.FromRequest(httptest.NewRequest(http.MethodGet, next.String(), nil))
+	require.NoError(t, err)
+	assert.True(t, paginated)
+	assert.Equal(t, pagination.Page{Limit: 2, After: cursor}, nextPage)
+
+	rec = httptest.NewRecorder()
+	pagination.SetNextLink(rec, r, page, nil)
+	_, ok = pagination.NextLink(rec.Header(), base)
+	assert.False(t, ok, "no link on the last page")
+
+	header := http.Header{"Link": {`<https://example.com/a?page=1>; rel="prev", <https://example.com/a?page=3>; rel="last next"`}}
+	next, ok = pagination.NextLink(header, base)
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/a?page=3", next.String())
+}
@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/database"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// BenchmarkDrivers compares the supported drivers on the same queries:
+// go test -run '^$' -bench BenchmarkDrivers ./internal/repository
+func BenchmarkDrivers(b *testing.B) {
+	for _, driver := range []database.Driver{database.DriverPQ, database.DriverPgx, database.DriverPgxPool} {
+		b.Run(string(driver), func(b *testing.B) {
+			pg, err := database.NewPostgres(testdb.GetDSN(), database.WithDriver(driver))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer pg.Close()
+			if err := pg.InitDB(); err != nil {
+				b.Fatal(err)
+			}
+			q := repository.New(pg.DB)
+			ctx := context.Background()
+			author, err := q.CreateAuthor(ctx, repository.CreateAuthorParams{Name: "John Doe", Bio: "A simple"})
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run("CreateAuthor", func(b *testing.B) {
+				for b.Loop() {
+					if _, err := q.CreateAuthor(ctx, repository.CreateAuthorParams{Name: "John Doe", Bio: "A simple"}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("GetAuthor", func(b *testing.B) {
+				for b.Loop() {
+					if _, err := q.GetAuthor(ctx, author.ID); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("ListAuthors", func(b *testing.B) {
+				for b.Loop() {
+					if _, err := q.ListAuthors(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run("GetAuthorParallel", func(b *testing.B) {
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if _, err := q.GetAuthor(ctx, author.ID); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		})
+	}
+}
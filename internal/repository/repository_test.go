@@ -2,8 +2,11 @@ package repository_test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/sgaunet/template-api/internal/database"
 	"github.com/sgaunet/template-api/internal/dbtest"
@@ -219,3 +222,134 @@ func TestUpdatePartialAuthor(t *testing.T) {
 	assert.Equal(t, "John Doe Jr", author.Name)
 	assert.Equal(t, "A simple test", author.Bio)
 }
+
+func TestCreateAuthors(t *testing.T) {
+	if err := database.WaitForDB(context.Background(), testdb.GetDSN()); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := database.NewPostgres(testdb.GetDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	err = pg.InitDB()
+	assert.Nil(t, err)
+
+	// Create authors
+	q := repository.New(pg.DB)
+	authors, err := q.CreateAuthors(context.Background(), repository.CreateAuthorsParams{
+		Names: []string{"John Doe", "Jane Doe"},
+		Bios:  []string{"A simple", "Another simple"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(authors))
+	assert.Equal(t, "John Doe", authors[0].Name)
+	assert.Equal(t, "Jane Doe", authors[1].Name)
+}
+
+func TestDeleteAuthors(t *testing.T) {
+	if err := database.WaitForDB(context.Background(), testdb.GetDSN()); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := database.NewPostgres(testdb.GetDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	err = pg.InitDB()
+	assert.Nil(t, err)
+
+	// Create authors
+	q := repository.New(pg.DB)
+	authors, err := q.CreateAuthors(context.Background(), repository.CreateAuthorsParams{
+		Names: []string{"John Doe", "Jane Doe"},
+		Bios:  []string{"A simple", "Another simple"},
+	})
+	assert.Nil(t, err)
+
+	// Delete authors, unknown IDs are ignored
+	deleted, err := q.DeleteAuthors(context.Background(), []int64{authors[0].ID, authors[1].ID, -1})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int64{authors[0].ID, authors[1].ID}, deleted)
+}
+
+func TestStreamAuthors(t *testing.T) {
+	if err := database.WaitForDB(context.Background(), testdb.GetDSN()); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := database.NewPostgres(testdb.GetDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	err = pg.InitDB()
+	assert.Nil(t, err)
+
+	// Create author
+	q := repository.New(pg.DB)
+	author, err := q.CreateAuthor(context.Background(), repository.CreateAuthorParams{
+		Name: "John Doe",
+		Bio:  "A simple",
+	})
+	assert.Nil(t, err)
+
+	// Stream authors
+	found := false
+	err = q.StreamAuthors(context.Background(), func(a repository.Author) error {
+		found = found || a.ID == author.ID
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, found)
+}
+
+func TestListAuditEntries(t *testing.T) {
+	if err := database.WaitForDB(context.Background(), testdb.GetDSN()); err != nil {
+		t.Fatal(err)
+	}
+	pg, err := database.NewPostgres(testdb.GetDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	err = pg.InitDB()
+	assert.Nil(t, err)
+
+	// Record changes of two authors
+	q := repository.New(pg.DB)
+	now := time.Now().UTC()
+	for i, entityID := range []string{"1", "2", "1"} {
+		err = q.InsertAuditEntry(context.Background(), repository.InsertAuditEntryParams{
+			EntityType: "author",
+			EntityID:   entityID,
+			Action:     "update",
+			Actor:      "alice",
+			Before:     json.RawMessage(`{"bio":"before"}`),
+			After:      json.RawMessage(`{"bio":"after"}`),
+			CreatedAt:  now.Add(time.Duration(i) * time.Minute),
+		})
+		assert.Nil(t, err)
+	}
+
+	// Filter by entity, most recent first
+	entries, err := q.ListAuditEntries(context.Background(), repository.ListAuditEntriesParams{
+		EntityType: "author",
+		EntityID:   "1",
+		Actor:      "alice",
+		MaxRows:    10,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+	assert.Greater(t, entries[0].ID, entries[1].ID)
+	assert.JSONEq(t, `{"bio":"after"}`, string(entries[0].After))
+
+	// Filter by time range and page
+	entries, err = q.ListAuditEntries(context.Background(), repository.ListAuditEntriesParams{
+		CreatedFrom: sql.NullTime{Time: now.Add(time.Minute), Valid: true},
+		CreatedTo:   sql.NullTime{Time: now.Add(2 * time.Minute), Valid: true},
+		MaxRows:     10,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "2", entries[0].EntityID)
+}
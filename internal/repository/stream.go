@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// Streamer iterates over rows without loading them all in memory.
+// It complements the sqlc-generated Querier whose :many queries return slices.
+type Streamer interface {
+	StreamAuthors(ctx context.Context, fn func(Author) error) error
+	StreamBooks(ctx context.Context, fn func(Book) error) error
+}
+
+var _ Streamer = (*Queries)(nil)
+
+// Queries are named like sqlc ones so that database.Router sends them to replicas.
+const streamAuthors = `-- name: StreamAuthors :many
+SELECT id, name, bio
+FROM authors
+ORDER BY id
+`
+
+// StreamAuthors calls fn for each author, ordered by ID.
+// Iteration stops at the first error returned by fn.
+func (q *Queries) StreamAuthors(ctx context.Context, fn func(Author) error) error {
+	rows, err := q.db.QueryContext(ctx, streamAuthors)
+	if err != nil {
+		return fmt.Errorf("could not query authors: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var i Author
+		if err := rows.Scan(&i.ID, &i.Name, &i.Bio); err != nil {
+			return fmt.Errorf("could not scan author: %w", err)
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate authors: %w", err)
+	}
+	return nil
+}
+
+const streamBooks = `-- name: StreamBooks :many
+SELECT id, title, author_id
+FROM books
+ORDER BY id
+`
+
+// StreamBooks calls fn for each book, ordered by ID.
+// Iteration stops at the first error returned by fn.
+func (q *Queries) StreamBooks(ctx context.Context, fn func(Book) error) error {
+	rows, err := q.db.QueryContext(ctx, streamBooks)
+	if err != nil {
+		return fmt.Errorf("could not query books: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var i Book
+		if err := rows.Scan(&i.ID, &i.Title, &i.AuthorID); err != nil {
+			return fmt.Errorf("could not scan book: %w", err)
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate books: %w", err)
+	}
+	return nil
+}
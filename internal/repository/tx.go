@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Transactor runs functions within a database transaction.
+type Transactor interface {
+	// InTx runs fn within a transaction: queries made through q are committed
+	// if fn returns nil and rolled back otherwise.
+	InTx(ctx context.Context, fn func(q Querier) error) error
+}
+
+// DBTransactor begins transactions on a database connection.
+type DBTransactor struct {
+	db *sql.DB
+}
+
+var _ Transactor = (*DBTransactor)(nil)
+
+// NewTransactor creates a new transactor. Transactions always run on db,
+// the primary database when replicas are used.
+func NewTransactor(db *sql.DB) *DBTransactor {
+	return &DBTransactor{db: db}
+}
+
+// InTx runs fn within a transaction.
+func (t *DBTransactor) InTx(ctx context.Context, fn func(q Querier) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+	if err := fn(New(tx)); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			return errors.Join(err, fmt.Errorf("could not rollback transaction: %w", errRollback))
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
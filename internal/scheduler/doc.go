@@ -0,0 +1,9 @@
+// Package scheduler runs periodic tasks on a single instance of a deployment.
+//
+// Tasks are scheduled with standard cron expressions. Instances elect a
+// leader, the only instance running tasks, with a Postgres advisory lock held
+// on a dedicated connection: when the leader stops or loses its connection,
+// the lock is released and another instance takes over. Each occurrence of a
+// task is recorded in the scheduler_runs table, which also keeps an
+// occurrence from running twice around a change of leader.
+package scheduler
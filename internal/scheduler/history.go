@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Run statuses recorded in history.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// History records task runs.
+type History interface {
+	// Start records the run of an occurrence of task, it returns false when
+	// the occurrence already ran.
+	Start(ctx context.Context, task string, scheduledAt time.Time) (int64, bool, error)
+	// Finish records the outcome of a run.
+	Finish(ctx context.Context, runID int64, runErr error) error
+}
+
+// queriesHistory is a history stored with sqlc-generated queries.
+type queriesHistory struct {
+	queries  repository.Querier
+	instance string
+}
+
+// NewHistory creates a new history, runs are recorded with the instance name.
+func NewHistory(queries repository.Querier, instance string) History {
+	return &queriesHistory{queries: queries, instance: instance}
+}
+
+func (h *queriesHistory) Start(ctx context.Context, task string, scheduledAt time.Time) (int64, bool, error) {
+	id, err := h.queries.StartSchedulerRun(ctx, repository.StartSchedulerRunParams{
+		Task:        task,
+		ScheduledAt: scheduledAt,
+		Instance:    h.instance,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("could not record run of %s: %w", task, err)
+	}
+	return id, true, nil
+}
+
+func (h *queriesHistory) Finish(ctx context.Context, runID int64, runErr error) error {
+	params := repository.FinishSchedulerRunParams{ID: runID, Status: StatusSucceeded}
+	if runErr != nil {
+		params.Status = StatusFailed
+		params.Error = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+	if err := h.queries.FinishSchedulerRun(ctx, params); err != nil {
+		return fmt.Errorf("could not record outcome of run %d: %w", runID, err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// leaderLockID is the advisory lock held by the leader.
+	leaderLockID = 749166235
+	// DefaultCampaignInterval is the default interval between two attempts
+	// of a follower to become leader.
+	DefaultCampaignInterval = 10 * time.Second
+	// leaderCheckInterval is the interval between two checks of the leader
+	// connection, leadership is lost with the connection.
+	leaderCheckInterval = 5 * time.Second
+)
+
+// Elector elects the instance running tasks.
+type Elector interface {
+	// Campaign blocks until this instance leads or ctx is done. The returned
+	// context is cancelled when leadership is lost, resign gives it up.
+	Campaign(ctx context.Context) (leaderCtx context.Context, resign func(), err error)
+}
+
+// PostgresElector elects the leader with a session advisory lock.
+type PostgresElector struct {
+	db       *sql.DB
+	interval time.Duration
+}
+
+var _ Elector = (*PostgresElector)(nil)
+
+// NewPostgresElector creates a new elector, followers campaign every
+// interval (0 means default).
+func NewPostgresElector(db *sql.DB, interval time.Duration) *PostgresElector {
+	if interval <= 0 {
+		interval = DefaultCampaignInterval
+	}
+	return &PostgresElector{db: db, interval: interval}
+}
+
+// Campaign blocks until the advisory lock is acquired or ctx is done.
+func (e *PostgresElector) Campaign(ctx context.Context) (context.Context, func(), error) {
+	for {
+		conn, acquired, err := e.tryLock(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if acquired {
+			return e.lead(ctx, conn)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, fmt.Errorf("scheduler campaign stopped: %w", ctx.Err())
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// tryLock tries to acquire the lock on a dedicated connection, the session
+// lock is held as long as the connection.
+func (e *PostgresElector) tryLock(ctx context.Context) (*sql.Conn, bool, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not get a connection for scheduler election: %w", err)
+	}
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockID).Scan(&acquired); err != nil {
+		_ = conn.Close()
+		return nil, false, fmt.Errorf("could not try scheduler lock: %w", err)
+	}
+	if !acquired {
+		_ = conn.Close()
+		return nil, false, nil
+	}
+	return conn, true, nil
+}
+
+// lead watches the leader connection until resign is called.
+func (e *PostgresElector) lead(ctx context.Context, conn *sql.Conn) (context.Context, func(), error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(leaderCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaderCtx.Done():
+				return
+			case <-ticker.C:
+				if err := conn.PingContext(leaderCtx); err != nil && leaderCtx.Err() == nil {
+					slog.Error("scheduler leadership lost", "error", err)
+					cancel()
+					return
+				}
+			}
+		}
+	})
+
+	var once sync.Once
+	resign := func() {
+		once.Do(func() {
+			cancel()
+			wg.Wait()
+			unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), leaderCheckInterval)
+			defer cancelUnlock()
+			if _, err := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock($1)", leaderLockID); err != nil {
+				slog.Warn("could not release scheduler lock", "error", err)
+			}
+			_ = conn.Close()
+		})
+	}
+	return leaderCtx, resign, nil
+}
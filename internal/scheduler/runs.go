@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Query parameters of GET /admin/scheduler/runs.
+const (
+	ParamTask = "task"
+	ParamFrom = "from"
+	ParamTo   = "to"
+)
+
+// RunResponse is a recorded run of a task occurrence.
+type RunResponse struct {
+	ID          int64      `json:"id"`
+	Task        string     `json:"task"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	Instance    string     `json:"instance"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// RunFilter selects runs, zero fields match any run.
+type RunFilter struct {
+	Task string
+	// From and To bound the scheduled time of the runs, To being excluded.
+	From time.Time
+	To   time.Time
+}
+
+// RunFilterFromQuery parses the filter of GET /admin/scheduler/runs, times
+// are RFC 3339.
+func RunFilterFromQuery(query url.Values) (RunFilter, error) {
+	f := RunFilter{Task: query.Get(ParamTask)}
+	for param, t := range map[string]*time.Time{ParamFrom: &f.From, ParamTo: &f.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return RunFilter{}, apperror.NewValidationError(
+				"Invalid time, expected RFC 3339",
+				map[string]string{"field": param, "value": value},
+			)
+		}
+		*t = parsed
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return RunFilter{}, apperror.NewValidationError(
+			"Time range is empty",
+			map[string]string{"field": ParamTo},
+		)
+	}
+	return f, nil
+}
+
+// Handler serves the run history recorded by NewHistory.
+type Handler struct {
+	queries repository.Querier
+}
+
+// NewHandler creates a new run history handler.
+func NewHandler(queries repository.Querier) *Handler {
+	return &Handler{queries: queries}
+}
+
+// List handles GET /admin/scheduler/runs: the runs matching the filter,
+// most recent first. The history is always paginated, with the default
+// limit unless a page is requested.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := RunFilterFromQuery(r.URL.Query())
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+	page, paginated, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+	if !paginated {
+		page = pagination.Page{Limit: pagination.DefaultLimit}
+	}
+
+	// One more run is read to know whether another page follows.
+	dbRuns, err := h.queries.ListSchedulerRuns(r.Context(), repository.ListSchedulerRunsParams{
+		Task:          filter.Task,
+		ScheduledFrom: sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		ScheduledTo:   sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		BeforeID:      page.After.ID,
+		MaxRows:       int32(page.Limit + 1), //nolint:gosec // bounded by pagination.MaxLimit
+	})
+	if err != nil {
+		apperror.WriteError(w, apperror.NewInternalError(err))
+		return
+	}
+	dbRuns, next := pagination.Trim(dbRuns, page, func(run repository.SchedulerRun) pagination.Cursor {
+		return pagination.Cursor{ID: run.ID}
+	})
+
+	responses := make([]*RunResponse, len(dbRuns))
+	for i, run := range dbRuns {
+		responses[i] = &RunResponse{
+			ID:          run.ID,
+			Task:        run.Task,
+			ScheduledAt: run.ScheduledAt,
+			Instance:    run.Instance,
+			Status:      run.Status,
+			Error:       run.Error.String,
+			StartedAt:   run.StartedAt,
+		}
+		if run.FinishedAt.Valid {
+			responses[i].FinishedAt = &run.FinishedAt.Time
+		}
+	}
+
+	pagination.SetNextLink(w, r, page, next)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
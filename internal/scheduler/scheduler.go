@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Task is a periodic task.
+type Task func(ctx context.Context) error
+
+type registeredTask struct {
+	name     string
+	schedule cron.Schedule
+	run      Task
+	// running skips occurrences while the previous run is not finished.
+	running atomic.Bool
+}
+
+// Scheduler runs tasks on the elected instance.
+type Scheduler struct {
+	elector Elector
+	history History
+	tasks   []*registeredTask
+}
+
+// New creates a new scheduler.
+func New(elector Elector, history History) *Scheduler {
+	return &Scheduler{elector: elector, history: history}
+}
+
+// Register schedules a task with a standard cron expression of five fields
+// (minute, hour, day of month, month, day of week) or a descriptor such as
+// @hourly. Tasks must be registered before Run.
+func (s *Scheduler) Register(name, spec string, run Task) error {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q of task %s: %w", spec, name, err)
+	}
+	s.tasks = append(s.tasks, &registeredTask{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Run campaigns for leadership and runs tasks while leading, until ctx is
+// cancelled. Running tasks are cancelled and awaited when leadership ends.
+func (s *Scheduler) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		leaderCtx, resign, err := s.elector.Campaign(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("scheduler campaign failed", "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(DefaultCampaignInterval):
+				}
+			}
+			continue
+		}
+		slog.Info("scheduler leadership acquired", "tasks", len(s.tasks))
+		s.lead(leaderCtx)
+		resign()
+	}
+}
+
+// lead runs tasks at their scheduled times until ctx is cancelled.
+func (s *Scheduler) lead(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	now := time.Now()
+	next := make([]time.Time, len(s.tasks))
+	for i, t := range s.tasks {
+		next[i] = t.schedule.Next(now)
+	}
+	for len(s.tasks) > 0 {
+		earliest := next[0]
+		for _, at := range next[1:] {
+			if at.Before(earliest) {
+				earliest = at
+			}
+		}
+		timer := time.NewTimer(time.Until(earliest))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for i, t := range s.tasks {
+			if next[i].After(earliest) {
+				continue
+			}
+			scheduledAt := next[i]
+			next[i] = t.schedule.Next(scheduledAt)
+			if !t.running.CompareAndSwap(false, true) {
+				slog.Warn("scheduled task still running, occurrence skipped", "task", t.name, "scheduled_at", scheduledAt)
+				continue
+			}
+			wg.Go(func() {
+				defer t.running.Store(false)
+				s.runTask(ctx, t, scheduledAt)
+			})
+		}
+	}
+	<-ctx.Done()
+}
+
+// runTask runs an occurrence of a task unless another instance already did.
+func (s *Scheduler) runTask(ctx context.Context, t *registeredTask, scheduledAt time.Time) {
+	runID, ok, err := s.history.Start(ctx, t.name, scheduledAt)
+	if err != nil {
+		slog.Error("could not start scheduled task", "task", t.name, "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	start := time.Now()
+	runErr := t.run(ctx)
+	if runErr != nil {
+		slog.Error("scheduled task failed", "task", t.name, "duration", time.Since(start), "error", runErr)
+	} else {
+		slog.Info("scheduled task succeeded", "task", t.name, "duration", time.Since(start))
+	}
+	if err := s.history.Finish(context.WithoutCancel(ctx), runID, runErr); err != nil {
+		slog.Error("could not finish scheduled task", "task", t.name, "error", err)
+	}
+}
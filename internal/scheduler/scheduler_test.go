@@ -0,0 +1,196 @@
+package scheduler_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/internal/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTask = errors.New("task failed")
+
+// alwaysLeader elects every instance, as during a change of leader.
+type alwaysLeader struct{}
+
+func (alwaysLeader) Campaign(ctx context.Context) (context.Context, func(), error) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	return leaderCtx, cancel, nil
+}
+
+type run struct {
+	task        string
+	scheduledAt time.Time
+	err         error
+	finished    bool
+}
+
+// fakeHistory is a history shared by instances.
+type fakeHistory struct {
+	mu   sync.Mutex
+	runs []*run
+}
+
+func (h *fakeHistory) Start(_ context.Context, task string, scheduledAt time.Time) (int64, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.runs {
+		if r.task == task && r.scheduledAt.Equal(scheduledAt) {
+			return 0, false, nil
+		}
+	}
+	h.runs = append(h.runs, &run{task: task, scheduledAt: scheduledAt})
+	return int64(len(h.runs)), true, nil
+}
+
+func (h *fakeHistory) Finish(_ context.Context, runID int64, runErr error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.runs[runID-1].err = runErr
+	h.runs[runID-1].finished = true
+	return nil
+}
+
+func (h *fakeHistory) finished() []run {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var runs []run
+	for _, r := range h.runs {
+		if r.finished {
+			runs = append(runs, *r)
+		}
+	}
+	return runs
+}
+
+func TestRegister_InvalidSchedule(t *testing.T) {
+	s := scheduler.New(alwaysLeader{}, &fakeHistory{})
+	assert.Error(t, s.Register("purge", "every minute", func(context.Context) error { return nil }))
+	assert.NoError(t, s.Register("purge", "*/5 * * * *", func(context.Context) error { return nil }))
+}
+
+func TestRun_OccurrencesRunOnce(t *testing.T) {
+	history := &fakeHistory{}
+	var runs atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two instances leading at the same time share the occurrences.
+	var wg sync.WaitGroup
+	for range 2 {
+		s := scheduler.New(alwaysLeader{}, history)
+		require.NoError(t, s.Register("count", "@every 1s", func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}))
+		require.NoError(t, s.Register("fail", "@every 1s", func(context.Context) error {
+			return errTask
+		}))
+		wg.Go(func() { s.Run(ctx) })
+	}
+
+	require.Eventually(t, func() bool {
+		tasks := map[string]bool{}
+		for _, r := range history.finished() {
+			tasks[r.task] = true
+		}
+		return tasks["count"] && tasks["fail"]
+	}, 3*time.Second, 10*time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	finished := history.finished()
+	var failed int
+	for _, r := range finished {
+		if r.task == "fail" {
+			assert.ErrorIs(t, r.err, errTask)
+			failed++
+		}
+	}
+	assert.Equal(t, int32(len(finished)-failed), runs.Load(), "each occurrence runs on a single instance")
+	assert.Positive(t, failed)
+}
+
+// fakeRuns serves recorded runs, the embedded Querier is nil and panics on
+// other queries.
+type fakeRuns struct {
+	repository.Querier
+	runs []repository.SchedulerRun
+}
+
+func (f *fakeRuns) ListSchedulerRuns(
+	_ context.Context, arg repository.ListSchedulerRunsParams,
+) ([]repository.SchedulerRun, error) {
+	var runs []repository.SchedulerRun
+	for _, run := range slices.Backward(f.runs) {
+		switch {
+		case arg.Task != "" && run.Task != arg.Task,
+			arg.ScheduledFrom.Valid && run.ScheduledAt.Before(arg.ScheduledFrom.Time),
+			arg.ScheduledTo.Valid && !run.ScheduledAt.Before(arg.ScheduledTo.Time),
+			arg.BeforeID != 0 && run.ID >= arg.BeforeID:
+			continue
+		}
+		if len(runs) < int(arg.MaxRows) {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+func TestHandler_List(t *testing.T) {
+	start := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	q := &fakeRuns{}
+	for i := range 4 {
+		run := repository.SchedulerRun{
+			ID:          int64(i + 1),
+			Task:        []string{"purge-outbox", "purge-jobs"}[i%2],
+			ScheduledAt: start.Add(time.Duration(i) * time.Hour),
+			Status:      scheduler.StatusSucceeded,
+			FinishedAt:  sql.NullTime{Time: start.Add(time.Duration(i) * time.Hour), Valid: true},
+		}
+		if i == 3 {
+			run.Status, run.FinishedAt = scheduler.StatusRunning, sql.NullTime{}
+		}
+		q.runs = append(q.runs, run)
+	}
+	handler := scheduler.NewHandler(q)
+
+	list := func(target string) ([]scheduler.RunResponse, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.List(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var runs []scheduler.RunResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&runs))
+		return runs, rec.Header().Get("Link")
+	}
+
+	runs, link := list("/admin/scheduler/runs?task=purge-jobs")
+	require.Len(t, runs, 2)
+	assert.Equal(t, []int64{4, 2}, []int64{runs[0].ID, runs[1].ID})
+	assert.Nil(t, runs[0].FinishedAt, "running")
+	assert.NotNil(t, runs[1].FinishedAt)
+	assert.Empty(t, link)
+
+	runs, _ = list("/admin/scheduler/runs?from=2026-10-19T01:00:00Z&to=2026-10-19T03:00:00Z")
+	assert.Equal(t, []int64{3, 2}, []int64{runs[0].ID, runs[1].ID})
+
+	runs, link = list("/admin/scheduler/runs?limit=3")
+	assert.Len(t, runs, 3)
+	assert.NotEmpty(t, link)
+
+	rec := httptest.NewRecorder()
+	handler.List(rec, httptest.NewRequest(http.MethodGet, "/admin/scheduler/runs?from=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,246 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: catalog/v1/books.proto
+
+package catalogv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Book is a book of the catalog.
+type Book struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	AuthorId      int64                  `protobuf:"varint,3,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Book) Reset() {
+	*x = Book{}
+	mi := &file_catalog_v1_books_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_v1_books_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_catalog_v1_books_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Book) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Book) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Book) GetAuthorId() int64 {
+	if x != nil {
+		return x.AuthorId
+	}
+	return 0
+}
+
+// CreateBookRequest follows the constraints of the books import: the title
+// is 1 to 32 characters long.
+type CreateBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	AuthorId      int64                  `protobuf:"varint,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateBookRequest) Reset() {
+	*x = CreateBookRequest{}
+	mi := &file_catalog_v1_books_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBookRequest) ProtoMessage() {}
+
+func (x *CreateBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_v1_books_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBookRequest.ProtoReflect.Descriptor instead.
+func (*CreateBookRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_v1_books_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateBookRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateBookRequest) GetAuthorId() int64 {
+	if x != nil {
+		return x.AuthorId
+	}
+	return 0
+}
+
+type ListBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListBooksRequest) Reset() {
+	*x = ListBooksRequest{}
+	mi := &file_catalog_v1_books_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBooksRequest) ProtoMessage() {}
+
+func (x *ListBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_catalog_v1_books_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBooksRequest.ProtoReflect.Descriptor instead.
+func (*ListBooksRequest) Descriptor() ([]byte, []int) {
+	return file_catalog_v1_books_proto_rawDescGZIP(), []int{2}
+}
+
+var File_catalog_v1_books_proto protoreflect.FileDescriptor
+
+const file_catalog_v1_books_proto_rawDesc = "" +
+	"\n" +
+	"\x16catalog/v1/books.proto\x12\n" +
+	"catalog.v1\"I\n" +
+	"\x04Book\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1b\n" +
+	"\tauthor_id\x18\x03 \x01(\x03R\bauthorId\"F\n" +
+	"\x11CreateBookRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\x03R\bauthorId\"\x12\n" +
+	"\x10ListBooksRequest2\x8c\x01\n" +
+	"\fBooksService\x12=\n" +
+	"\n" +
+	"CreateBook\x12\x1d.catalog.v1.CreateBookRequest\x1a\x10.catalog.v1.Book\x12=\n" +
+	"\tListBooks\x12\x1c.catalog.v1.ListBooksRequest\x1a\x10.catalog.v1.Book0\x01B>Z<github.com/sgaunet/template-api/pkg/api/catalog/v1;catalogv1b\x06proto3"
+
+var (
+	file_catalog_v1_books_proto_rawDescOnce sync.Once
+	file_catalog_v1_books_proto_rawDescData []byte
+)
+
+func file_catalog_v1_books_proto_rawDescGZIP() []byte {
+	file_catalog_v1_books_proto_rawDescOnce.Do(func() {
+		file_catalog_v1_books_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_catalog_v1_books_proto_rawDesc), len(file_catalog_v1_books_proto_rawDesc)))
+	})
+	return file_catalog_v1_books_proto_rawDescData
+}
+
+var file_catalog_v1_books_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_catalog_v1_books_proto_goTypes = []any{
+	(*Book)(nil),              // 0: catalog.v1.Book
+	(*CreateBookRequest)(nil), // 1: catalog.v1.CreateBookRequest
+	(*ListBooksRequest)(nil),  // 2: catalog.v1.ListBooksRequest
+}
+var file_catalog_v1_books_proto_depIdxs = []int32{
+	1, // 0: catalog.v1.BooksService.CreateBook:input_type -> catalog.v1.CreateBookRequest
+	2, // 1: catalog.v1.BooksService.ListBooks:input_type -> catalog.v1.ListBooksRequest
+	0, // 2: catalog.v1.BooksService.CreateBook:output_type -> catalog.v1.Book
+	0, // 3: catalog.v1.BooksService.ListBooks:output_type -> catalog.v1.Book
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_catalog_v1_books_proto_init() }
+func file_catalog_v1_books_proto_init() {
+	if File_catalog_v1_books_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_catalog_v1_books_proto_rawDesc), len(file_catalog_v1_books_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_catalog_v1_books_proto_goTypes,
+		DependencyIndexes: file_catalog_v1_books_proto_depIdxs,
+		MessageInfos:      file_catalog_v1_books_proto_msgTypes,
+	}.Build()
+	File_catalog_v1_books_proto = out.File
+	file_catalog_v1_books_proto_goTypes = nil
+	file_catalog_v1_books_proto_depIdxs = nil
+}
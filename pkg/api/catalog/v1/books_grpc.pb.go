@@ -0,0 +1,173 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: catalog/v1/books.proto
+
+package catalogv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BooksService_CreateBook_FullMethodName = "/catalog.v1.BooksService/CreateBook"
+	BooksService_ListBooks_FullMethodName  = "/catalog.v1.BooksService/ListBooks"
+)
+
+// BooksServiceClient is the client API for BooksService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BooksService manages the books of the catalog. Errors carry the same
+// details as AuthorsService.
+type BooksServiceClient interface {
+	// CreateBook creates a book of an existing author.
+	CreateBook(ctx context.Context, in *CreateBookRequest, opts ...grpc.CallOption) (*Book, error)
+	// ListBooks streams the books of the catalog.
+	ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error)
+}
+
+type booksServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBooksServiceClient(cc grpc.ClientConnInterface) BooksServiceClient {
+	return &booksServiceClient{cc}
+}
+
+func (c *booksServiceClient) CreateBook(ctx context.Context, in *CreateBookRequest, opts ...grpc.CallOption) (*Book, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Book)
+	err := c.cc.Invoke(ctx, BooksService_CreateBook_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *booksServiceClient) ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BooksService_ServiceDesc.Streams[0], BooksService_ListBooks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListBooksRequest, Book]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BooksService_ListBooksClient = grpc.ServerStreamingClient[Book]
+
+// BooksServiceServer is the server API for BooksService service.
+// All implementations must embed UnimplementedBooksServiceServer
+// for forward compatibility.
+//
+// BooksService manages the books of the catalog. Errors carry the same
+// details as AuthorsService.
+type BooksServiceServer interface {
+	// CreateBook creates a book of an existing author.
+	CreateBook(context.Context, *CreateBookRequest) (*Book, error)
+	// ListBooks streams the books of the catalog.
+	ListBooks(*ListBooksRequest, grpc.ServerStreamingServer[Book]) error
+	mustEmbedUnimplementedBooksServiceServer()
+}
+
+// UnimplementedBooksServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBooksServiceServer struct{}
+
+func (UnimplementedBooksServiceServer) CreateBook(context.Context, *CreateBookRequest) (*Book, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBook not implemented")
+}
+func (UnimplementedBooksServiceServer) ListBooks(*ListBooksRequest, grpc.ServerStreamingServer[Book]) error {
+	return status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
+}
+func (UnimplementedBooksServiceServer) mustEmbedUnimplementedBooksServiceServer() {}
+func (UnimplementedBooksServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeBooksServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BooksServiceServer will
+// result in compilation errors.
+type UnsafeBooksServiceServer interface {
+	mustEmbedUnimplementedBooksServiceServer()
+}
+
+func RegisterBooksServiceServer(s grpc.ServiceRegistrar, srv BooksServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBooksServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BooksService_ServiceDesc, srv)
+}
+
+func _BooksService_CreateBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BooksServiceServer).CreateBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BooksService_CreateBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BooksServiceServer).CreateBook(ctx, req.(*CreateBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BooksService_ListBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BooksServiceServer).ListBooks(m, &grpc.GenericServerStream[ListBooksRequest, Book]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BooksService_ListBooksServer = grpc.ServerStreamingServer[Book]
+
+// BooksService_ServiceDesc is the grpc.ServiceDesc for BooksService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BooksService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "catalog.v1.BooksService",
+	HandlerType: (*BooksServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateBook",
+			Handler:    _BooksService_CreateBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListBooks",
+			Handler:       _BooksService_ListBooks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "catalog/v1/books.proto",
+}
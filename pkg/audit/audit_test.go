@@ -0,0 +1,195 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/pkg/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditLog is an in-memory audit log, the embedded Querier is nil and
+// panics on other queries.
+type fakeAuditLog struct {
+	repository.Querier
+	rows []repository.AuditLog
+}
+
+func (f *fakeAuditLog) InsertAuditEntry(_ context.Context, arg repository.InsertAuditEntryParams) error {
+	f.rows = append(f.rows, repository.AuditLog{
+		ID:         int64(len(f.rows) + 1),
+		EntityType: arg.EntityType,
+		EntityID:   arg.EntityID,
+		Action:     arg.Action,
+		Actor:      arg.Actor,
+		RequestID:  arg.RequestID,
+		Ip:         arg.Ip,
+		Before:     arg.Before,
+		After:      arg.After,
+		CreatedAt:  arg.CreatedAt,
+	})
+	return nil
+}
+
+func (f *fakeAuditLog) ListAuditEntries(
+	_ context.Context, arg repository.ListAuditEntriesParams,
+) ([]repository.AuditLog, error) {
+	var rows []repository.AuditLog
+	for _, row := range slices.Backward(f.rows) {
+		switch {
+		case arg.EntityType != "" && row.EntityType != arg.EntityType,
+			arg.EntityID != "" && row.EntityID != arg.EntityID,
+			arg.Actor != "" && row.Actor != arg.Actor,
+			arg.CreatedFrom.Valid && row.CreatedAt.Before(arg.CreatedFrom.Time),
+			arg.CreatedTo.Valid && !row.CreatedAt.Before(arg.CreatedTo.Time),
+			arg.BeforeID != 0 && row.ID >= arg.BeforeID:
+			continue
+		}
+		if len(rows) < int(arg.MaxRows) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type author struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Bio  string `json:"bio"`
+}
+
+func TestInsert(t *testing.T) {
+	q := &fakeAuditLog{}
+	ctx := audit.NewContext(context.Background(), audit.Origin{Actor: "alice", RequestID: "req-1", IP: "192.0.2.1"})
+
+	before := author{ID: 1, Name: "Mary Shelley", Bio: "Novelist"}
+	after := author{ID: 1, Name: "Mary Shelley", Bio: "English novelist"}
+	require.NoError(t, audit.Insert(ctx, q,
+		audit.Created(audit.EntityAuthor, "1", before),
+		audit.Updated(audit.EntityAuthor, "1", before, after),
+		audit.Deleted(audit.EntityAuthor, "1", after),
+	))
+
+	require.Len(t, q.rows, 3)
+	for _, row := range q.rows {
+		assert.Equal(t, "alice", row.Actor)
+		assert.Equal(t, "req-1", row.RequestID)
+		assert.Equal(t, "192.0.2.1", row.Ip)
+	}
+	assert.Equal(t, "create", q.rows[0].Action)
+	assert.JSONEq(t, `null`, string(q.rows[0].Before))
+	assert.JSONEq(t, `{"id":1,"name":"Mary Shelley","bio":"Novelist"}`, string(q.rows[0].After))
+	// only the changed fields of updates are kept
+	assert.JSONEq(t, `{"bio":"Novelist"}`, string(q.rows[1].Before))
+	assert.JSONEq(t, `{"bio":"English novelist"}`, string(q.rows[1].After))
+	assert.JSONEq(t, `null`, string(q.rows[2].After))
+}
+
+func TestMiddleware(t *testing.T) {
+	var origin audit.Origin
+	handler := chimiddleware.RequestID(audit.Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		origin = audit.FromContext(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodPost, "/authors", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set(middleware.CallerHeader, "alice")
+	req.Header.Set(chimiddleware.RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, audit.Origin{Actor: "alice", RequestID: "req-1", IP: "192.0.2.1"}, origin)
+	assert.Equal(t, audit.Origin{}, audit.FromContext(context.Background()))
+}
+
+func TestFilterFromQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    audit.Filter
+		wantErr bool
+	}{
+		{"empty", "", audit.Filter{}, false},
+		{
+			"all",
+			"entity_type=author&entity_id=1&actor=alice&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z",
+			audit.Filter{
+				EntityType: audit.EntityAuthor, EntityID: "1", Actor: "alice",
+				From: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			false,
+		},
+		{"unknown entity type", "entity_type=publisher", audit.Filter{}, true},
+		{"entity ID without type", "entity_id=1", audit.Filter{}, true},
+		{"invalid time", "from=yesterday", audit.Filter{}, true},
+		{"empty range", "from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z", audit.Filter{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			got, err := audit.FilterFromQuery(query)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, apperror.IsValidationError(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHandler_List(t *testing.T) {
+	q := &fakeAuditLog{}
+	for id := range 5 {
+		ctx := audit.NewContext(context.Background(), audit.Origin{Actor: []string{"alice", "bob"}[id%2]})
+		require.NoError(t, audit.Insert(ctx, q, audit.Created(audit.EntityBook, strconv.Itoa(id+1), nil)))
+	}
+	handler := audit.NewHandler(audit.NewService(audit.NewRepository(q)))
+
+	list := func(target string) ([]audit.EntryResponse, string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.List(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var entries []audit.EntryResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+		return entries, rec.Header().Get("Link")
+	}
+	ids := func(entries []audit.EntryResponse) []string {
+		ids := make([]string, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.EntityID
+		}
+		return ids
+	}
+
+	entries, link := list("/audit?actor=alice")
+	assert.Equal(t, []string{"5", "3", "1"}, ids(entries))
+	assert.Empty(t, link)
+
+	// pages follow the Link header
+	entries, link = list("/audit?limit=2")
+	assert.Equal(t, []string{"5", "4"}, ids(entries))
+	require.NotEmpty(t, link)
+	next, err := url.Parse(link[1 : len(link)-len(`>; rel="next"`)])
+	require.NoError(t, err)
+	entries, _ = list(next.String())
+	assert.Equal(t, []string{"3", "2"}, ids(entries))
+
+	rec := httptest.NewRecorder()
+	handler.List(rec, httptest.NewRequest(http.MethodGet, "/audit?entity_type=publisher", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,10 @@
+// Package audit records who changed the catalog, and when.
+//
+// The repositories of the authors and books insert an entry in the
+// audit_log table for each creation, update and deletion, in the
+// transaction of the change. Entries carry the origin of the request
+// (Middleware): the caller identity set by the gateway, the request ID and
+// the client IP, and the entity before and after the change; only the
+// changed fields are kept for updates. GET /audit lists the entries, most
+// recent first.
+package audit
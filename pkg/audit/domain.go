@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// Action is the kind of change recorded by an entry.
+type Action string
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityAuthor = "author"
+	EntityBook   = "book"
+)
+
+// EntityTypes are the entity types GET /audit can be filtered by.
+var EntityTypes = []string{EntityAuthor, EntityBook}
+
+// Query parameters of GET /audit.
+const (
+	ParamEntityType = "entity_type"
+	ParamEntityID   = "entity_id"
+	ParamActor      = "actor"
+	ParamFrom       = "from"
+	ParamTo         = "to"
+)
+
+// Entry is a change recorded in the audit log.
+type Entry struct {
+	ID         int64
+	EntityType string
+	EntityID   string
+	Action     Action
+	Actor      string
+	RequestID  string
+	IP         string
+	// Before and After are JSON null before a creation and after a deletion.
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt time.Time
+}
+
+// Filter selects entries, zero fields match any entry.
+type Filter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
+	// From and To bound the time of the entries, To being excluded.
+	From time.Time
+	To   time.Time
+}
+
+// FilterFromQuery parses the filter of GET /audit, times are RFC 3339.
+func FilterFromQuery(query url.Values) (Filter, error) {
+	f := Filter{
+		EntityType: query.Get(ParamEntityType),
+		EntityID:   query.Get(ParamEntityID),
+		Actor:      query.Get(ParamActor),
+	}
+	for param, t := range map[string]*time.Time{ParamFrom: &f.From, ParamTo: &f.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return Filter{}, apperror.NewValidationError(
+				"Invalid time, expected RFC 3339",
+				map[string]string{"field": param, "value": value},
+			)
+		}
+		*t = parsed
+	}
+	return f, f.Validate()
+}
+
+// Validate validates the filter.
+func (f Filter) Validate() error {
+	if f.EntityType != "" && !slices.Contains(EntityTypes, f.EntityType) {
+		return apperror.NewValidationError(
+			"Unknown entity type",
+			map[string]string{"field": ParamEntityType, "value": f.EntityType},
+		)
+	}
+	if f.EntityID != "" && f.EntityType == "" {
+		return apperror.NewValidationError(
+			"An entity ID requires an entity type",
+			map[string]string{"field": ParamEntityID},
+		)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return apperror.NewValidationError(
+			"Time range is empty",
+			map[string]string{"field": ParamTo},
+		)
+	}
+	return nil
+}
+
+// EntryResponse is the response format.
+type EntryResponse struct {
+	ID         int64           `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Action     Action          `json:"action"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id"`
+	IP         string          `json:"ip"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// ToResponse converts domain entry to response.
+func (e *Entry) ToResponse() *EntryResponse {
+	return &EntryResponse{
+		ID:         e.ID,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		Action:     e.Action,
+		Actor:      e.Actor,
+		RequestID:  e.RequestID,
+		IP:         e.IP,
+		Before:     e.Before,
+		After:      e.After,
+		CreatedAt:  e.CreatedAt,
+	}
+}
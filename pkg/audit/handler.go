@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/pagination"
+)
+
+// Handler handles HTTP requests for the audit log.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new audit log handler.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List handles GET /audit. The log is always paginated, with the default
+// limit unless a page is requested.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	filter, err := FilterFromQuery(r.URL.Query())
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+	page, paginated, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+	if !paginated {
+		page = pagination.Page{Limit: pagination.DefaultLimit}
+	}
+
+	entries, next, err := h.service.List(r.Context(), filter, page)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	responses := make([]*EntryResponse, len(entries))
+	for i, entry := range entries {
+		responses[i] = entry.ToResponse()
+	}
+
+	pagination.SetNextLink(w, r, page, next)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Origin is where a change comes from.
+type Origin struct {
+	// Actor is the caller identity (middleware.CallerHeader), empty when anonymous.
+	Actor     string
+	RequestID string
+	IP        string
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the origin of its changes.
+func NewContext(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, contextKey{}, origin)
+}
+
+// FromContext returns the origin of the changes made with ctx, zero outside
+// of requests.
+func FromContext(ctx context.Context) Origin {
+	origin, _ := ctx.Value(contextKey{}).(Origin)
+	return origin
+}
+
+// Middleware records the origin of each request in its context. It must
+// run after the request ID middleware.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), Origin{
+			Actor:     middleware.Caller(r),
+			RequestID: chimiddleware.GetReqID(r.Context()),
+			IP:        middleware.ClientIP(r),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Change is a change of an entity to record. Before and After are encoded
+// in JSON, e.g. the response format of the entity.
+type Change struct {
+	EntityType string
+	EntityID   string
+	Action     Action
+	Before     any
+	After      any
+}
+
+// Created returns the creation of an entity.
+func Created(entityType, entityID string, after any) Change {
+	return Change{EntityType: entityType, EntityID: entityID, Action: ActionCreate, After: after}
+}
+
+// Updated returns the update of an entity.
+func Updated(entityType, entityID string, before, after any) Change {
+	return Change{EntityType: entityType, EntityID: entityID, Action: ActionUpdate, Before: before, After: after}
+}
+
+// Deleted returns the deletion of an entity.
+func Deleted(entityType, entityID string, before any) Change {
+	return Change{EntityType: entityType, EntityID: entityID, Action: ActionDelete, Before: before}
+}
+
+// Insert records changes in the audit log, with the origin of ctx. q should
+// be bound to the transaction of the changes.
+func Insert(ctx context.Context, q repository.Querier, changes ...Change) error {
+	origin := FromContext(ctx)
+	now := time.Now().UTC()
+	for _, change := range changes {
+		before, after, err := diff(change.Before, change.After)
+		if err != nil {
+			return fmt.Errorf("could not encode %s %s change: %w", change.EntityType, change.Action, err)
+		}
+		err = q.InsertAuditEntry(ctx, repository.InsertAuditEntryParams{
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			Action:     string(change.Action),
+			Actor:      origin.Actor,
+			RequestID:  origin.RequestID,
+			Ip:         origin.IP,
+			Before:     before,
+			After:      after,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			return fmt.Errorf("could not insert %s %s change in audit log: %w", change.EntityType, change.Action, err)
+		}
+	}
+	return nil
+}
+
+// diff encodes before and after in JSON. When both are objects, only the
+// fields which changed are kept.
+func diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var beforeFields, afterFields map[string]any
+	if json.Unmarshal(beforeJSON, &beforeFields) != nil || json.Unmarshal(afterJSON, &afterFields) != nil ||
+		beforeFields == nil || afterFields == nil {
+		return beforeJSON, afterJSON, nil
+	}
+	for name, value := range beforeFields {
+		if changed, ok := afterFields[name]; ok && reflect.DeepEqual(value, changed) {
+			delete(beforeFields, name)
+			delete(afterFields, name)
+		}
+	}
+	if beforeJSON, err = json.Marshal(beforeFields); err != nil {
+		return nil, nil, err
+	}
+	if afterJSON, err = json.Marshal(afterFields); err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
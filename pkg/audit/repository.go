@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Repository defines the interface for audit log access.
+type Repository interface {
+	// List returns up to limit entries matching f, older than the entry
+	// beforeID (zero for the most recent entries), most recent first.
+	List(ctx context.Context, f Filter, beforeID int64, limit int) ([]*Entry, error)
+}
+
+// repositoryImpl wraps sqlc-generated queries.
+type repositoryImpl struct {
+	queries repository.Querier
+}
+
+// NewRepository creates a new audit log repository.
+func NewRepository(queries repository.Querier) Repository {
+	return &repositoryImpl{queries: queries}
+}
+
+func (r *repositoryImpl) List(ctx context.Context, f Filter, beforeID int64, limit int) ([]*Entry, error) {
+	dbEntries, err := r.queries.ListAuditEntries(ctx, repository.ListAuditEntriesParams{
+		EntityType:  f.EntityType,
+		EntityID:    f.EntityID,
+		Actor:       f.Actor,
+		CreatedFrom: sql.NullTime{Time: f.From, Valid: !f.From.IsZero()},
+		CreatedTo:   sql.NullTime{Time: f.To, Valid: !f.To.IsZero()},
+		BeforeID:    beforeID,
+		MaxRows:     int32(limit), //nolint:gosec // bounded by pagination.MaxLimit
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+
+	entries := make([]*Entry, len(dbEntries))
+	for i, dbEntry := range dbEntries {
+		entries[i] = &Entry{
+			ID:         dbEntry.ID,
+			EntityType: dbEntry.EntityType,
+			EntityID:   dbEntry.EntityID,
+			Action:     Action(dbEntry.Action),
+			Actor:      dbEntry.Actor,
+			RequestID:  dbEntry.RequestID,
+			IP:         dbEntry.Ip,
+			Before:     dbEntry.Before,
+			After:      dbEntry.After,
+			CreatedAt:  dbEntry.CreatedAt,
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sgaunet/template-api/internal/pagination"
+)
+
+// Service provides the audit log queries.
+type Service interface {
+	// List returns a page of the entries matching f, most recent first, and
+	// the cursor of the next page, nil on the last page.
+	List(ctx context.Context, f Filter, page pagination.Page) ([]*Entry, *pagination.Cursor, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new audit log service.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) List(ctx context.Context, f Filter, page pagination.Page) ([]*Entry, *pagination.Cursor, error) {
+	if err := f.Validate(); err != nil {
+		return nil, nil, err
+	}
+	// One more entry is read to know whether another page follows.
+	entries, err := s.repo.List(ctx, f, page.After.ID, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	entries, next := pagination.Trim(entries, page, func(e *Entry) pagination.Cursor {
+		return pagination.Cursor{ID: e.ID}
+	})
+	return entries, next, nil
+}
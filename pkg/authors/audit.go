@@ -0,0 +1,30 @@
+package authors
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/pkg/audit"
+)
+
+func createdChange(a *Author) audit.Change {
+	return audit.Created(audit.EntityAuthor, strconv.FormatInt(a.ID, 10), a.ToResponse())
+}
+
+func updatedChange(before, after *Author) audit.Change {
+	return audit.Updated(audit.EntityAuthor, strconv.FormatInt(after.ID, 10), before.ToResponse(), after.ToResponse())
+}
+
+// deletedChanges returns the deletion of the authors of locked whose ID is
+// in deleted.
+func deletedChanges(locked []repository.Author, deleted []int64) []audit.Change {
+	changes := make([]audit.Change, 0, len(deleted))
+	for _, dbAuthor := range locked {
+		if slices.Contains(deleted, dbAuthor.ID) {
+			a := &Author{ID: dbAuthor.ID, Name: dbAuthor.Name, Bio: dbAuthor.Bio}
+			changes = append(changes, audit.Deleted(audit.EntityAuthor, strconv.FormatInt(a.ID, 10), a.ToResponse()))
+		}
+	}
+	return changes
+}
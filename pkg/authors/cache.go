@@ -0,0 +1,225 @@
+package authors
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/cache"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is the default time authors stay in cache.
+const DefaultCacheTTL = time.Minute
+
+const (
+	authorCacheKeyPrefix      = "authors:id:"
+	authorsListCacheKey       = "authors:list"
+	authorsPageCacheKeyPrefix = "authors:page:"
+)
+
+// cachedRepository is a read-through cache in front of a Repository.
+// Lookups of the same key are deduplicated so that an expired entry
+// triggers a single load from the underlying repository.
+type cachedRepository struct {
+	repo  Repository
+	cache cache.Cache
+	ttl   time.Duration
+	group singleflight.Group
+	// version is incremented by every invalidation, so that loads running
+	// concurrently with a write don't cache what they read before it.
+	version atomic.Uint64
+}
+
+// NewCachedRepository decorates repo with a read-through cache of GetByID, GetByIDs, List and
+// ListPage. Writes invalidate the affected entries, pages are keyed by the version of the cache so
+// that any write invalidates them all, and loads running concurrently with a write of this
+// instance aren't cached; with a cache shared by several instances, entries loaded while another
+// instance writes may be stale until ttl. A ttl lower or equal to zero means DefaultCacheTTL.
+func NewCachedRepository(repo Repository, c cache.Cache, ttl time.Duration) Repository {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &cachedRepository{repo: repo, cache: c, ttl: ttl}
+}
+
+func (r *cachedRepository) Create(ctx context.Context, author *Author) (*Author, error) {
+	created, err := r.repo.Create(ctx, author)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent decorator
+	}
+	r.invalidate(ctx, authorsListCacheKey)
+	return created, nil
+}
+
+func (r *cachedRepository) GetByID(ctx context.Context, id int64) (*Author, error) {
+	key := authorCacheKey(id)
+	return readThrough(ctx, r, key, func() (*Author, error) {
+		return r.repo.GetByID(ctx, id)
+	})
+}
+
+// GetByIDs returns the cached authors and loads the others with a single
+// lookup of the underlying repository.
+func (r *cachedRepository) GetByIDs(ctx context.Context, ids []int64) ([]*Author, error) {
+	found := make([]*Author, 0, len(ids))
+	var missing []int64
+	for _, id := range ids {
+		key := authorCacheKey(id)
+		data, ok, err := r.cache.Get(ctx, key)
+		if err != nil {
+			slog.Warn("could not read authors cache", "key", key, "error", err)
+		}
+		var author *Author
+		if ok && json.Unmarshal(data, &author) == nil && author != nil {
+			found = append(found, author)
+			continue
+		}
+		missing = append(missing, id)
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	version := r.version.Load()
+	loaded, err := r.repo.GetByIDs(ctx, missing)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent decorator
+	}
+	for _, author := range loaded {
+		r.store(ctx, version, authorCacheKey(author.ID), author)
+	}
+	return append(found, loaded...), nil
+}
+
+func (r *cachedRepository) List(ctx context.Context) ([]*Author, error) {
+	return readThrough(ctx, r, authorsListCacheKey, func() ([]*Author, error) {
+		return r.repo.List(ctx)
+	})
+}
+
+// ListPage caches pages under the current version: a write moves the
+// following lookups to new keys, and the previous pages expire.
+func (r *cachedRepository) ListPage(ctx context.Context, after pagination.Cursor, limit int) ([]*Author, error) {
+	key := authorsPageCacheKey(r.version.Load(), after, limit)
+	return readThrough(ctx, r, key, func() ([]*Author, error) {
+		return r.repo.ListPage(ctx, after, limit)
+	})
+}
+
+func (r *cachedRepository) Update(ctx context.Context, author *Author) (*Author, error) {
+	updated, err := r.repo.Update(ctx, author)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent decorator
+	}
+	r.invalidate(ctx, authorCacheKey(author.ID), authorsListCacheKey)
+	return updated, nil
+}
+
+func (r *cachedRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.repo.Delete(ctx, id); err != nil {
+		return err //nolint:wrapcheck // transparent decorator
+	}
+	r.invalidate(ctx, authorCacheKey(id), authorsListCacheKey)
+	return nil
+}
+
+func (r *cachedRepository) CreateBatch(ctx context.Context, authors []*Author) ([]*Author, error) {
+	created, err := r.repo.CreateBatch(ctx, authors)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent decorator
+	}
+	r.invalidate(ctx, authorsListCacheKey)
+	return created, nil
+}
+
+func (r *cachedRepository) DeleteBatch(ctx context.Context, ids []int64) ([]int64, error) {
+	deleted, err := r.repo.DeleteBatch(ctx, ids)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // transparent decorator
+	}
+	keys := make([]string, 0, len(deleted)+1)
+	for _, id := range deleted {
+		keys = append(keys, authorCacheKey(id))
+	}
+	r.invalidate(ctx, append(keys, authorsListCacheKey)...)
+	return deleted, nil
+}
+
+func (r *cachedRepository) Stream(ctx context.Context, fn func(*Author) error) error {
+	return r.repo.Stream(ctx, fn) //nolint:wrapcheck // transparent decorator
+}
+
+// invalidate deletes keys after a write. The version is incremented first,
+// see store.
+func (r *cachedRepository) invalidate(ctx context.Context, keys ...string) {
+	r.version.Add(1)
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		slog.Warn("could not invalidate authors cache", "keys", keys, "error", err)
+	}
+}
+
+// store caches value, loaded at version, under key. A value loaded before
+// an invalidation may be stale and isn't cached: the version is checked
+// again after caching it, and the entry is deleted if an invalidation ran
+// concurrently.
+func (r *cachedRepository) store(ctx context.Context, version uint64, key string, value any) {
+	if r.version.Load() != version {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := r.cache.Set(ctx, key, data, r.ttl); err != nil {
+		slog.Warn("could not write authors cache", "key", key, "error", err)
+		return
+	}
+	if r.version.Load() != version {
+		if err := r.cache.Delete(ctx, key); err != nil {
+			slog.Warn("could not invalidate authors cache", "keys", []string{key}, "error", err)
+		}
+	}
+}
+
+// readThrough returns the cached value of key, or loads and caches it.
+// Cache failures are logged and the underlying repository is used instead.
+func readThrough[T any](ctx context.Context, r *cachedRepository, key string, load func() (T, error)) (T, error) {
+	var value T
+	data, found, err := r.cache.Get(ctx, key)
+	if err != nil {
+		slog.Warn("could not read authors cache", "key", key, "error", err)
+	}
+	if found {
+		if err := json.Unmarshal(data, &value); err == nil {
+			return value, nil
+		}
+		slog.Warn("could not decode cached authors", "key", key, "error", err)
+	}
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		version := r.version.Load()
+		loaded, err := load()
+		if err != nil {
+			return loaded, err
+		}
+		r.store(ctx, version, key, loaded)
+		return loaded, nil
+	})
+	if err != nil {
+		return value, err //nolint:wrapcheck // transparent decorator
+	}
+	return v.(T), nil //nolint:forcetypeassert // load returns a T
+}
+
+func authorCacheKey(id int64) string {
+	return authorCacheKeyPrefix + strconv.FormatInt(id, 10)
+}
+
+func authorsPageCacheKey(version uint64, after pagination.Cursor, limit int) string {
+	return authorsPageCacheKeyPrefix + strconv.FormatUint(version, 10) + ":" + after.Encode() + ":" + strconv.Itoa(limit)
+}
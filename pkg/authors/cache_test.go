@@ -0,0 +1,169 @@
+package authors_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/cache"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts the lookups reaching the underlying repository.
+type countingRepository struct {
+	*fakeRepository
+	gets  atomic.Int64
+	lists atomic.Int64
+	pages atomic.Int64
+}
+
+func (c *countingRepository) GetByID(ctx context.Context, id int64) (*authors.Author, error) {
+	c.gets.Add(1)
+	time.Sleep(10 * time.Millisecond) // let concurrent lookups pile up
+	return c.fakeRepository.GetByID(ctx, id)
+}
+
+func (c *countingRepository) List(ctx context.Context) ([]*authors.Author, error) {
+	c.lists.Add(1)
+	return c.fakeRepository.List(ctx)
+}
+
+func (c *countingRepository) ListPage(
+	ctx context.Context, after pagination.Cursor, limit int,
+) ([]*authors.Author, error) {
+	c.pages.Add(1)
+	return c.fakeRepository.ListPage(ctx, after, limit)
+}
+
+func TestCachedRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepository{fakeRepository: newFakeRepository()}
+	cached := authors.NewCachedRepository(repo, cache.NewLRU(10), time.Minute)
+	created, err := cached.Create(ctx, &authors.Author{Name: "ValidName", Bio: "bio"})
+	require.NoError(t, err)
+
+	for range 3 {
+		author, err := cached.GetByID(ctx, created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *created, *author)
+	}
+	assert.Equal(t, int64(1), repo.gets.Load())
+
+	require.NoError(t, cached.Delete(ctx, created.ID))
+	_, err = cached.GetByID(ctx, created.ID)
+	assert.Error(t, err)
+	assert.Equal(t, int64(2), repo.gets.Load())
+}
+
+func TestCachedRepository_ListInvalidatedOnWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepository{fakeRepository: newFakeRepository()}
+	cached := authors.NewCachedRepository(repo, cache.NewLRU(10), time.Minute)
+
+	list, err := cached.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	_, _ = cached.List(ctx)
+	assert.Equal(t, int64(1), repo.lists.Load())
+
+	_, err = cached.CreateBatch(ctx, []*authors.Author{{Name: "ValidName"}})
+	require.NoError(t, err)
+	list, err = cached.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, int64(2), repo.lists.Load())
+}
+
+func TestCachedRepository_ListPage(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepository{fakeRepository: newFakeRepository()}
+	cached := authors.NewCachedRepository(repo, cache.NewLRU(10), time.Minute)
+	_, err := cached.CreateBatch(ctx, []*authors.Author{{Name: "Ann Leckie"}, {Name: "Iain Banks"}, {Name: "Le Guin"}})
+	require.NoError(t, err)
+
+	first, err := cached.ListPage(ctx, pagination.Cursor{}, 2)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	next := pagination.Cursor{Key: first[1].Name, ID: first[1].ID}
+	second, err := cached.ListPage(ctx, next, 2)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	for range 2 {
+		_, _ = cached.ListPage(ctx, pagination.Cursor{}, 2)
+		_, _ = cached.ListPage(ctx, next, 2)
+	}
+	assert.Equal(t, int64(2), repo.pages.Load(), "pages are cached by cursor and limit")
+
+	// any write invalidates every page
+	require.NoError(t, cached.Delete(ctx, second[0].ID))
+	second, err = cached.ListPage(ctx, next, 2)
+	require.NoError(t, err)
+	assert.Empty(t, second)
+	_, _ = cached.ListPage(ctx, pagination.Cursor{}, 2)
+	assert.Equal(t, int64(4), repo.pages.Load())
+}
+
+func TestCachedRepository_StampedeProtection(t *testing.T) {
+	ctx := context.Background()
+	repo := &countingRepository{fakeRepository: newFakeRepository()}
+	created, err := repo.Create(ctx, &authors.Author{Name: "ValidName"})
+	require.NoError(t, err)
+	cached := authors.NewCachedRepository(repo, cache.NewLRU(10), time.Minute)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			_, err := cached.GetByID(ctx, created.ID)
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1), repo.gets.Load())
+}
+
+// blockingRepository pauses GetByID between reading the author and
+// returning it, until release is closed.
+type blockingRepository struct {
+	*fakeRepository
+	loaded  chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingRepository) GetByID(ctx context.Context, id int64) (*authors.Author, error) {
+	author, err := b.fakeRepository.GetByID(ctx, id)
+	b.loaded <- struct{}{}
+	<-b.release
+	return author, err
+}
+
+func TestCachedRepository_DeleteDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	repo := &blockingRepository{
+		fakeRepository: newFakeRepository(),
+		loaded:         make(chan struct{}, 2),
+		release:        make(chan struct{}),
+	}
+	created, err := repo.Create(ctx, &authors.Author{Name: "ValidName"})
+	require.NoError(t, err)
+	cached := authors.NewCachedRepository(repo, cache.NewLRU(10), time.Minute)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		author, err := cached.GetByID(ctx, created.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, author.ID)
+	})
+	<-repo.loaded
+	require.NoError(t, cached.Delete(ctx, created.ID))
+	close(repo.release)
+	wg.Wait()
+
+	// the author read before the delete isn't cached
+	_, err = cached.GetByID(ctx, created.ID)
+	assert.Error(t, err)
+}
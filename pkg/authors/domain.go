@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
 )
 
 // Author represents a domain author with business logic.
@@ -139,3 +140,50 @@ func (a *Author) ToResponse() *AuthorResponse {
 		Bio:  a.Bio,
 	}
 }
+
+// BatchResult is the outcome of a single item of a batch operation.
+// Err is set when the item failed. Author is only set by batch creation.
+type BatchResult struct {
+	Index  int
+	ID     int64
+	Author *Author
+	Err    error
+}
+
+// BatchItemResponse is the response format of a single batch item.
+type BatchItemResponse struct {
+	Index  int                     `json:"index"`
+	ID     int64                   `json:"id,omitempty"`
+	Author *AuthorResponse         `json:"author,omitempty"`
+	Error  *apperror.ErrorResponse `json:"error,omitempty"`
+}
+
+// ToResponse converts a batch result to response.
+func (r *BatchResult) ToResponse() *BatchItemResponse {
+	resp := &BatchItemResponse{Index: r.Index, ID: r.ID}
+	if r.Err != nil {
+		errResp, _ := apperror.NewErrorResponse(r.Err)
+		resp.Error = &errResp
+		return resp
+	}
+	if r.Author != nil {
+		resp.Author = r.Author.ToResponse()
+	}
+	return resp
+}
+
+// exportMapping maps exported authors to CSV columns.
+var exportMapping = exchange.CSVMapping[*AuthorResponse]{
+	Header: []string{"id", "name", "bio"},
+	Marshal: func(a *AuthorResponse) []string {
+		return []string{strconv.FormatInt(a.ID, 10), a.Name, a.Bio}
+	},
+}
+
+// importMapping maps CSV columns to author creation requests.
+var importMapping = exchange.CSVMapping[CreateAuthorRequest]{
+	Header: []string{"name", "bio"},
+	Unmarshal: func(fields []string) (CreateAuthorRequest, error) {
+		return CreateAuthorRequest{Name: fields[0], Bio: fields[1]}, nil
+	},
+}
@@ -0,0 +1,49 @@
+package authors
+
+import (
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/outbox"
+)
+
+// Domain event types published for authors.
+const (
+	EventAuthorCreated = "AuthorCreated"
+	EventAuthorUpdated = "AuthorUpdated"
+	EventAuthorDeleted = "AuthorDeleted"
+)
+
+// aggregateType is the aggregate type of author events.
+const aggregateType = "author"
+
+// DeletedEvent is the payload of AuthorDeleted events.
+type DeletedEvent struct {
+	ID int64 `json:"id"`
+}
+
+func createdEvent(a *Author) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(a.ID, 10),
+		Type:          EventAuthorCreated,
+		Data:          a.ToResponse(),
+	}
+}
+
+func updatedEvent(a *Author) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(a.ID, 10),
+		Type:          EventAuthorUpdated,
+		Data:          a.ToResponse(),
+	}
+}
+
+func deletedEvent(id int64) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(id, 10),
+		Type:          EventAuthorDeleted,
+		Data:          DeletedEvent{ID: id},
+	}
+}
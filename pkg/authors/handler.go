@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/pagination"
 )
 
 // Handler handles HTTP requests for authors.
@@ -42,9 +44,24 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// List handles GET /authors.
+// List handles GET /authors, paginated with the limit and cursor query
+// parameters. Without them, all the authors are returned.
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
-	authors, err := h.service.List(r.Context())
+	page, paginated, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	var (
+		authors []*Author
+		next    *pagination.Cursor
+	)
+	if paginated {
+		authors, next, err = h.service.ListPage(r.Context(), page)
+	} else {
+		authors, err = h.service.List(r.Context())
+	}
 	if err != nil {
 		apperror.WriteError(w, err)
 		return
@@ -55,6 +72,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		responses[i] = author.ToResponse()
 	}
 
+	pagination.SetNextLink(w, r, page, next)
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(responses); err != nil {
 		// Response already written, can't send error response
@@ -62,12 +80,57 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Get handles GET /authors/{uuid}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := authorID(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(author.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Update handles PUT /authors/{uuid}.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := authorID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateAuthorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	author, err := h.service.Update(r.Context(), id, &req)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(author.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
 // Delete handles DELETE /authors/{uuid}.
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "uuid")
-	id, err := strconv.ParseInt(idStr, 10, 64)
-	if err != nil {
-		apperror.WriteError(w, apperror.NewBadRequestError("Invalid author ID"))
+	id, ok := authorID(w, r)
+	if !ok {
 		return
 	}
 
@@ -78,3 +141,110 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// CreateBatch handles POST /authors:batch.
+func (h *Handler) CreateBatch(w http.ResponseWriter, r *http.Request) {
+	var reqs []CreateAuthorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	results, err := h.service.CreateBatch(r.Context(), reqs)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	writeBatchResults(w, results)
+}
+
+// DeleteBatch handles DELETE /authors:batch.
+func (h *Handler) DeleteBatch(w http.ResponseWriter, r *http.Request) {
+	var ids []int64
+
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	results, err := h.service.DeleteBatch(r.Context(), ids)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	writeBatchResults(w, results)
+}
+
+// Export handles GET /authors/export.
+// The format (CSV or NDJSON) is negotiated with the Accept header.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	format := exchange.NegotiateFormat(r.Header.Get("Accept"))
+	writer := exchange.NewWriter(w, format, exportMapping)
+	w.Header().Set("Content-Type", format.ContentType())
+
+	written := false
+	err := h.service.Export(r.Context(), func(author *Author) error {
+		written = true
+		return writer.Write(author.ToResponse())
+	})
+	if err != nil {
+		if !written {
+			apperror.WriteError(w, err)
+		}
+		// Otherwise response already started, can't send error response
+		return
+	}
+	_ = writer.Flush()
+}
+
+// Import handles POST /authors/import.
+// The format (CSV or NDJSON) is given by the Content-Type header.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	format, err := exchange.FormatFromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	result, err := h.service.Import(r.Context(), exchange.NewReader(r.Body, format, importMapping))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+func writeBatchResults(w http.ResponseWriter, results []*BatchResult) {
+	responses := make([]*BatchItemResponse, len(results))
+	for i, result := range results {
+		responses[i] = result.ToResponse()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// authorID parses the {uuid} URL parameter, writing an error if it's invalid.
+func authorID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "uuid"), 10, 64)
+	if err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid author ID"))
+		return 0, false
+	}
+	return id, true
+}
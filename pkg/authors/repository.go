@@ -6,41 +6,81 @@ import (
 	"errors"
 
 	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/internal/pagination"
 	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/pkg/audit"
 )
 
+var errStreamingUnsupported = errors.New("queries don't support streaming")
+
 // Repository defines the interface for author data access.
 type Repository interface {
 	Create(ctx context.Context, author *Author) (*Author, error)
 	GetByID(ctx context.Context, id int64) (*Author, error)
+	GetByIDs(ctx context.Context, ids []int64) ([]*Author, error)
 	List(ctx context.Context) ([]*Author, error)
+	// ListPage returns up to limit authors following after, in the order of List.
+	ListPage(ctx context.Context, after pagination.Cursor, limit int) ([]*Author, error)
+	Update(ctx context.Context, author *Author) (*Author, error)
 	Delete(ctx context.Context, id int64) error
+	CreateBatch(ctx context.Context, authors []*Author) ([]*Author, error)
+	DeleteBatch(ctx context.Context, ids []int64) ([]int64, error)
+	Stream(ctx context.Context, fn func(*Author) error) error
 }
 
 // repositoryImpl wraps sqlc-generated queries.
 type repositoryImpl struct {
 	queries repository.Querier
+	tx      repository.Transactor
+}
+
+// RepositoryOption configures the author repository.
+type RepositoryOption func(*repositoryImpl)
+
+// WithOutbox publishes domain events: mutations run in a transaction of tx
+// which also records their events in the outbox. Mutations are recorded in
+// the audit log with or without it, in the transaction when there is one.
+func WithOutbox(tx repository.Transactor) RepositoryOption {
+	return func(r *repositoryImpl) {
+		r.tx = tx
+	}
 }
 
 // NewRepository creates a new author repository.
-func NewRepository(queries repository.Querier) Repository {
-	return &repositoryImpl{queries: queries}
+func NewRepository(queries repository.Querier, opts ...RepositoryOption) Repository {
+	r := &repositoryImpl{queries: queries}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 func (r *repositoryImpl) Create(ctx context.Context, author *Author) (*Author, error) {
-	dbAuthor, err := r.queries.CreateAuthor(ctx, repository.CreateAuthorParams{
-		Name: author.Name,
-		Bio:  author.Bio,
+	var created *Author
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		dbAuthor, err := q.CreateAuthor(ctx, repository.CreateAuthorParams{
+			Name: author.Name,
+			Bio:  author.Bio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = &Author{
+			ID:   dbAuthor.ID,
+			Name: dbAuthor.Name,
+			Bio:  dbAuthor.Bio,
+		}
+		if err := audit.Insert(ctx, q, createdChange(created)); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{createdEvent(created)}, nil
 	})
 	if err != nil {
 		return nil, apperror.NewInternalError(err)
 	}
 
-	return &Author{
-		ID:   dbAuthor.ID,
-		Name: dbAuthor.Name,
-		Bio:  dbAuthor.Bio,
-	}, nil
+	return created, nil
 }
 
 func (r *repositoryImpl) GetByID(ctx context.Context, id int64) (*Author, error) {
@@ -59,6 +99,24 @@ func (r *repositoryImpl) GetByID(ctx context.Context, id int64) (*Author, error)
 	}, nil
 }
 
+func (r *repositoryImpl) GetByIDs(ctx context.Context, ids []int64) ([]*Author, error) {
+	dbAuthors, err := r.queries.GetAuthorsByIDs(ctx, ids)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+
+	authors := make([]*Author, len(dbAuthors))
+	for i, dbAuthor := range dbAuthors {
+		authors[i] = &Author{
+			ID:   dbAuthor.ID,
+			Name: dbAuthor.Name,
+			Bio:  dbAuthor.Bio,
+		}
+	}
+
+	return authors, nil
+}
+
 func (r *repositoryImpl) List(ctx context.Context) ([]*Author, error) {
 	dbAuthors, err := r.queries.ListAuthors(ctx)
 	if err != nil {
@@ -77,8 +135,86 @@ func (r *repositoryImpl) List(ctx context.Context) ([]*Author, error) {
 	return authors, nil
 }
 
+func (r *repositoryImpl) ListPage(ctx context.Context, after pagination.Cursor, limit int) ([]*Author, error) {
+	dbAuthors, err := r.queries.ListAuthorsPage(ctx, repository.ListAuthorsPageParams{
+		AfterName: after.Key,
+		AfterID:   after.ID,
+		MaxRows:   int32(limit), //nolint:gosec // bounded by pagination.MaxLimit
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+
+	authors := make([]*Author, len(dbAuthors))
+	for i, dbAuthor := range dbAuthors {
+		authors[i] = &Author{
+			ID:   dbAuthor.ID,
+			Name: dbAuthor.Name,
+			Bio:  dbAuthor.Bio,
+		}
+	}
+
+	return authors, nil
+}
+
+func (r *repositoryImpl) Update(ctx context.Context, author *Author) (*Author, error) {
+	var updated *Author
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		locked, err := q.LockAuthors(ctx, []int64{author.ID})
+		if err != nil {
+			return nil, err
+		}
+		if len(locked) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		dbAuthor, err := q.UpdateAuthor(ctx, repository.UpdateAuthorParams{
+			ID:   author.ID,
+			Name: author.Name,
+			Bio:  author.Bio,
+		})
+		if err != nil {
+			return nil, err
+		}
+		updated = &Author{
+			ID:   dbAuthor.ID,
+			Name: dbAuthor.Name,
+			Bio:  dbAuthor.Bio,
+		}
+		before := &Author{ID: locked[0].ID, Name: locked[0].Name, Bio: locked[0].Bio}
+		if err := audit.Insert(ctx, q, updatedChange(before, updated)); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{updatedEvent(updated)}, nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.NewNotFoundError("Author not found")
+		}
+		return nil, apperror.NewInternalError(err)
+	}
+
+	return updated, nil
+}
+
 func (r *repositoryImpl) Delete(ctx context.Context, id int64) error {
-	if err := r.queries.DeleteAuthor(ctx, id); err != nil {
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		// The author is locked to record it in the audit log as deleted.
+		locked, err := q.LockAuthors(ctx, []int64{id})
+		if err != nil {
+			return nil, err
+		}
+		// DeleteAuthors reports whether the author existed, so that no event
+		// is recorded for missing authors.
+		deleted, err := q.DeleteAuthors(ctx, []int64{id})
+		if err != nil || len(deleted) == 0 {
+			return nil, err
+		}
+		if err := audit.Insert(ctx, q, deletedChanges(locked, deleted)...); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{deletedEvent(id)}, nil
+	})
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return apperror.NewNotFoundError("Author not found")
 		}
@@ -86,3 +222,97 @@ func (r *repositoryImpl) Delete(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+func (r *repositoryImpl) CreateBatch(ctx context.Context, authors []*Author) ([]*Author, error) {
+	params := repository.CreateAuthorsParams{
+		Names: make([]string, len(authors)),
+		Bios:  make([]string, len(authors)),
+	}
+	for i, author := range authors {
+		params.Names[i] = author.Name
+		params.Bios[i] = author.Bio
+	}
+
+	// A single multi-row INSERT is atomic, no explicit transaction is needed
+	// unless events are recorded.
+	var created []*Author
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		dbAuthors, err := q.CreateAuthors(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		created = make([]*Author, len(dbAuthors))
+		events := make([]outbox.Event, len(dbAuthors))
+		changes := make([]audit.Change, len(dbAuthors))
+		for i, dbAuthor := range dbAuthors {
+			created[i] = &Author{
+				ID:   dbAuthor.ID,
+				Name: dbAuthor.Name,
+				Bio:  dbAuthor.Bio,
+			}
+			events[i] = createdEvent(created[i])
+			changes[i] = createdChange(created[i])
+		}
+		if err := audit.Insert(ctx, q, changes...); err != nil {
+			return nil, err
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+
+	return created, nil
+}
+
+func (r *repositoryImpl) DeleteBatch(ctx context.Context, ids []int64) ([]int64, error) {
+	var deleted []int64
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		// The authors are locked to record them in the audit log as deleted.
+		locked, err := q.LockAuthors(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		deleted, err = q.DeleteAuthors(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		events := make([]outbox.Event, len(deleted))
+		for i, id := range deleted {
+			events[i] = deletedEvent(id)
+		}
+		if err := audit.Insert(ctx, q, deletedChanges(locked, deleted)...); err != nil {
+			return nil, err
+		}
+		return events, nil
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return deleted, nil
+}
+
+func (r *repositoryImpl) Stream(ctx context.Context, fn func(*Author) error) error {
+	streamer, ok := r.queries.(repository.Streamer)
+	if !ok {
+		return apperror.NewInternalError(errStreamingUnsupported)
+	}
+
+	var fnErr error
+	err := streamer.StreamAuthors(ctx, func(dbAuthor repository.Author) error {
+		fnErr = fn(&Author{
+			ID:   dbAuthor.ID,
+			Name: dbAuthor.Name,
+			Bio:  dbAuthor.Bio,
+		})
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	return nil
+}
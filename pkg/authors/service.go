@@ -2,27 +2,73 @@ package authors
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/pagination"
 )
 
+var errBatchMismatch = errors.New("batch result size mismatch")
+
 // Service provides author business logic.
 type Service interface {
 	Create(ctx context.Context, req *CreateAuthorRequest) (*Author, error)
 	GetByID(ctx context.Context, id int64) (*Author, error)
+	// GetByIDs returns the authors of ids, missing authors are omitted.
+	GetByIDs(ctx context.Context, ids []int64) ([]*Author, error)
 	List(ctx context.Context) ([]*Author, error)
+	// ListPage returns a page of authors and the cursor of the next page,
+	// nil on the last page.
+	ListPage(ctx context.Context, page pagination.Page) ([]*Author, *pagination.Cursor, error)
+	// Update replaces the name and bio of the author id with those of req.
+	Update(ctx context.Context, id int64, req *CreateAuthorRequest) (*Author, error)
 	Delete(ctx context.Context, id int64) error
+	CreateBatch(ctx context.Context, reqs []CreateAuthorRequest) ([]*BatchResult, error)
+	DeleteBatch(ctx context.Context, ids []int64) ([]*BatchResult, error)
+	Export(ctx context.Context, fn func(*Author) error) error
+	Import(ctx context.Context, src ImportSource) (*exchange.ImportResult, error)
+}
+
+// ImportSource yields the rows of an import.
+type ImportSource interface {
+	Next() (exchange.Row[CreateAuthorRequest], error)
 }
 
+// DefaultMaxBatchSize is the default maximum number of items in a batch request.
+const DefaultMaxBatchSize = 1000
+
 type service struct {
-	repo Repository
+	repo         Repository
+	maxBatchSize int
+}
+
+// ServiceOption configures the author service.
+type ServiceOption func(*service)
+
+// WithMaxBatchSize sets the maximum number of items accepted by batch operations.
+// Values lower or equal to zero are ignored.
+func WithMaxBatchSize(size int) ServiceOption {
+	return func(s *service) {
+		if size > 0 {
+			s.maxBatchSize = size
+		}
+	}
 }
 
 // NewService creates a new author service.
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, opts ...ServiceOption) Service {
+	s := &service{
+		repo:         repo,
+		maxBatchSize: DefaultMaxBatchSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *service) Create(ctx context.Context, req *CreateAuthorRequest) (*Author, error) {
@@ -62,6 +108,21 @@ func (s *service) GetByID(ctx context.Context, id int64) (*Author, error) {
 	return author, nil
 }
 
+func (s *service) GetByIDs(ctx context.Context, ids []int64) ([]*Author, error) {
+	if len(ids) == 0 {
+		return []*Author{}, nil
+	}
+	if err := s.validateBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	authors, err := s.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get authors: %w", err)
+	}
+	return authors, nil
+}
+
 func (s *service) List(ctx context.Context) ([]*Author, error) {
 	authors, err := s.repo.List(ctx)
 	if err != nil {
@@ -70,6 +131,39 @@ func (s *service) List(ctx context.Context) ([]*Author, error) {
 	return authors, nil
 }
 
+func (s *service) ListPage(ctx context.Context, page pagination.Page) ([]*Author, *pagination.Cursor, error) {
+	// One more author is read to know whether another page follows.
+	authors, err := s.repo.ListPage(ctx, page.After, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list authors: %w", err)
+	}
+	authors, next := pagination.Trim(authors, page, func(a *Author) pagination.Cursor {
+		return pagination.Cursor{Key: a.Name, ID: a.ID}
+	})
+	return authors, next, nil
+}
+
+func (s *service) Update(ctx context.Context, id int64, req *CreateAuthorRequest) (*Author, error) {
+	if id <= 0 {
+		return nil, apperror.NewValidationError(
+			"Invalid author ID",
+			map[string]string{"field": "id", "value": strconv.FormatInt(id, 10)},
+		)
+	}
+
+	author, err := req.ToAuthor()
+	if err != nil {
+		return nil, err
+	}
+	author.ID = id
+
+	updated, err := s.repo.Update(ctx, author)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update author: %w", err)
+	}
+	return updated, nil
+}
+
 func (s *service) Delete(ctx context.Context, id int64) error {
 	if id <= 0 {
 		return apperror.NewValidationError(
@@ -83,3 +177,161 @@ func (s *service) Delete(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+func (s *service) CreateBatch(ctx context.Context, reqs []CreateAuthorRequest) ([]*BatchResult, error) {
+	if err := s.validateBatchSize(len(reqs)); err != nil {
+		return nil, err
+	}
+
+	// Validate every item, only valid ones are persisted
+	results := make([]*BatchResult, len(reqs))
+	valid := make([]*Author, 0, len(reqs))
+	validIdx := make([]int, 0, len(reqs))
+	for i := range reqs {
+		results[i] = &BatchResult{Index: i}
+		author, err := reqs[i].ToAuthor()
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, author)
+		validIdx = append(validIdx, i)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	created, err := s.repo.CreateBatch(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authors: %w", err)
+	}
+	if len(created) != len(valid) {
+		return nil, apperror.NewInternalError(fmt.Errorf("%w: expected %d authors, got %d",
+			errBatchMismatch, len(valid), len(created)))
+	}
+	for i, author := range created {
+		results[validIdx[i]].ID = author.ID
+		results[validIdx[i]].Author = author
+	}
+	return results, nil
+}
+
+func (s *service) DeleteBatch(ctx context.Context, ids []int64) ([]*BatchResult, error) {
+	if err := s.validateBatchSize(len(ids)); err != nil {
+		return nil, err
+	}
+
+	results := make([]*BatchResult, len(ids))
+	valid := make([]int64, 0, len(ids))
+	for i, id := range ids {
+		results[i] = &BatchResult{Index: i, ID: id}
+		if id <= 0 {
+			results[i].Err = apperror.NewValidationError(
+				"Invalid author ID",
+				map[string]string{"field": "id", "value": strconv.FormatInt(id, 10)},
+			)
+			continue
+		}
+		valid = append(valid, id)
+	}
+
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	deleted, err := s.repo.DeleteBatch(ctx, valid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete authors: %w", err)
+	}
+	deletedSet := make(map[int64]struct{}, len(deleted))
+	for _, id := range deleted {
+		deletedSet[id] = struct{}{}
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if _, ok := deletedSet[result.ID]; !ok {
+			result.Err = apperror.NewNotFoundError("Author not found")
+		}
+	}
+	return results, nil
+}
+
+func (s *service) Export(ctx context.Context, fn func(*Author) error) error {
+	if err := s.repo.Stream(ctx, fn); err != nil {
+		return fmt.Errorf("failed to export authors: %w", err)
+	}
+	return nil
+}
+
+// Import validates every row and inserts valid ones in chunks of maxBatchSize.
+// Each chunk is atomic, chunks inserted before a failure are kept.
+func (s *service) Import(ctx context.Context, src ImportSource) (*exchange.ImportResult, error) {
+	result := &exchange.ImportResult{}
+	pending := make([]*Author, 0, s.maxBatchSize)
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		created, err := s.repo.CreateBatch(ctx, pending)
+		if err != nil {
+			return fmt.Errorf("failed to import authors: %w", err)
+		}
+		result.Imported += len(created)
+		pending = pending[:0]
+		return nil
+	}
+
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read authors: %w", err)
+		}
+		if row.Err != nil {
+			result.AddError(row.Line, row.Err)
+			continue
+		}
+		author, err := row.Value.ToAuthor()
+		if err != nil {
+			result.AddError(row.Line, err)
+			continue
+		}
+		pending = append(pending, author)
+		if len(pending) == s.maxBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *service) validateBatchSize(size int) error {
+	if size == 0 {
+		return apperror.NewValidationError(
+			"Batch is empty",
+			map[string]string{"field": "items", "min": "1", "value": "0"},
+		)
+	}
+	if size > s.maxBatchSize {
+		return apperror.NewValidationError(
+			"Batch too large",
+			map[string]string{
+				"field": "items",
+				"max":   strconv.Itoa(s.maxBatchSize),
+				"value": strconv.Itoa(size),
+			},
+		)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package authors_test
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository is an in-memory authors.Repository.
+type fakeRepository struct {
+	authors map[int64]*authors.Author
+	nextID  int64
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{authors: map[int64]*authors.Author{}}
+}
+
+func (f *fakeRepository) Create(_ context.Context, author *authors.Author) (*authors.Author, error) {
+	f.nextID++
+	created := &authors.Author{ID: f.nextID, Name: author.Name, Bio: author.Bio}
+	f.authors[created.ID] = created
+	return created, nil
+}
+
+func (f *fakeRepository) GetByID(_ context.Context, id int64) (*authors.Author, error) {
+	author, ok := f.authors[id]
+	if !ok {
+		return nil, apperror.NewNotFoundError("Author not found")
+	}
+	return author, nil
+}
+
+func (f *fakeRepository) GetByIDs(_ context.Context, ids []int64) ([]*authors.Author, error) {
+	var found []*authors.Author
+	for _, id := range ids {
+		if author, ok := f.authors[id]; ok {
+			found = append(found, author)
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeRepository) List(_ context.Context) ([]*authors.Author, error) {
+	list := make([]*authors.Author, 0, len(f.authors))
+	for _, author := range f.authors {
+		list = append(list, author)
+	}
+	return list, nil
+}
+
+func (f *fakeRepository) ListPage(_ context.Context, after pagination.Cursor, limit int) ([]*authors.Author, error) {
+	list := make([]*authors.Author, 0, len(f.authors))
+	for _, author := range f.authors {
+		if cmp.Or(strings.Compare(author.Name, after.Key), cmp.Compare(author.ID, after.ID)) > 0 {
+			list = append(list, author)
+		}
+	}
+	slices.SortFunc(list, func(a, b *authors.Author) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return list[:min(limit, len(list))], nil
+}
+
+func (f *fakeRepository) Update(_ context.Context, author *authors.Author) (*authors.Author, error) {
+	if _, ok := f.authors[author.ID]; !ok {
+		return nil, apperror.NewNotFoundError("Author not found")
+	}
+	updated := &authors.Author{ID: author.ID, Name: author.Name, Bio: author.Bio}
+	f.authors[author.ID] = updated
+	return updated, nil
+}
+
+func (f *fakeRepository) Delete(_ context.Context, id int64) error {
+	delete(f.authors, id)
+	return nil
+}
+
+func (f *fakeRepository) CreateBatch(ctx context.Context, list []*authors.Author) ([]*authors.Author, error) {
+	created := make([]*authors.Author, len(list))
+	for i, author := range list {
+		created[i], _ = f.Create(ctx, author)
+	}
+	return created, nil
+}
+
+func (f *fakeRepository) DeleteBatch(_ context.Context, ids []int64) ([]int64, error) {
+	deleted := []int64{}
+	for _, id := range ids {
+		if _, ok := f.authors[id]; ok {
+			delete(f.authors, id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+func (f *fakeRepository) Stream(_ context.Context, fn func(*authors.Author) error) error {
+	for id := int64(1); id <= f.nextID; id++ {
+		if author, ok := f.authors[id]; ok {
+			if err := fn(author); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func TestService_CreateBatch_IndexAlignedErrors(t *testing.T) {
+	svc := authors.NewService(newFakeRepository())
+	results, err := svc.CreateBatch(context.Background(), []authors.CreateAuthorRequest{
+		{Name: "ValidName", Bio: "bio"},
+		{Name: "abc", Bio: "bio"},
+		{Name: "OtherName", Bio: "bio"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "ValidName", results[0].Author.Name)
+	assert.True(t, apperror.IsValidationError(results[1].Err))
+	assert.Nil(t, results[1].Author)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, "OtherName", results[2].Author.Name)
+	assert.NotEqual(t, results[0].ID, results[2].ID)
+}
+
+func TestService_CreateBatch_TooLarge(t *testing.T) {
+	svc := authors.NewService(newFakeRepository(), authors.WithMaxBatchSize(1))
+	_, err := svc.CreateBatch(context.Background(), []authors.CreateAuthorRequest{
+		{Name: "ValidName"},
+		{Name: "OtherName"},
+	})
+	assert.True(t, apperror.IsValidationError(err))
+}
+
+func TestService_CreateBatch_Empty(t *testing.T) {
+	svc := authors.NewService(newFakeRepository())
+	_, err := svc.CreateBatch(context.Background(), nil)
+	assert.True(t, apperror.IsValidationError(err))
+}
+
+func TestService_DeleteBatch(t *testing.T) {
+	repo := newFakeRepository()
+	svc := authors.NewService(repo)
+	created, err := svc.Create(context.Background(), &authors.CreateAuthorRequest{Name: "ValidName"})
+	require.NoError(t, err)
+
+	results, err := svc.DeleteBatch(context.Background(), []int64{created.ID, 0, 42})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.True(t, apperror.IsValidationError(results[1].Err))
+	assert.True(t, apperror.IsNotFoundError(results[2].Err))
+	assert.Empty(t, repo.authors)
+}
+
+func TestService_Import(t *testing.T) {
+	repo := newFakeRepository()
+	svc := authors.NewService(repo, authors.WithMaxBatchSize(2))
+	input := `{"name":"ValidName","bio":"bio"}
+{"name":"abc"}
+not json
+{"name":"OtherName"}
+{"name":"ThirdName"}
+`
+	src := exchange.NewReader(strings.NewReader(input), exchange.FormatNDJSON,
+		exchange.CSVMapping[authors.CreateAuthorRequest]{})
+	result, err := svc.Import(context.Background(), src)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, result.Imported)
+	assert.Equal(t, 2, result.Failed)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 2, result.Errors[0].Line)
+	assert.Equal(t, 3, result.Errors[1].Line)
+	assert.Len(t, repo.authors, 3)
+
+	var names []string
+	err = svc.Export(context.Background(), func(a *authors.Author) error {
+		names = append(names, a.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ValidName", "OtherName", "ThirdName"}, names)
+}
+
+func TestService_ListPage(t *testing.T) {
+	ctx := context.Background()
+	svc := authors.NewService(newFakeRepository())
+	for _, name := range []string{"Edith Wharton", "Anne Bronte", "Charles Dickens", "Anne Bronte", "Bram Stoker"} {
+		_, err := svc.Create(ctx, &authors.CreateAuthorRequest{Name: name})
+		require.NoError(t, err)
+	}
+
+	var (
+		names []string
+		ids   []int64
+		pages int
+	)
+	page := pagination.Page{Limit: 2}
+	for {
+		list, next, err := svc.ListPage(ctx, page)
+		require.NoError(t, err)
+		pages++
+		for _, author := range list {
+			names = append(names, author.Name)
+			ids = append(ids, author.ID)
+		}
+		if next == nil {
+			break
+		}
+		page.After = *next
+	}
+
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"Anne Bronte", "Anne Bronte", "Bram Stoker", "Charles Dickens", "Edith Wharton"}, names)
+	assert.Equal(t, []int64{2, 4, 5, 3, 1}, ids, "equal names are ordered by id")
+}
+
+func TestService_Update(t *testing.T) {
+	ctx := context.Background()
+	svc := authors.NewService(newFakeRepository())
+	created, err := svc.Create(ctx, &authors.CreateAuthorRequest{Name: "Mary Shelley"})
+	require.NoError(t, err)
+
+	updated, err := svc.Update(ctx, created.ID, &authors.CreateAuthorRequest{Name: " Mary W. Shelley ", Bio: "Frankenstein"})
+	require.NoError(t, err)
+	assert.Equal(t, &authors.Author{ID: created.ID, Name: "Mary W. Shelley", Bio: "Frankenstein"}, updated)
+
+	_, err = svc.Update(ctx, created.ID, &authors.CreateAuthorRequest{Name: "Mary"})
+	assert.True(t, apperror.IsValidationError(err))
+	_, err = svc.Update(ctx, 0, &authors.CreateAuthorRequest{Name: "Mary Shelley"})
+	assert.True(t, apperror.IsValidationError(err))
+	_, err = svc.Update(ctx, 42, &authors.CreateAuthorRequest{Name: "Mary Shelley"})
+	assert.True(t, apperror.IsNotFoundError(err))
+}
@@ -0,0 +1,19 @@
+package books
+
+import (
+	"strconv"
+
+	"github.com/sgaunet/template-api/pkg/audit"
+)
+
+func createdChange(b *Book) audit.Change {
+	return audit.Created(audit.EntityBook, strconv.FormatInt(b.ID, 10), b.ToResponse())
+}
+
+func updatedChange(before, after *Book) audit.Change {
+	return audit.Updated(audit.EntityBook, strconv.FormatInt(after.ID, 10), before.ToResponse(), after.ToResponse())
+}
+
+func deletedChange(b *Book) audit.Change {
+	return audit.Deleted(audit.EntityBook, strconv.FormatInt(b.ID, 10), b.ToResponse())
+}
@@ -0,0 +1,2 @@
+// Package books provides the books domain logic.
+package books
@@ -0,0 +1,128 @@
+package books
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+)
+
+// Book represents a domain book with business logic.
+type Book struct {
+	ID       int64
+	Title    string
+	AuthorID int64
+}
+
+// BookTitle value object with validation.
+type BookTitle string
+
+// Title length constraints for validation.
+const (
+	MinTitleLength = 1
+	MaxTitleLength = 32
+)
+
+// NewBookTitle creates and validates a book title.
+func NewBookTitle(title string) (BookTitle, error) {
+	trimmed := strings.TrimSpace(title)
+
+	if len(trimmed) < MinTitleLength {
+		return "", apperror.NewValidationError(
+			"Book title too short",
+			map[string]string{
+				"field": "title",
+				"min":   strconv.Itoa(MinTitleLength),
+				"value": strconv.Itoa(len(trimmed)),
+			},
+		)
+	}
+
+	if len(trimmed) > MaxTitleLength {
+		return "", apperror.NewValidationError(
+			"Book title too long",
+			map[string]string{
+				"field": "title",
+				"max":   strconv.Itoa(MaxTitleLength),
+				"value": strconv.Itoa(len(trimmed)),
+			},
+		)
+	}
+
+	return BookTitle(trimmed), nil
+}
+
+func (t BookTitle) String() string {
+	return string(t)
+}
+
+// NewBook creates a new book.
+func NewBook(title BookTitle, authorID int64) *Book {
+	return &Book{
+		Title:    title.String(),
+		AuthorID: authorID,
+	}
+}
+
+// CreateBookRequest is the request to create a book.
+type CreateBookRequest struct {
+	Title    string `json:"title"`
+	AuthorID int64  `json:"author_id"`
+}
+
+// ToBook converts request to domain book.
+func (r *CreateBookRequest) ToBook() (*Book, error) {
+	title, err := NewBookTitle(r.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.AuthorID <= 0 {
+		return nil, apperror.NewValidationError(
+			"Invalid author ID",
+			map[string]string{"field": "author_id", "value": strconv.FormatInt(r.AuthorID, 10)},
+		)
+	}
+
+	return NewBook(title, r.AuthorID), nil
+}
+
+// BookResponse is the response format.
+type BookResponse struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	AuthorID int64  `json:"author_id"`
+}
+
+// ToResponse converts domain book to response.
+func (b *Book) ToResponse() *BookResponse {
+	return &BookResponse{
+		ID:       b.ID,
+		Title:    b.Title,
+		AuthorID: b.AuthorID,
+	}
+}
+
+// exportMapping maps exported books to CSV columns.
+var exportMapping = exchange.CSVMapping[*BookResponse]{
+	Header: []string{"id", "title", "author_id"},
+	Marshal: func(b *BookResponse) []string {
+		return []string{strconv.FormatInt(b.ID, 10), b.Title, strconv.FormatInt(b.AuthorID, 10)}
+	},
+}
+
+// importMapping maps CSV columns to book creation requests.
+var importMapping = exchange.CSVMapping[CreateBookRequest]{
+	Header: []string{"title", "author_id"},
+	Unmarshal: func(fields []string) (CreateBookRequest, error) {
+		authorID, err := strconv.ParseInt(strings.TrimSpace(fields[1]), 10, 64)
+		if err != nil {
+			return CreateBookRequest{}, apperror.NewValidationError(
+				"Invalid author ID",
+				map[string]string{"field": "author_id", "value": fields[1]},
+			)
+		}
+		return CreateBookRequest{Title: fields[0], AuthorID: authorID}, nil
+	},
+}
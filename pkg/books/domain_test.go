@@ -0,0 +1,51 @@
+package books_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBookTitle_Valid(t *testing.T) {
+	title, err := books.NewBookTitle("  Dune  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", title.String())
+}
+
+func TestNewBookTitle_Empty(t *testing.T) {
+	_, err := books.NewBookTitle("   ")
+	assert.Error(t, err)
+	assert.True(t, apperror.IsValidationError(err))
+}
+
+func TestNewBookTitle_TooLong(t *testing.T) {
+	_, err := books.NewBookTitle(strings.Repeat("a", books.MaxTitleLength+1))
+	assert.Error(t, err)
+	assert.True(t, apperror.IsValidationError(err))
+}
+
+func TestCreateBookRequest_ToBook(t *testing.T) {
+	req := books.CreateBookRequest{Title: "Dune", AuthorID: 1}
+	book, err := req.ToBook()
+	assert.NoError(t, err)
+	assert.Equal(t, "Dune", book.Title)
+	assert.Equal(t, int64(1), book.AuthorID)
+}
+
+func TestCreateBookRequest_ToBook_InvalidAuthor(t *testing.T) {
+	req := books.CreateBookRequest{Title: "Dune"}
+	_, err := req.ToBook()
+	assert.Error(t, err)
+	assert.True(t, apperror.IsValidationError(err))
+}
+
+func TestBook_ToResponse(t *testing.T) {
+	book := &books.Book{ID: 3, Title: "Dune", AuthorID: 1}
+	response := book.ToResponse()
+	assert.Equal(t, int64(3), response.ID)
+	assert.Equal(t, "Dune", response.Title)
+	assert.Equal(t, int64(1), response.AuthorID)
+}
@@ -0,0 +1,49 @@
+package books
+
+import (
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/outbox"
+)
+
+// Domain event types published for books.
+const (
+	EventBookCreated = "BookCreated"
+	EventBookUpdated = "BookUpdated"
+	EventBookDeleted = "BookDeleted"
+)
+
+// aggregateType is the aggregate type of book events.
+const aggregateType = "book"
+
+// DeletedEvent is the payload of BookDeleted events.
+type DeletedEvent struct {
+	ID int64 `json:"id"`
+}
+
+func createdEvent(b *Book) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(b.ID, 10),
+		Type:          EventBookCreated,
+		Data:          b.ToResponse(),
+	}
+}
+
+func updatedEvent(b *Book) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(b.ID, 10),
+		Type:          EventBookUpdated,
+		Data:          b.ToResponse(),
+	}
+}
+
+func deletedEvent(id int64) outbox.Event {
+	return outbox.Event{
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatInt(id, 10),
+		Type:          EventBookDeleted,
+		Data:          DeletedEvent{ID: id},
+	}
+}
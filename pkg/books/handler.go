@@ -0,0 +1,199 @@
+package books
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/pagination"
+)
+
+// Handler handles HTTP requests for books.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new book handler.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create handles POST /books.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateBookRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	book, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(book.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// List handles GET /books, paginated with the limit and cursor query
+// parameters. Without them, all the books are returned.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	page, paginated, err := pagination.FromRequest(r)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	var (
+		books []*Book
+		next  *pagination.Cursor
+	)
+	if paginated {
+		books, next, err = h.service.ListPage(r.Context(), page)
+	} else {
+		books, err = h.service.List(r.Context())
+	}
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	responses := make([]*BookResponse, len(books))
+	for i, book := range books {
+		responses[i] = book.ToResponse()
+	}
+
+	pagination.SetNextLink(w, r, page, next)
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Get handles GET /books/{id}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(book.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Update handles PUT /books/{id}.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateBookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	book, err := h.service.Update(r.Context(), id, &req)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(book.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Delete handles DELETE /books/{id}.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := bookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Export handles GET /books/export.
+// The format (CSV or NDJSON) is negotiated with the Accept header.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	format := exchange.NegotiateFormat(r.Header.Get("Accept"))
+	writer := exchange.NewWriter(w, format, exportMapping)
+	w.Header().Set("Content-Type", format.ContentType())
+
+	written := false
+	err := h.service.Export(r.Context(), func(book *Book) error {
+		written = true
+		return writer.Write(book.ToResponse())
+	})
+	if err != nil {
+		if !written {
+			apperror.WriteError(w, err)
+		}
+		// Otherwise response already started, can't send error response
+		return
+	}
+	_ = writer.Flush()
+}
+
+// Import handles POST /books/import.
+// The format (CSV or NDJSON) is given by the Content-Type header.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	defer func() { _ = r.Body.Close() }()
+
+	format, err := exchange.FormatFromContentType(r.Header.Get("Content-Type"))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	result, err := h.service.Import(r.Context(), exchange.NewReader(r.Body, format, importMapping))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(result.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// bookID parses the {id} URL parameter, writing an error if it's invalid.
+func bookID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid book ID"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,231 @@
+package books
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/database"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/internal/repository"
+	"github.com/sgaunet/template-api/pkg/audit"
+)
+
+var errStreamingUnsupported = errors.New("queries don't support streaming")
+
+// Repository defines the interface for book data access.
+type Repository interface {
+	Create(ctx context.Context, book *Book) (*Book, error)
+	GetByID(ctx context.Context, id int64) (*Book, error)
+	List(ctx context.Context) ([]*Book, error)
+	// ListPage returns up to limit books following after, in the order of List.
+	ListPage(ctx context.Context, after pagination.Cursor, limit int) ([]*Book, error)
+	ListByAuthors(ctx context.Context, authorIDs []int64) ([]*Book, error)
+	Update(ctx context.Context, book *Book) (*Book, error)
+	Delete(ctx context.Context, id int64) error
+	Stream(ctx context.Context, fn func(*Book) error) error
+}
+
+// repositoryImpl wraps sqlc-generated queries.
+type repositoryImpl struct {
+	queries repository.Querier
+	tx      repository.Transactor
+}
+
+// RepositoryOption configures the book repository.
+type RepositoryOption func(*repositoryImpl)
+
+// WithOutbox publishes domain events: mutations run in a transaction of tx
+// which also records their events in the outbox. Mutations are recorded in
+// the audit log with or without it, in the transaction when there is one.
+func WithOutbox(tx repository.Transactor) RepositoryOption {
+	return func(r *repositoryImpl) {
+		r.tx = tx
+	}
+}
+
+// NewRepository creates a new book repository.
+func NewRepository(queries repository.Querier, opts ...RepositoryOption) Repository {
+	r := &repositoryImpl{queries: queries}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *repositoryImpl) Create(ctx context.Context, book *Book) (*Book, error) {
+	var created *Book
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		dbBook, err := q.CreateBook(ctx, repository.CreateBookParams{
+			Title:    book.Title,
+			AuthorID: book.AuthorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = &Book{
+			ID:       dbBook.ID,
+			Title:    dbBook.Title,
+			AuthorID: dbBook.AuthorID,
+		}
+		if err := audit.Insert(ctx, q, createdChange(created)); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{createdEvent(created)}, nil
+	})
+	if err != nil {
+		return nil, writeError(err, book)
+	}
+
+	return created, nil
+}
+
+func (r *repositoryImpl) GetByID(ctx context.Context, id int64) (*Book, error) {
+	dbBook, err := r.queries.GetBook(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.NewNotFoundError("Book not found")
+		}
+		return nil, apperror.NewInternalError(err)
+	}
+	return toBooks([]repository.Book{dbBook})[0], nil
+}
+
+func (r *repositoryImpl) List(ctx context.Context) ([]*Book, error) {
+	dbBooks, err := r.queries.ListBooks(ctx)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return toBooks(dbBooks), nil
+}
+
+func (r *repositoryImpl) ListPage(ctx context.Context, after pagination.Cursor, limit int) ([]*Book, error) {
+	dbBooks, err := r.queries.ListBooksPage(ctx, repository.ListBooksPageParams{
+		AfterTitle: after.Key,
+		AfterID:    after.ID,
+		MaxRows:    int32(limit), //nolint:gosec // bounded by pagination.MaxLimit
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return toBooks(dbBooks), nil
+}
+
+func (r *repositoryImpl) ListByAuthors(ctx context.Context, authorIDs []int64) ([]*Book, error) {
+	dbBooks, err := r.queries.ListBooksByAuthorIDs(ctx, authorIDs)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return toBooks(dbBooks), nil
+}
+
+func (r *repositoryImpl) Update(ctx context.Context, book *Book) (*Book, error) {
+	var updated *Book
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		locked, err := q.LockBook(ctx, book.ID)
+		if err != nil {
+			return nil, err
+		}
+		dbBook, err := q.UpdateBook(ctx, repository.UpdateBookParams{
+			ID:       book.ID,
+			Title:    book.Title,
+			AuthorID: book.AuthorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		updated = toBooks([]repository.Book{dbBook})[0]
+		before := toBooks([]repository.Book{locked})[0]
+		if err := audit.Insert(ctx, q, updatedChange(before, updated)); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{updatedEvent(updated)}, nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.NewNotFoundError("Book not found")
+		}
+		return nil, writeError(err, book)
+	}
+
+	return updated, nil
+}
+
+func (r *repositoryImpl) Delete(ctx context.Context, id int64) error {
+	err := outbox.Record(ctx, r.tx, r.queries, func(q repository.Querier) ([]outbox.Event, error) {
+		// The book is locked to record it in the audit log as deleted.
+		locked, err := q.LockBook(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		deleted, err := q.DeleteBook(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if deleted == 0 {
+			return nil, sql.ErrNoRows
+		}
+		if err := audit.Insert(ctx, q, deletedChange(toBooks([]repository.Book{locked})[0])); err != nil {
+			return nil, err
+		}
+		return []outbox.Event{deletedEvent(id)}, nil
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperror.NewNotFoundError("Book not found")
+		}
+		return apperror.NewInternalError(err)
+	}
+	return nil
+}
+
+func (r *repositoryImpl) Stream(ctx context.Context, fn func(*Book) error) error {
+	streamer, ok := r.queries.(repository.Streamer)
+	if !ok {
+		return apperror.NewInternalError(errStreamingUnsupported)
+	}
+
+	var fnErr error
+	err := streamer.StreamBooks(ctx, func(dbBook repository.Book) error {
+		fnErr = fn(&Book{
+			ID:       dbBook.ID,
+			Title:    dbBook.Title,
+			AuthorID: dbBook.AuthorID,
+		})
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	return nil
+}
+
+func toBooks(dbBooks []repository.Book) []*Book {
+	books := make([]*Book, len(dbBooks))
+	for i, dbBook := range dbBooks {
+		books[i] = &Book{
+			ID:       dbBook.ID,
+			Title:    dbBook.Title,
+			AuthorID: dbBook.AuthorID,
+		}
+	}
+	return books
+}
+
+// writeError converts the error of an insert or update of book, reporting
+// unknown authors as validation errors.
+func writeError(err error, book *Book) error {
+	if database.PgErrorCode(err) == database.CodeForeignKeyViolation {
+		return apperror.NewValidationError(
+			"Author not found",
+			map[string]string{"field": "author_id", "value": strconv.FormatInt(book.AuthorID, 10)},
+		)
+	}
+	return apperror.NewInternalError(err)
+}
@@ -0,0 +1,175 @@
+package books
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/pagination"
+)
+
+// Service provides book business logic.
+type Service interface {
+	Create(ctx context.Context, req *CreateBookRequest) (*Book, error)
+	GetByID(ctx context.Context, id int64) (*Book, error)
+	List(ctx context.Context) ([]*Book, error)
+	// ListPage returns a page of books and the cursor of the next page,
+	// nil on the last page.
+	ListPage(ctx context.Context, page pagination.Page) ([]*Book, *pagination.Cursor, error)
+	// ListByAuthors returns the books of the authors, sorted by author and title.
+	ListByAuthors(ctx context.Context, authorIDs []int64) ([]*Book, error)
+	// Update replaces the title and author of the book id with those of req.
+	Update(ctx context.Context, id int64, req *CreateBookRequest) (*Book, error)
+	Delete(ctx context.Context, id int64) error
+	Export(ctx context.Context, fn func(*Book) error) error
+	Import(ctx context.Context, src ImportSource) (*exchange.ImportResult, error)
+}
+
+// ImportSource yields the rows of an import.
+type ImportSource interface {
+	Next() (exchange.Row[CreateBookRequest], error)
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService creates a new book service.
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) Create(ctx context.Context, req *CreateBookRequest) (*Book, error) {
+	// Convert to domain model
+	book, err := req.ToBook()
+	if err != nil {
+		return nil, err
+	}
+
+	// Persist
+	created, err := s.repo.Create(ctx, book)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create book: %w", err)
+	}
+	return created, nil
+}
+
+func (s *service) GetByID(ctx context.Context, id int64) (*Book, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	book, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get book: %w", err)
+	}
+	return book, nil
+}
+
+func (s *service) List(ctx context.Context) ([]*Book, error) {
+	books, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	return books, nil
+}
+
+func (s *service) ListPage(ctx context.Context, page pagination.Page) ([]*Book, *pagination.Cursor, error) {
+	// One more book is read to know whether another page follows.
+	books, err := s.repo.ListPage(ctx, page.After, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	books, next := pagination.Trim(books, page, func(b *Book) pagination.Cursor {
+		return pagination.Cursor{Key: b.Title, ID: b.ID}
+	})
+	return books, next, nil
+}
+
+func (s *service) ListByAuthors(ctx context.Context, authorIDs []int64) ([]*Book, error) {
+	if len(authorIDs) == 0 {
+		return []*Book{}, nil
+	}
+	books, err := s.repo.ListByAuthors(ctx, authorIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list books: %w", err)
+	}
+	return books, nil
+}
+
+func (s *service) Update(ctx context.Context, id int64, req *CreateBookRequest) (*Book, error) {
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	book, err := req.ToBook()
+	if err != nil {
+		return nil, err
+	}
+	book.ID = id
+
+	updated, err := s.repo.Update(ctx, book)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update book: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *service) Delete(ctx context.Context, id int64) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete book: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Export(ctx context.Context, fn func(*Book) error) error {
+	if err := s.repo.Stream(ctx, fn); err != nil {
+		return fmt.Errorf("failed to export books: %w", err)
+	}
+	return nil
+}
+
+// Import inserts books one by one so that rows referencing an unknown author
+// are reported as line errors instead of failing the whole import.
+func (s *service) Import(ctx context.Context, src ImportSource) (*exchange.ImportResult, error) {
+	result := &exchange.ImportResult{}
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read books: %w", err)
+		}
+		if row.Err != nil {
+			result.AddError(row.Line, row.Err)
+			continue
+		}
+		if _, err := s.Create(ctx, &row.Value); err != nil {
+			if !apperror.IsValidationError(err) {
+				return nil, err
+			}
+			result.AddError(row.Line, err)
+			continue
+		}
+		result.Imported++
+	}
+}
+
+func validateID(id int64) error {
+	if id <= 0 {
+		return apperror.NewValidationError(
+			"Invalid book ID",
+			map[string]string{"field": "id", "value": strconv.FormatInt(id, 10)},
+		)
+	}
+	return nil
+}
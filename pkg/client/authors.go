@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Author is an author of the catalog.
+type Author struct {
+	ID   int64  `json:"id"   yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	Bio  string `json:"bio"  yaml:"bio"`
+}
+
+// AuthorInput is the content of an author to create or update.
+type AuthorInput struct {
+	Name string `json:"name" yaml:"name"`
+	Bio  string `json:"bio"  yaml:"bio"`
+}
+
+// CreateAuthor creates an author.
+func (c *Client) CreateAuthor(ctx context.Context, input AuthorInput) (*Author, error) {
+	var author Author
+	if _, err := c.do(ctx, http.MethodPost, c.endpoint("authors"), input, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// GetAuthor returns the author id.
+func (c *Client) GetAuthor(ctx context.Context, id int64) (*Author, error) {
+	var author Author
+	if _, err := c.do(ctx, http.MethodGet, c.authorURL(id), nil, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// ListAuthors iterates over the authors sorted by name, fetching them page
+// by page. The iteration stops at the first error.
+func (c *Client) ListAuthors(ctx context.Context) iter.Seq2[*Author, error] {
+	return list[*Author](ctx, c, c.endpoint("authors"))
+}
+
+// UpdateAuthor replaces the name and bio of the author id.
+func (c *Client) UpdateAuthor(ctx context.Context, id int64, input AuthorInput) (*Author, error) {
+	var author Author
+	if _, err := c.do(ctx, http.MethodPut, c.authorURL(id), input, &author); err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// DeleteAuthor deletes the author id.
+func (c *Client) DeleteAuthor(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, c.authorURL(id), nil, nil)
+	return err
+}
+
+func (c *Client) authorURL(id int64) *url.URL {
+	return c.endpoint("authors", strconv.FormatInt(id, 10))
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Book is a book of the catalog.
+type Book struct {
+	ID       int64  `json:"id"        yaml:"id"`
+	Title    string `json:"title"     yaml:"title"`
+	AuthorID int64  `json:"author_id" yaml:"author_id"`
+}
+
+// BookInput is the content of a book to create or update.
+type BookInput struct {
+	Title    string `json:"title"     yaml:"title"`
+	AuthorID int64  `json:"author_id" yaml:"author_id"`
+}
+
+// CreateBook creates a book.
+func (c *Client) CreateBook(ctx context.Context, input BookInput) (*Book, error) {
+	var book Book
+	if _, err := c.do(ctx, http.MethodPost, c.endpoint("books"), input, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// GetBook returns the book id.
+func (c *Client) GetBook(ctx context.Context, id int64) (*Book, error) {
+	var book Book
+	if _, err := c.do(ctx, http.MethodGet, c.bookURL(id), nil, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// ListBooks iterates over the books sorted by title, fetching them page by
+// page. The iteration stops at the first error.
+func (c *Client) ListBooks(ctx context.Context) iter.Seq2[*Book, error] {
+	return list[*Book](ctx, c, c.endpoint("books"))
+}
+
+// UpdateBook replaces the title and author of the book id.
+func (c *Client) UpdateBook(ctx context.Context, id int64, input BookInput) (*Book, error) {
+	var book Book
+	if _, err := c.do(ctx, http.MethodPut, c.bookURL(id), input, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// DeleteBook deletes the book id.
+func (c *Client) DeleteBook(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodDelete, c.bookURL(id), nil, nil)
+	return err
+}
+
+func (c *Client) bookURL(id int64) *url.URL {
+	return c.endpoint("books", strconv.FormatInt(id, 10))
+}
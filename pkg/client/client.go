@@ -0,0 +1,259 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/backoff"
+)
+
+// Defaults of the client options.
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+	DefaultPageSize       = 100
+	DefaultTimeout        = 30 * time.Second
+)
+
+const contentTypeJSON = "application/json"
+
+var errInvalidBaseURL = errors.New("base URL must be absolute")
+
+// Client calls the API at a base URL. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	header     http.Header
+	maxRetries int
+	backoff    backoff.Exponential
+	pageSize   int
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the requests, e.g. to
+// configure TLS. The default client has a timeout of DefaultTimeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithHeader adds a header to every request, e.g. credentials.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// WithRetries sets the maximum number of retries of a request, 0 disables
+// retries. Negative values are ignored.
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+	}
+}
+
+// WithBackoff sets the bounds of the exponential backoff between retries.
+// Values lower or equal to zero are ignored.
+func WithBackoff(initial, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		if initial > 0 {
+			c.backoff.Initial = initial
+		}
+		if maxDelay > 0 {
+			c.backoff.Max = maxDelay
+		}
+	}
+}
+
+// WithPageSize sets the number of items requested per page by the list
+// iterators. Values lower or equal to zero are ignored.
+func WithPageSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.pageSize = size
+		}
+	}
+}
+
+// New creates a client of the API at baseURL, e.g. "http://localhost:3000".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return nil, errInvalidBaseURL
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		header:     http.Header{},
+		maxRetries: DefaultMaxRetries,
+		backoff:    backoff.New(DefaultInitialBackoff, DefaultMaxBackoff),
+		pageSize:   DefaultPageSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// endpoint returns the URL of the path segments.
+func (c *Client) endpoint(segments ...string) *url.URL {
+	return c.baseURL.JoinPath(segments...)
+}
+
+// do sends a request with the JSON encoding of in, retrying it when
+// possible, and decodes the response into out. in and out may be nil.
+// It returns the response headers.
+func (c *Client) do(ctx context.Context, method string, u *url.URL, in, out any) (http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, fmt.Errorf("could not encode request: %w", err)
+		}
+	}
+
+	resp, err := c.roundTrip(ctx, method, u, body, contentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, decode(resp, out)
+}
+
+// roundTrip sends a request until its response can't be retried, and
+// returns the last response.
+func (c *Client) roundTrip(ctx context.Context, method string, u *url.URL, body []byte, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		var contentType string
+		if body != nil {
+			contentType = contentTypeJSON
+		}
+		resp, err := c.send(ctx, method, u, bytes.NewReader(body), contentType, accept)
+		if err != nil {
+			if attempt < c.maxRetries && isIdempotent(method) && ctx.Err() == nil {
+				if err := c.wait(ctx, attempt, nil); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+
+		if attempt < c.maxRetries && isRetryable(method, resp.StatusCode) {
+			drain(resp)
+			if err := c.wait(ctx, attempt, resp); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// send sends a single request. contentType is ignored without body.
+func (c *Client) send(
+	ctx context.Context, method string, u *url.URL, body io.Reader, contentType, accept string,
+) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", accept)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, u.Redacted(), err)
+	}
+	return resp, nil
+}
+
+// wait sleeps before retrying an attempt, as long as asked by the
+// Retry-After header of resp if any.
+func (c *Client) wait(ctx context.Context, attempt int, resp *http.Response) error {
+	delay := c.backoff.Delay(attempt)
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck
+	case <-timer.C:
+		return nil
+	}
+}
+
+// decode reads the body of resp into out, or the error it reports.
+func decode(resp *http.Response, out any) error {
+	defer drain(resp)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+	return nil
+}
+
+// drain reads the rest of the body so that the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorSize))
+	_ = resp.Body.Close()
+}
+
+// isIdempotent reports whether a request can be sent again without
+// changing its effect.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether a request which failed with status can be
+// retried: 429 and 503 mean the request wasn't processed, other server
+// errors may have happened after a change was made.
+func isRetryable(method string, status int) bool {
+	switch {
+	case status == http.StatusTooManyRequests, status == http.StatusServiceUnavailable:
+		return true
+	case status >= http.StatusInternalServerError:
+		return isIdempotent(method)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,440 @@
+package client_test
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/client"
+	"github.com/sgaunet/template-api/pkg/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// store is an in-memory authors.Repository and books.Repository.
+type store[T any] struct {
+	mu     sync.Mutex
+	items  map[int64]T
+	nextID int64
+	id     func(T) int64
+	key    func(T) string
+	setID  func(T, int64) T
+	name   string
+}
+
+func (s *store[T]) create(item T) T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	item = s.setID(item, s.nextID)
+	s.items[s.nextID] = item
+	return item
+}
+
+func (s *store[T]) get(id int64) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[id]
+	if !ok {
+		return item, apperror.NewNotFoundError(s.name + " not found")
+	}
+	return item, nil
+}
+
+func (s *store[T]) page(after pagination.Cursor, limit int) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []T
+	for _, item := range s.items {
+		if cmp.Or(strings.Compare(s.key(item), after.Key), cmp.Compare(s.id(item), after.ID)) > 0 {
+			list = append(list, item)
+		}
+	}
+	slices.SortFunc(list, func(a, b T) int {
+		return cmp.Or(strings.Compare(s.key(a), s.key(b)), cmp.Compare(s.id(a), s.id(b)))
+	})
+	return list[:min(limit, len(list))]
+}
+
+func (s *store[T]) stream(fn func(T) error) error {
+	for _, item := range s.page(pagination.Cursor{}, len(s.items)) {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *store[T]) update(item T) (T, error) {
+	if _, err := s.get(s.id(item)); err != nil {
+		return item, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[s.id(item)] = item
+	return item, nil
+}
+
+func (s *store[T]) delete(id int64) error {
+	if _, err := s.get(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, id)
+	return nil
+}
+
+type authorsRepository struct {
+	authors.Repository
+
+	store *store[*authors.Author]
+}
+
+func (r *authorsRepository) Create(_ context.Context, a *authors.Author) (*authors.Author, error) {
+	return r.store.create(a), nil
+}
+
+func (r *authorsRepository) CreateBatch(_ context.Context, list []*authors.Author) ([]*authors.Author, error) {
+	created := make([]*authors.Author, len(list))
+	for i, a := range list {
+		created[i] = r.store.create(a)
+	}
+	return created, nil
+}
+
+func (r *authorsRepository) Stream(_ context.Context, fn func(*authors.Author) error) error {
+	return r.store.stream(fn)
+}
+
+func (r *authorsRepository) GetByID(_ context.Context, id int64) (*authors.Author, error) {
+	return r.store.get(id)
+}
+
+func (r *authorsRepository) ListPage(_ context.Context, after pagination.Cursor, limit int) ([]*authors.Author, error) {
+	return r.store.page(after, limit), nil
+}
+
+func (r *authorsRepository) Update(_ context.Context, a *authors.Author) (*authors.Author, error) {
+	return r.store.update(a)
+}
+
+func (r *authorsRepository) Delete(_ context.Context, id int64) error {
+	return r.store.delete(id)
+}
+
+type booksRepository struct {
+	books.Repository
+
+	store *store[*books.Book]
+}
+
+func (r *booksRepository) Create(_ context.Context, b *books.Book) (*books.Book, error) {
+	return r.store.create(b), nil
+}
+
+func (r *booksRepository) Stream(_ context.Context, fn func(*books.Book) error) error {
+	return r.store.stream(fn)
+}
+
+func (r *booksRepository) GetByID(_ context.Context, id int64) (*books.Book, error) {
+	return r.store.get(id)
+}
+
+func (r *booksRepository) ListPage(_ context.Context, after pagination.Cursor, limit int) ([]*books.Book, error) {
+	return r.store.page(after, limit), nil
+}
+
+func (r *booksRepository) Update(_ context.Context, b *books.Book) (*books.Book, error) {
+	return r.store.update(b)
+}
+
+func (r *booksRepository) Delete(_ context.Context, id int64) error {
+	return r.store.delete(id)
+}
+
+// newServer serves the real routes, with response validation, in front of
+// in-memory repositories. wrap may intercept the requests.
+func newServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+	authorsRepo := &authorsRepository{store: &store[*authors.Author]{
+		items: map[int64]*authors.Author{},
+		id:    func(a *authors.Author) int64 { return a.ID },
+		key:   func(a *authors.Author) string { return a.Name },
+		setID: func(a *authors.Author, id int64) *authors.Author { a.ID = id; return a },
+		name:  "Author",
+	}}
+	booksRepo := &booksRepository{store: &store[*books.Book]{
+		items: map[int64]*books.Book{},
+		id:    func(b *books.Book) int64 { return b.ID },
+		key:   func(b *books.Book) string { return b.Title },
+		setID: func(b *books.Book, id int64) *books.Book { b.ID = id; return b },
+		name:  "Book",
+	}}
+
+	w, err := webserver.NewWebServer(
+		authors.NewHandler(authors.NewService(authorsRepo)),
+		books.NewHandler(books.NewService(booksRepo)),
+		nil, nil, nil, nil,
+		webserver.WithResponseValidation(),
+	)
+	require.NoError(t, err)
+	handler := w.Handler()
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, srv *httptest.Server, opts ...client.Option) *client.Client {
+	t.Helper()
+	c, err := client.New(srv.URL, append([]client.Option{client.WithBackoff(time.Millisecond, time.Millisecond)}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func collect[T any](t *testing.T, seq func(func(T, error) bool)) []T {
+	t.Helper()
+	var items []T
+	for item, err := range seq {
+		require.NoError(t, err)
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestAuthors(t *testing.T) {
+	ctx := context.Background()
+	var gets atomic.Int32
+	srv := newServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				gets.Add(1)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	c := newClient(t, srv, client.WithPageSize(2))
+
+	created, err := c.CreateAuthor(ctx, client.AuthorInput{Name: "Mary Shelley", Bio: "Frankenstein"})
+	require.NoError(t, err)
+	assert.Equal(t, &client.Author{ID: 1, Name: "Mary Shelley", Bio: "Frankenstein"}, created)
+
+	author, err := c.GetAuthor(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, author)
+
+	author, err = c.UpdateAuthor(ctx, created.ID, client.AuthorInput{Name: "Mary W. Shelley"})
+	require.NoError(t, err)
+	assert.Equal(t, &client.Author{ID: 1, Name: "Mary W. Shelley"}, author)
+
+	for _, name := range []string{"Edith Wharton", "Anne Bronte", "Bram Stoker", "Jane Austen"} {
+		_, err := c.CreateAuthor(ctx, client.AuthorInput{Name: name})
+		require.NoError(t, err)
+	}
+	gets.Store(0)
+	var names []string
+	for _, author := range collect(t, c.ListAuthors(ctx)) {
+		names = append(names, author.Name)
+	}
+	assert.Equal(t, []string{"Anne Bronte", "Bram Stoker", "Edith Wharton", "Jane Austen", "Mary W. Shelley"}, names)
+	assert.Equal(t, int32(3), gets.Load(), "5 authors in pages of 2")
+
+	gets.Store(0)
+	for range c.ListAuthors(ctx) {
+		break
+	}
+	assert.Equal(t, int32(1), gets.Load(), "pages are fetched on demand")
+
+	require.NoError(t, c.DeleteAuthor(ctx, created.ID))
+	_, err = c.GetAuthor(ctx, created.ID)
+	require.ErrorIs(t, err, client.ErrNotFound)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "NOT_FOUND: Author not found", apiErr.Error())
+	assert.ErrorIs(t, c.DeleteAuthor(ctx, created.ID), client.ErrNotFound)
+}
+
+func TestAuthors_ValidationError(t *testing.T) {
+	c := newClient(t, newServer(t, nil))
+
+	_, err := c.CreateAuthor(context.Background(), client.AuthorInput{Name: "Ann"})
+	require.ErrorIs(t, err, client.ErrValidation)
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	// The server validates requests against its OpenAPI document.
+	assert.Contains(t, apiErr.Details, "/body/name")
+}
+
+func TestBooks(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newServer(t, nil), client.WithPageSize(1))
+
+	created, err := c.CreateBook(ctx, client.BookInput{Title: "Frankenstein", AuthorID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, &client.Book{ID: 1, Title: "Frankenstein", AuthorID: 1}, created)
+	_, err = c.CreateBook(ctx, client.BookInput{Title: "Dracula", AuthorID: 2})
+	require.NoError(t, err)
+
+	book, err := c.GetBook(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, book)
+
+	book, err = c.UpdateBook(ctx, created.ID, client.BookInput{Title: "The Last Man", AuthorID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "The Last Man", book.Title)
+	_, err = c.UpdateBook(ctx, created.ID, client.BookInput{Title: "The Last Man"})
+	assert.ErrorIs(t, err, client.ErrValidation)
+
+	var titles []string
+	for _, book := range collect(t, c.ListBooks(ctx)) {
+		titles = append(titles, book.Title)
+	}
+	assert.Equal(t, []string{"Dracula", "The Last Man"}, titles)
+
+	require.NoError(t, c.DeleteBook(ctx, created.ID))
+	_, err = c.GetBook(ctx, created.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestImportExport(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t, newServer(t, nil))
+
+	result, err := c.ImportAuthors(ctx, strings.NewReader("name,bio\nMary Shelley,Frankenstein\nAnn,\nBram Stoker,\n"), client.FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	assert.Equal(t, apperror.ErrCodeValidation, result.Errors[0].Error.Code)
+
+	result, err = c.ImportBooks(ctx, strings.NewReader(`{"title":"Dracula","author_id":2}`+"\n"), client.FormatNDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+
+	var out strings.Builder
+	require.NoError(t, c.ExportAuthors(ctx, &out, client.FormatCSV))
+	assert.Equal(t, "id,name,bio\n2,Bram Stoker,\n1,Mary Shelley,Frankenstein\n", out.String())
+
+	out.Reset()
+	require.NoError(t, c.ExportBooks(ctx, &out, client.FormatNDJSON))
+	assert.JSONEq(t, `{"id":1,"title":"Dracula","author_id":2}`, out.String())
+
+	_, err = c.ImportAuthors(ctx, strings.NewReader("{"), client.FormatNDJSON)
+	require.NoError(t, err, "invalid lines are reported in the result")
+}
+
+// failing fails the requests of method with status until failures reaches 0.
+func failing(method string, status int, failures *atomic.Int32, attempts *atomic.Int32) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != method {
+				next.ServeHTTP(w, r)
+				return
+			}
+			attempts.Add(1)
+			if failures.Add(-1) >= 0 {
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		method   string
+		status   int
+		failures int32
+		retries  int
+		attempts int32
+		err      error
+	}{
+		"unavailable":          {method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 2, retries: 3, attempts: 3},
+		"too many requests":    {method: http.MethodPost, status: http.StatusTooManyRequests, failures: 1, retries: 3, attempts: 2},
+		"idempotent error":     {method: http.MethodGet, status: http.StatusBadGateway, failures: 3, retries: 3, attempts: 4},
+		"retries exhausted":    {method: http.MethodGet, status: http.StatusInternalServerError, failures: 10, retries: 2, attempts: 3, err: client.ErrInternal},
+		"non idempotent error": {method: http.MethodPost, status: http.StatusInternalServerError, failures: 1, retries: 3, attempts: 1, err: client.ErrInternal},
+		"retries disabled":     {method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 1, retries: 0, attempts: 1, err: client.ErrInternal},
+		"client errors":        {method: http.MethodGet, status: http.StatusForbidden, failures: 1, retries: 3, attempts: 1, err: client.ErrForbidden},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var failures, attempts atomic.Int32
+			srv := newServer(t, failing(tc.method, tc.status, &failures, &attempts))
+			c := newClient(t, srv, client.WithRetries(tc.retries))
+			_, err := c.CreateAuthor(ctx, client.AuthorInput{Name: "Mary Shelley"})
+			require.NoError(t, err)
+
+			attempts.Store(0)
+			failures.Store(tc.failures)
+			if tc.method == http.MethodPost {
+				_, err = c.CreateAuthor(ctx, client.AuthorInput{Name: "Mary Shelley"})
+			} else {
+				_, err = c.GetAuthor(ctx, 1)
+			}
+			assert.Equal(t, tc.attempts, attempts.Load())
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRetries_ContextCanceled(t *testing.T) {
+	var failures, attempts atomic.Int32
+	failures.Store(10)
+	srv := newServer(t, failing(http.MethodGet, http.StatusServiceUnavailable, &failures, &attempts))
+	c := newClient(t, srv, client.WithBackoff(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.GetAuthor(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestList_Error(t *testing.T) {
+	var failures, attempts atomic.Int32
+	failures.Store(10)
+	srv := newServer(t, failing(http.MethodGet, http.StatusInternalServerError, &failures, &attempts))
+	c := newClient(t, srv, client.WithRetries(0))
+
+	var errs []error
+	for author, err := range c.ListAuthors(context.Background()) {
+		assert.Nil(t, author)
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], client.ErrInternal))
+}
+
+func TestNew(t *testing.T) {
+	for _, baseURL := range []string{"localhost:3000", "/authors", "http://%zz"} {
+		_, err := client.New(baseURL)
+		assert.Error(t, err, baseURL)
+	}
+}
@@ -0,0 +1,14 @@
+// Package client is a typed Go client of the template-api HTTP API.
+//
+// Errors returned by the API are decoded into *Error values, which match
+// the sentinel errors of their code with errors.Is:
+//
+//	author, err := c.GetAuthor(ctx, id)
+//	if errors.Is(err, client.ErrNotFound) {
+//		...
+//	}
+//
+// Requests failing with 429 or 503 are retried with exponential backoff,
+// as are idempotent requests (GET, PUT, DELETE) failing with other 5xx
+// statuses or transport errors.
+package client
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// ErrorCode is the code of an API error, e.g. "NOT_FOUND".
+type ErrorCode = apperror.ErrorCode
+
+// ErrorResponse is the body of API errors.
+type ErrorResponse = apperror.ErrorResponse
+
+// Sentinel errors matching the API errors of the corresponding code.
+var (
+	ErrValidation   = errors.New("validation error")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrInternal     = errors.New("internal error")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrBadRequest   = errors.New("bad request")
+	// ErrTooManyRequests is returned once the retries of rate limited requests are exhausted.
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// maxErrorSize bounds the error responses read.
+const maxErrorSize = 1 << 20
+
+var sentinels = map[ErrorCode]error{
+	apperror.ErrCodeValidation:      ErrValidation,
+	apperror.ErrCodeNotFound:        ErrNotFound,
+	apperror.ErrCodeConflict:        ErrConflict,
+	apperror.ErrCodeInternal:        ErrInternal,
+	apperror.ErrCodeUnauthorized:    ErrUnauthorized,
+	apperror.ErrCodeForbidden:       ErrForbidden,
+	apperror.ErrCodeBadRequest:      ErrBadRequest,
+	apperror.ErrCodeTooManyRequests: ErrTooManyRequests,
+}
+
+// Error is an error response of the API.
+type Error struct {
+	StatusCode int
+	Code       ErrorCode
+	Message    string
+	// Details describes validation errors, e.g. the invalid field.
+	Details map[string]string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the sentinel error of the code, so that errors.Is(err,
+// ErrNotFound) reports whether err is a NOT_FOUND error.
+func (e *Error) Unwrap() error {
+	return sentinels[e.Code]
+}
+
+// decodeError reads the error of resp. Responses which aren't an
+// apperror.ErrorResponse (e.g. from a proxy) get the code of their status.
+func decodeError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	var body ErrorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorSize))
+	if json.Unmarshal(data, &body) == nil && body.Code != "" {
+		e.Code = body.Code
+		e.Message = body.Message
+		e.Details = body.Details
+		return e
+	}
+	e.Code = codeFromStatus(resp.StatusCode)
+	e.Message = http.StatusText(resp.StatusCode)
+	return e
+}
+
+func codeFromStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return apperror.ErrCodeBadRequest
+	case http.StatusUnauthorized:
+		return apperror.ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return apperror.ErrCodeForbidden
+	case http.StatusNotFound:
+		return apperror.ErrCodeNotFound
+	case http.StatusConflict:
+		return apperror.ErrCodeConflict
+	case http.StatusTooManyRequests:
+		return apperror.ErrCodeTooManyRequests
+	default:
+		if status >= http.StatusInternalServerError {
+			return apperror.ErrCodeInternal
+		}
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sgaunet/template-api/internal/exchange"
+)
+
+// Format is the format of imports and exports.
+type Format string
+
+// Supported import and export formats.
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+)
+
+func (f Format) contentType() string {
+	if f == FormatCSV {
+		return exchange.ContentTypeCSV
+	}
+	return exchange.ContentTypeNDJSON
+}
+
+// ImportResult is the report of an import.
+type ImportResult struct {
+	Imported int         `json:"imported" yaml:"imported"`
+	Failed   int         `json:"failed"   yaml:"failed"`
+	Errors   []LineError `json:"errors"   yaml:"errors"`
+}
+
+// LineError is the error of an imported line.
+type LineError struct {
+	Line  int           `json:"line"  yaml:"line"`
+	Error ErrorResponse `json:"error" yaml:"error"`
+}
+
+// ImportAuthors creates the authors read from r: one CreateAuthorRequest
+// per NDJSON line, or CSV with the name and bio columns. Invalid lines are
+// reported in the result. Imports are streamed, they aren't retried.
+func (c *Client) ImportAuthors(ctx context.Context, r io.Reader, format Format) (*ImportResult, error) {
+	return c.importFrom(ctx, "authors", r, format)
+}
+
+// ExportAuthors writes all the authors to w.
+func (c *Client) ExportAuthors(ctx context.Context, w io.Writer, format Format) error {
+	return c.exportTo(ctx, "authors", w, format)
+}
+
+// ImportBooks creates the books read from r: one CreateBookRequest per
+// NDJSON line, or CSV with the title and author_id columns. Invalid lines
+// are reported in the result. Imports are streamed, they aren't retried.
+func (c *Client) ImportBooks(ctx context.Context, r io.Reader, format Format) (*ImportResult, error) {
+	return c.importFrom(ctx, "books", r, format)
+}
+
+// ExportBooks writes all the books to w.
+func (c *Client) ExportBooks(ctx context.Context, w io.Writer, format Format) error {
+	return c.exportTo(ctx, "books", w, format)
+}
+
+func (c *Client) importFrom(ctx context.Context, resource string, r io.Reader, format Format) (*ImportResult, error) {
+	resp, err := c.send(ctx, http.MethodPost, c.endpoint(resource, "import"), r, format.contentType(), contentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var result ImportResult
+	if err := decode(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) exportTo(ctx context.Context, resource string, w io.Writer, format Format) error {
+	resp, err := c.roundTrip(ctx, http.MethodGet, c.endpoint(resource, "export"), nil, format.contentType())
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("could not export %s: %w", resource, err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/sgaunet/template-api/internal/pagination"
+)
+
+// list iterates over the items of a paginated endpoint, following the
+// Link headers of the pages.
+func list[T any](ctx context.Context, c *Client, u *url.URL) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		query := u.Query()
+		query.Set(pagination.ParamLimit, strconv.Itoa(c.pageSize))
+		next := *u
+		next.RawQuery = query.Encode()
+
+		for {
+			var page []T
+			header, err := c.do(ctx, http.MethodGet, &next, nil, &page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			link, ok := pagination.NextLink(header, &next)
+			if !ok {
+				return
+			}
+			next = *link
+		}
+	}
+}
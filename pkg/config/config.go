@@ -2,58 +2,157 @@
 package config
 
 import (
-	"errors"
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/caarlos0/env/v11"
-	"github.com/sgaunet/dsn/v2/pkg/dsn"
-	"gopkg.in/yaml.v3"
 )
 
 // Config is the configuration for the application.
 type Config struct {
-	DBDSN    string `env:"DB_DSN"    yaml:"dbdsn"`
-	RedisDSN string `env:"REDIS_DSN" yaml:"redisdsn"`
-	// RedisStream     string `mapstructure:"redisstream"`
+	// Secrets are tagged secret:"true" to be redacted (see Settings).
+	DBDSN    string `env:"DB_DSN"    secret:"true" yaml:"dbdsn"`
+	RedisDSN string `env:"REDIS_DSN" secret:"true" yaml:"redisdsn"`
+	// AdminToken is the bearer token of the administration routes, which answer 401 when it's empty.
+	AdminToken string `env:"ADMIN_TOKEN" secret:"true" yaml:"admintoken"`
+	// DBDriver is the Postgres driver: pq (default), pgx or pgxpool.
+	DBDriver string `env:"DB_DRIVER" yaml:"dbdriver"`
+	// Database connection pool, zero values keep the database/sql defaults.
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS"     yaml:"dbmaxopenconns"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS"     yaml:"dbmaxidleconns"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME"  yaml:"dbconnmaxlifetime"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" yaml:"dbconnmaxidletime"`
+	// DBStatementTimeout aborts statements running longer, it overrides the statement_timeout DSN parameter.
+	DBStatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" yaml:"dbstatementtimeout"`
+	// DBReplicaDSNs are read replicas receiving read-only queries.
+	DBReplicaDSNs []string `env:"DB_REPLICA_DSNS" envSeparator:"," secret:"true" yaml:"dbreplicadsns"`
+	// DBReplicaHealthCheckInterval is the interval between two replica health checks (0 means default).
+	DBReplicaHealthCheckInterval time.Duration `env:"DB_REPLICA_HEALTH_CHECK_INTERVAL" yaml:"dbreplicahealthcheckinterval"`
+	// DBDisableAutoMigrate skips migrations on serve, useful when several replicas are deployed.
+	DBDisableAutoMigrate bool `env:"DB_DISABLE_AUTO_MIGRATE" yaml:"dbdisableautomigrate"`
+	// DBMigrationLockTimeout is the maximum time to wait for another replica migrating the database (0 means default).
+	DBMigrationLockTimeout time.Duration `env:"DB_MIGRATION_LOCK_TIMEOUT" yaml:"dbmigrationlocktimeout"`
+	// CacheBackend enables the authors cache: memory or redis (RedisDSN), disabled when empty.
+	CacheBackend string `env:"CACHE_BACKEND" yaml:"cachebackend"`
+	// CacheTTL is the time entries stay in cache (0 means default).
+	CacheTTL time.Duration `env:"CACHE_TTL" yaml:"cachettl"`
+	// CacheSize is the maximum number of entries of the memory cache (0 means default).
+	CacheSize int `env:"CACHE_SIZE" yaml:"cachesize"`
+	// AuthorsBatchMaxSize is the maximum number of items of a batch request (0 means default).
+	AuthorsBatchMaxSize int `env:"AUTHORS_BATCH_MAX_SIZE" yaml:"authorsbatchmaxsize"`
+	// OutboxEnabled publishes domain events to the Redis stream OutboxStream (RedisDSN). Events are
+	// recorded in the outbox and relayed to the change stream and webhooks whether it's set or not.
+	OutboxEnabled bool   `env:"OUTBOX_ENABLED" yaml:"outboxenabled"`
+	OutboxStream  string `env:"OUTBOX_STREAM"  yaml:"outboxstream"`
+	// OutboxPollInterval is the interval between two outbox relay runs (0 means default).
+	OutboxPollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL" yaml:"outboxpollinterval"`
+	// OutboxBatchSize is the maximum number of events published per relay run (0 means default).
+	OutboxBatchSize int `env:"OUTBOX_BATCH_SIZE" yaml:"outboxbatchsize"`
+	// Change stream (GET /events), zero values mean defaults.
+	EventsReplaySize        int           `env:"EVENTS_REPLAY_SIZE"        yaml:"eventsreplaysize"`
+	EventsClientBuffer      int           `env:"EVENTS_CLIENT_BUFFER"      yaml:"eventsclientbuffer"`
+	EventsHeartbeatInterval time.Duration `env:"EVENTS_HEARTBEAT_INTERVAL" yaml:"eventsheartbeatinterval"`
+	// JobsConcurrency is the number of background jobs run concurrently (0 means default).
+	JobsConcurrency int `env:"JOBS_CONCURRENCY" yaml:"jobsconcurrency"`
+	// JobsPollInterval is the interval between two claims of an idle job worker (0 means default).
+	JobsPollInterval time.Duration `env:"JOBS_POLL_INTERVAL" yaml:"jobspollinterval"`
+	// SchedulerDisabled disables the periodic maintenance tasks on this instance.
+	SchedulerDisabled bool `env:"SCHEDULER_DISABLED" yaml:"schedulerdisabled"`
+	// MaintenanceRetention is the time processed outbox events, jobs and webhook deliveries are kept (0 means default).
+	MaintenanceRetention time.Duration `env:"MAINTENANCE_RETENTION" yaml:"maintenanceretention"`
+	// WebhooksEnabled delivers domain events to webhook subscriptions.
+	WebhooksEnabled bool `env:"WEBHOOKS_ENABLED" yaml:"webhooksenabled"`
+	// WebhooksMaxAttempts is the number of attempts before a delivery is dead (0 means default).
+	WebhooksMaxAttempts int `env:"WEBHOOKS_MAX_ATTEMPTS" yaml:"webhooksmaxattempts"`
+	// WebhooksTimeout is the timeout of a delivery request (0 means default).
+	WebhooksTimeout time.Duration `env:"WEBHOOKS_TIMEOUT" yaml:"webhookstimeout"`
+	// WebhooksAllowPrivate accepts webhooks to loopback, private and link-local addresses, for development.
+	WebhooksAllowPrivate bool `env:"WEBHOOKS_ALLOW_PRIVATE" yaml:"webhooksallowprivate"`
+	// ValidateRequests rejects requests not matching the OpenAPI document.
+	ValidateRequests bool `env:"VALIDATE_REQUESTS" yaml:"validaterequests"`
+	// ValidateResponses also checks responses against the OpenAPI document, for tests and staging.
+	ValidateResponses bool `env:"VALIDATE_RESPONSES" yaml:"validateresponses"`
+	// GRPCEnabled starts the gRPC server on GRPCListenAddr (empty means default, :3001).
+	GRPCEnabled    bool   `env:"GRPC_ENABLED"     yaml:"grpcenabled"`
+	GRPCListenAddr string `env:"GRPC_LISTEN_ADDR" yaml:"grpclistenaddr"`
+	// GraphQL query limits, zero values mean defaults.
+	GraphQLMaxDepth      int `env:"GRAPHQL_MAX_DEPTH"      yaml:"graphqlmaxdepth"`
+	GraphQLMaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY" yaml:"graphqlmaxcomplexity"`
+	// FlagsRefreshInterval is the interval between two reloads of the feature flags of the database (0 means default).
+	FlagsRefreshInterval time.Duration `env:"FLAGS_REFRESH_INTERVAL" yaml:"flagsrefreshinterval"`
+
+	// The settings tagged reload:"live" are applied without restarting when
+	// the configuration is reloaded (see Reloader), the others require a restart.
+
+	// LogLevel is the minimum level of logs: debug, info (default), warn or error.
+	LogLevel string `env:"LOG_LEVEL" reload:"live" yaml:"loglevel"`
+	// RateLimit is the number of requests per second allowed per client IP, 0 disables rate limiting.
+	RateLimit float64 `env:"RATE_LIMIT" reload:"live" yaml:"ratelimit"`
+	// RateLimitBurst is the number of requests a client can send at once (0 means RateLimit rounded up).
+	RateLimitBurst int `env:"RATE_LIMIT_BURST" reload:"live" yaml:"ratelimitburst"`
+	// CORSAllowedOrigins are the origins allowed to call the API from browsers, "*" allows any origin.
+	CORSAllowedOrigins []string `env:"CORS_ALLOWED_ORIGINS" envSeparator:"," reload:"live" yaml:"corsallowedorigins"`
+	// FeatureFlags enables or disables features by name, e.g. FEATURE_FLAGS=books:true,newsearch:false.
+	// The flags of the database (see flags.Store) override them.
+	FeatureFlags map[string]bool `env:"FEATURE_FLAGS" reload:"live" yaml:"featureflags"`
+
+	// sources maps the YAML keys of the settings set by Load to their source.
+	sources map[string]Source
 }
 
-// Load loads the configuration from a file and overrides with environment variables.
+// Load loads the configuration from a file and overrides with environment
+// variables. ${NAME} references in the YAML values are replaced by the
+// environment variable NAME, and the value of a setting can be read from
+// the file named by its environment variable with the _FILE suffix, e.g.
+// DB_DSN_FILE. Unknown YAML keys are rejected. An empty filename only
+// reads the environment, a named file must exist.
 func Load(filename string) (Config, error) {
-	var cfg Config
+	cfg := Config{sources: map[string]Source{}}
 
-	// Load config from YAML file if it exists
-	if _, err := os.Stat(filename); err == nil {
+	// Load config from YAML file if one is named
+	if filename != "" {
 		//nolint:gosec
 		yamlFile, err := os.ReadFile(filename)
 		if err != nil {
 			return cfg, fmt.Errorf("could not read yaml file: %w", err)
 		}
-		err = yaml.Unmarshal(yamlFile, &cfg)
+		keys, err := decodeYAML(yamlFile, &cfg, os.LookupEnv)
 		if err != nil {
-			return cfg, fmt.Errorf("could not parse yaml file: %w", err)
+			return cfg, err
+		}
+		for _, key := range keys {
+			cfg.sources[key] = SourceYAML
 		}
 	}
 
 	// Parse environment variables and override YAML values
-	if err := env.Parse(&cfg); err != nil {
+	environ, fromFile, err := environment()
+	if err != nil {
+		return cfg, fmt.Errorf("error reading environment variables: %w", err)
+	}
+	if err := env.ParseWithOptions(&cfg, env.Options{Environment: environ}); err != nil {
 		return cfg, fmt.Errorf("error parsing environment variables: %w", err)
 	}
+	for _, f := range fields() {
+		switch {
+		case fromFile[f.env]:
+			cfg.sources[f.name] = SourceFile
+		case f.env != "" && environ[f.env] != "":
+			// empty variables don't override the YAML values
+			cfg.sources[f.name] = SourceEnv
+		}
+	}
 
 	return cfg, nil
 }
 
-
-// ErrInvalidConfig is returned when the configuration is invalid.
-var ErrInvalidConfig = errors.New("invalid configuration")
-
-// Validate checks if the configuration is valid.
-func (c *Config) Validate() error {
-	if c.DBDSN == "" {
-		return fmt.Errorf("%w: DBDSN is empty", ErrInvalidConfig)
-	}
-	if _, err := dsn.New(c.DBDSN); err != nil {
-		return fmt.Errorf("%w: invalid DBDSN: %w", ErrInvalidConfig, err)
+// Level returns the level of LogLevel, info when it's empty or invalid.
+func (c *Config) Level() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return slog.LevelInfo
 	}
-	return nil
+	return level
 }
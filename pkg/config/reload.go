@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay groups the events of a file written in several steps.
+const reloadDelay = 100 * time.Millisecond
+
+// kubernetesDataDir is the symlink swapped when a Kubernetes ConfigMap
+// mounted as a volume is updated.
+const kubernetesDataDir = "..data"
+
+// ErrRestartRequired is returned when a reloaded configuration changes
+// settings which are only applied at startup.
+var ErrRestartRequired = errors.New("changed settings require a restart")
+
+// Change is a setting changed by a reload.
+type Change struct {
+	// Name is the YAML key of the setting.
+	Name     string
+	Old, New any
+	// Live reports whether the setting is applied without restarting.
+	Live bool
+}
+
+// String describes the change. The values of settings requiring a restart,
+// such as DSNs, may be secrets: only their name is given.
+func (c Change) String() string {
+	if !c.Live {
+		return c.Name
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Name, c.Old, c.New)
+}
+
+// Diff returns the settings changed from old to updated, in the order of
+// the Config fields.
+func Diff(old, updated Config) []Change {
+	var changes []Change
+	oldValue, updatedValue := reflect.ValueOf(old), reflect.ValueOf(updated)
+	for _, f := range fields() {
+		before, after := oldValue.Field(f.index).Interface(), updatedValue.Field(f.index).Interface()
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+		changes = append(changes, Change{Name: f.name, Old: before, New: after, Live: f.live})
+	}
+	return changes
+}
+
+// Reloader reloads the configuration when its file changes or when the
+// process receives SIGHUP. A reloaded configuration is validated, then
+// applied if it only changes live settings (see Config); it is rejected as
+// a whole otherwise.
+type Reloader struct {
+	filename string
+	apply    func(Config)
+
+	mu      sync.Mutex
+	current Config
+}
+
+// NewReloader creates a reloader of the configuration loaded from filename
+// (see Load), cfg being the configuration in use. apply is called with each
+// reloaded configuration to update the live settings.
+func NewReloader(filename string, cfg Config, apply func(Config)) *Reloader {
+	return &Reloader{filename: filename, apply: apply, current: cfg}
+}
+
+// Current returns the configuration applied last.
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads and applies the configuration, returning the changed
+// settings.
+func (r *Reloader) Reload() ([]Change, error) {
+	cfg, err := Load(r.filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := Diff(r.current, cfg)
+	var restart []string
+	for _, change := range changes {
+		if !change.Live {
+			restart = append(restart, change.Name)
+		}
+	}
+	if len(restart) > 0 {
+		return changes, fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(restart, ", "))
+	}
+	if len(changes) > 0 {
+		r.apply(cfg)
+		r.current = cfg
+	}
+	return changes, nil
+}
+
+// Run reloads the configuration on SIGHUP and when its file changes, until
+// ctx is cancelled. The directory of the file is watched, so that files
+// replaced by editors or by Kubernetes are reloaded too.
+func (r *Reloader) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var (
+		events      <-chan fsnotify.Event
+		watchErrors <-chan error
+	)
+	if r.filename != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err == nil {
+			err = watcher.Add(filepath.Dir(r.filename))
+			defer func() { _ = watcher.Close() }()
+		}
+		if err != nil {
+			slog.Error("cannot watch the configuration file, reload it with SIGHUP", "file", r.filename, "error", err)
+		} else {
+			events, watchErrors = watcher.Events, watcher.Errors
+		}
+	}
+
+	delay := time.NewTimer(reloadDelay)
+	delay.Stop()
+	defer delay.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload("SIGHUP")
+		case event := <-events:
+			if r.concerns(event) {
+				delay.Reset(reloadDelay)
+			}
+		case <-delay.C:
+			r.reload("file changed")
+		case err := <-watchErrors:
+			slog.Error("configuration file watch failed", "file", r.filename, "error", err)
+		}
+	}
+}
+
+// concerns reports whether event may have changed the configuration file.
+func (r *Reloader) concerns(event fsnotify.Event) bool {
+	if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+		return false
+	}
+	return filepath.Clean(event.Name) == filepath.Clean(r.filename) ||
+		filepath.Base(event.Name) == kubernetesDataDir
+}
+
+// reload reloads the configuration and logs the outcome.
+func (r *Reloader) reload(trigger string) {
+	changes, err := r.Reload()
+	if err != nil {
+		slog.Error("configuration reload rejected", "trigger", trigger, "error", err)
+		return
+	}
+	if len(changes) == 0 {
+		slog.Info("configuration unchanged", "trigger", trigger)
+		return
+	}
+	diff := make([]string, len(changes))
+	for i, change := range changes {
+		diff[i] = change.String()
+	}
+	slog.Info("configuration reloaded", "trigger", trigger, "changes", diff)
+}
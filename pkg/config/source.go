@@ -0,0 +1,269 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source is where the value of a setting comes from.
+type Source string
+
+// Sources of the settings, by increasing precedence.
+const (
+	SourceDefault Source = "default"
+	SourceYAML    Source = "yaml"
+	SourceEnv     Source = "env"
+	// SourceFile is a file named by an environment variable with the _FILE
+	// suffix, e.g. DB_DSN_FILE, as mounted by Docker or Kubernetes secrets.
+	SourceFile Source = "file"
+)
+
+// fileSuffix is the suffix of the environment variables naming the file
+// of a setting.
+const fileSuffix = "_FILE"
+
+// redacted replaces the values of secrets.
+const redacted = "xxxxx"
+
+var (
+	errUnknownKey      = errors.New("unknown key")
+	errUndefinedVar    = errors.New("undefined variable")
+	errAmbiguousSecret = errors.New("both set")
+)
+
+// variable matches the ${NAME} references expanded in YAML values.
+var variable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// secretParam matches the query parameters of URLs holding credentials,
+// e.g. password or sslpassword of PostgreSQL DSNs.
+var secretParam = regexp.MustCompile(`(?i)pass|secret|token|key`)
+
+// field describes a setting of Config.
+type field struct {
+	index  int
+	name   string // YAML key
+	env    string
+	live   bool
+	secret bool
+}
+
+// fields returns the settings of Config, in the order of the fields.
+func fields() []field {
+	var list []field
+	for i, f := range reflect.VisibleFields(reflect.TypeFor[Config]()) {
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		envName, _, _ := strings.Cut(f.Tag.Get("env"), ",")
+		list = append(list, field{
+			index:  i,
+			name:   name,
+			env:    envName,
+			live:   f.Tag.Get("reload") == "live",
+			secret: f.Tag.Get("secret") == "true",
+		})
+	}
+	return list
+}
+
+// environment returns the environment variables, with the content of the
+// files named by the _FILE variables of the settings, and the variables
+// read from files.
+func environment() (map[string]string, map[string]bool, error) {
+	environ := map[string]string{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		environ[key] = value
+	}
+
+	fromFile := map[string]bool{}
+	for _, f := range fields() {
+		filename := environ[f.env+fileSuffix]
+		if f.env == "" || filename == "" {
+			continue
+		}
+		if environ[f.env] != "" {
+			return nil, nil, fmt.Errorf("%w: %s and %s", errAmbiguousSecret, f.env, f.env+fileSuffix)
+		}
+		//nolint:gosec // the file is chosen by the operator
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read %s: %w", f.env+fileSuffix, err)
+		}
+		// Files usually end with a newline, which isn't part of the secret.
+		environ[f.env] = strings.TrimRight(string(content), "\r\n")
+		fromFile[f.env] = true
+	}
+	return environ, fromFile, nil
+}
+
+// decodeYAML decodes the YAML configuration into cfg, expanding the
+// ${NAME} references of the values with lookup. It fails on unknown keys,
+// and returns the keys set.
+func decodeYAML(data []byte, cfg *Config, lookup func(string) (string, bool)) ([]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("could not parse yaml file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		// empty file
+		return nil, nil
+	}
+	root := doc.Content[0]
+
+	// Config has no nested settings: the keys of the root are checked.
+	var (
+		keys []string
+		errs []error
+	)
+	if root.Kind == yaml.MappingNode {
+		known := map[string]bool{}
+		for _, f := range fields() {
+			known[f.name] = true
+		}
+		for i := 0; i < len(root.Content); i += 2 {
+			key := root.Content[i]
+			if !known[key.Value] {
+				errs = append(errs, fmt.Errorf("line %d: %w %q", key.Line, errUnknownKey, key.Value))
+				continue
+			}
+			keys = append(keys, key.Value)
+		}
+	}
+	errs = append(errs, expand(root, lookup)...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid yaml file: %w", errors.Join(errs...))
+	}
+
+	if err := root.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("could not parse yaml file: %w", err)
+	}
+	return keys, nil
+}
+
+// expand replaces the ${NAME} references of the values of node.
+func expand(node *yaml.Node, lookup func(string) (string, bool)) []error {
+	var errs []error
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		node.Value = variable.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			name := variable.FindStringSubmatch(ref)[1]
+			value, ok := lookup(name)
+			if !ok {
+				errs = append(errs, fmt.Errorf("line %d: %w %s", node.Line, errUndefinedVar, name))
+			}
+			return value
+		})
+		if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0 {
+			// resolve the type of the expanded value, e.g. ratelimit: ${RATE_LIMIT}
+			node.Tag = ""
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			errs = append(errs, expand(node.Content[i], lookup)...)
+		}
+	default:
+		for _, child := range node.Content {
+			errs = append(errs, expand(child, lookup)...)
+		}
+	}
+	return errs
+}
+
+// Setting is the effective value of a setting, see Config.Settings.
+type Setting struct {
+	// Name is the YAML key of the setting.
+	Name string
+	Env  string
+	// Value is formatted as in environment variables, secrets are redacted.
+	Value  string
+	Source Source
+}
+
+// Source returns where the value of the setting name (its YAML key) comes
+// from. Configurations not returned by Load only have default values.
+func (c *Config) Source(name string) Source {
+	if source, ok := c.sources[name]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Settings returns the effective settings, with their source.
+func (c *Config) Settings() []Setting {
+	value := reflect.ValueOf(c).Elem()
+	list := fields()
+	settings := make([]Setting, len(list))
+	for i, f := range list {
+		settings[i] = Setting{
+			Name:   f.name,
+			Env:    f.env,
+			Value:  format(value.Field(f.index).Interface(), f.secret),
+			Source: c.Source(f.name),
+		}
+	}
+	return settings
+}
+
+// format formats v as in environment variables. The passwords and
+// credential parameters of secret URLs are redacted, other secrets
+// entirely.
+func format(v any, secret bool) string {
+	switch v := v.(type) {
+	case string:
+		if !secret || v == "" {
+			return v
+		}
+		if u, err := url.Parse(v); err == nil && u.Scheme != "" {
+			u.RawQuery = redactQuery(u.RawQuery)
+			return u.Redacted()
+		}
+		return redacted
+	case []string:
+		values := make([]string, len(v))
+		for i, s := range v {
+			values[i] = format(s, secret)
+		}
+		return strings.Join(values, ",")
+	case map[string]bool:
+		values := make([]string, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			values = append(values, fmt.Sprintf("%s:%t", key, v[key]))
+		}
+		return strings.Join(values, ",")
+	case time.Duration:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// redactQuery redacts the values of the credential parameters of the raw
+// query, keeping the order of the parameters.
+func redactQuery(query string) string {
+	if query == "" {
+		return query
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && secretParam.MatchString(name) {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sgaunet/template-api/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+	return filename
+}
+
+func TestLoad_Sources(t *testing.T) {
+	filename := writeFile(t, "config.yaml", "dbdsn: postgres://app:${PGPASSWORD}@db:5432/app\n"+
+		"ratelimit: ${RATE}\ncachebackend: '${BACKEND}'\nloglevel: warn\n")
+	t.Setenv("PGPASSWORD", "s3cret")
+	t.Setenv("RATE", "2.5")
+	t.Setenv("BACKEND", "memory")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("REDIS_DSN_FILE", writeFile(t, "redis", "redis://:hunter2@redis:6379\n"))
+
+	cfg, err := config.Load(filename)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, "postgres://app:s3cret@db:5432/app", cfg.DBDSN)
+	assert.InDelta(t, 2.5, cfg.RateLimit, 0, "expanded values are typed")
+	assert.Equal(t, "memory", cfg.CacheBackend)
+	assert.Equal(t, "redis://:hunter2@redis:6379", cfg.RedisDSN, "the trailing newline of files is trimmed")
+
+	assert.Equal(t, config.SourceYAML, cfg.Source("dbdsn"))
+	assert.Equal(t, config.SourceEnv, cfg.Source("loglevel"))
+	assert.Equal(t, config.SourceFile, cfg.Source("redisdsn"))
+	assert.Equal(t, config.SourceDefault, cfg.Source("jobsconcurrency"))
+
+	settings := map[string]config.Setting{}
+	for _, s := range cfg.Settings() {
+		settings[s.Name] = s
+	}
+	assert.Equal(t, config.Setting{
+		Name: "dbdsn", Env: "DB_DSN", Value: "postgres://app:xxxxx@db:5432/app", Source: config.SourceYAML,
+	}, settings["dbdsn"])
+	assert.Equal(t, "redis://:xxxxx@redis:6379", settings["redisdsn"].Value)
+	assert.Equal(t, "debug", settings["loglevel"].Value)
+	assert.Equal(t, "0s", settings["cachettl"].Value)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Run("unknown keys", func(t *testing.T) {
+		_, err := config.Load(writeFile(t, "config.yaml", "dbdsn: postgres://db\ndbdns: postgres://db\ncachesise: 10\n"))
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `line 2: unknown key "dbdns"`)
+		assert.ErrorContains(t, err, `line 3: unknown key "cachesise"`)
+	})
+	t.Run("undefined variable", func(t *testing.T) {
+		_, err := config.Load(writeFile(t, "config.yaml", "dbdsn: postgres://app:${UNDEFINED_PASSWORD}@db\n"))
+		assert.ErrorContains(t, err, "line 1: undefined variable UNDEFINED_PASSWORD")
+	})
+	t.Run("value and file", func(t *testing.T) {
+		t.Setenv("DB_DSN", "postgres://db")
+		t.Setenv("DB_DSN_FILE", writeFile(t, "dsn", "postgres://db"))
+		_, err := config.Load("")
+		assert.ErrorContains(t, err, "DB_DSN and DB_DSN_FILE")
+	})
+	t.Run("missing config file", func(t *testing.T) {
+		_, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("DB_DSN_FILE", filepath.Join(t.TempDir(), "missing"))
+		_, err := config.Load("")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestSettings_RedactsQuery(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://app@db:5432/app?sslmode=require&password=s3cret&sslpassword=k3y")
+	t.Setenv("REDIS_DSN", "host=redis password=hunter2")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	settings := map[string]config.Setting{}
+	for _, s := range cfg.Settings() {
+		settings[s.Name] = s
+	}
+	assert.Equal(t, "postgres://app@db:5432/app?sslmode=require&password=xxxxx&sslpassword=xxxxx",
+		settings["dbdsn"].Value)
+	assert.Equal(t, "xxxxx", settings["redisdsn"].Value, "secrets which aren't URLs are redacted entirely")
+}
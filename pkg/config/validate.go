@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sgaunet/dsn/v2/pkg/dsn"
+)
+
+// ErrInvalidConfig is returned when the configuration is invalid.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// FieldError is an invalid setting.
+type FieldError struct {
+	// Path locates the value: the YAML key of the setting, followed by the
+	// index or the key of list and map items, e.g. dbreplicadsns[1].
+	Path   string
+	Env    string
+	Source Source
+	// Message doesn't contain the value, which may be a secret.
+	Message string
+
+	// index is the index of the setting in Config, to sort errors.
+	index int
+}
+
+func (e *FieldError) Error() string {
+	switch e.Source {
+	case SourceEnv:
+		return fmt.Sprintf("%s: %s (env %s)", e.Path, e.Message, e.Env)
+	case SourceFile:
+		return fmt.Sprintf("%s: %s (file %s)", e.Path, e.Message, e.Env+fileSuffix)
+	default:
+		return fmt.Sprintf("%s: %s (%s)", e.Path, e.Message, e.Source)
+	}
+}
+
+// ValidationError lists the invalid settings of a configuration.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns ErrInvalidConfig.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// validator collects the errors of a configuration.
+type validator struct {
+	cfg    *Config
+	fields map[string]field
+	errs   []*FieldError
+}
+
+// fail records an error of the value at path, a setting or one of its items.
+func (v *validator) fail(path, format string, args ...any) {
+	name, _, _ := strings.Cut(path, "[")
+	name, _, _ = strings.Cut(name, ".")
+	v.errs = append(v.errs, &FieldError{
+		Path:    path,
+		Env:     v.fields[name].env,
+		Source:  v.cfg.Source(name),
+		Message: fmt.Sprintf(format, args...),
+		index:   v.fields[name].index,
+	})
+}
+
+// Validate checks every setting, and returns a *ValidationError listing
+// all the invalid ones.
+func (c *Config) Validate() error {
+	v := &validator{cfg: c, fields: map[string]field{}}
+	for _, f := range fields() {
+		v.fields[f.name] = f
+	}
+
+	v.numbers()
+	v.database()
+	v.redis()
+	v.enum("cachebackend", c.CacheBackend, "memory", "redis")
+	v.listenAddr("grpclistenaddr", c.GRPCListenAddr)
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); c.LogLevel != "" && err != nil {
+		v.fail("loglevel", "must be one of debug, info, warn, error")
+	}
+	if c.RateLimitBurst > 0 && c.RateLimit == 0 {
+		v.fail("ratelimitburst", "requires ratelimit")
+	}
+	for i, origin := range c.CORSAllowedOrigins {
+		v.origin(fmt.Sprintf("corsallowedorigins[%d]", i), origin)
+	}
+	for name := range c.FeatureFlags {
+		if strings.TrimSpace(name) == "" {
+			v.fail("featureflags", "contains an empty name")
+		}
+	}
+
+	if len(v.errs) > 0 {
+		slices.SortStableFunc(v.errs, func(a, b *FieldError) int { return a.index - b.index })
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
+
+// numbers checks that the counts, sizes and durations aren't negative, 0
+// meaning the default.
+func (v *validator) numbers() {
+	value := reflect.ValueOf(v.cfg).Elem()
+	for _, f := range fields() {
+		field := value.Field(f.index)
+		switch field.Kind() { //nolint:exhaustive // other kinds aren't numbers
+		case reflect.Int, reflect.Int64:
+			if field.Int() < 0 {
+				v.fail(f.name, "must not be negative")
+			}
+		case reflect.Float64:
+			if field.Float() < 0 {
+				v.fail(f.name, "must not be negative")
+			}
+		}
+	}
+}
+
+func (v *validator) database() {
+	c := v.cfg
+	if c.DBDSN == "" {
+		v.fail("dbdsn", "is required")
+	} else {
+		v.dsn("dbdsn", c.DBDSN, "postgres", "postgresql")
+	}
+	for i, replica := range c.DBReplicaDSNs {
+		v.dsn(fmt.Sprintf("dbreplicadsns[%d]", i), replica, "postgres", "postgresql")
+	}
+	v.enum("dbdriver", c.DBDriver, "pq", "pgx", "pgxpool")
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		v.fail("dbmaxidleconns", "must not be greater than dbmaxopenconns")
+	}
+	if c.DBStatementTimeout > 0 && c.DBStatementTimeout < time.Millisecond {
+		v.fail("dbstatementtimeout", "must be at least 1ms")
+	}
+}
+
+func (v *validator) redis() {
+	c := v.cfg
+	if c.RedisDSN != "" {
+		v.dsn("redisdsn", c.RedisDSN, "redis", "rediss")
+		return
+	}
+	switch {
+	case c.CacheBackend == "redis":
+		v.fail("redisdsn", "is required by the redis cache")
+	case c.OutboxEnabled:
+		v.fail("redisdsn", "is required by the outbox")
+	}
+}
+
+// dsn checks a DSN and, if any, its scheme.
+func (v *validator) dsn(path, value string, schemes ...string) {
+	d, err := dsn.New(value)
+	if err != nil {
+		v.fail(path, "invalid DSN: %v", err)
+		return
+	}
+	if scheme := d.GetScheme(); scheme != "" && !slices.Contains(schemes, scheme) {
+		v.fail(path, "scheme must be one of %s", strings.Join(schemes, ", "))
+	}
+}
+
+// enum checks that value is empty (the default) or one of values.
+func (v *validator) enum(path, value string, values ...string) {
+	if value != "" && !slices.Contains(values, value) {
+		v.fail(path, "must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// listenAddr checks a host:port address, the host being optional.
+func (v *validator) listenAddr(path, addr string) {
+	if addr == "" {
+		return
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		v.fail(path, "must be host:port or :port")
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		v.fail(path, "port must be between 1 and 65535")
+	}
+}
+
+// origin checks a CORS origin: * or scheme://host[:port].
+func (v *validator) origin(path, origin string) {
+	if origin == "*" {
+		return
+	}
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil {
+		v.fail(path, "must be * or an origin like https://example.com")
+	}
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sgaunet/template-api/internal/outbox"
+)
+
+// Broker defaults.
+const (
+	DefaultReplaySize   = 1000
+	DefaultClientBuffer = 64
+)
+
+// EventReset is the type of the event sent to a client resuming from an
+// event no longer in the replay buffer: the events it missed are lost, and
+// it should reload what it derives from the stream. Its empty identifier
+// clears the last event ID of EventSource.
+const EventReset = "reset"
+
+// resetEvent is the event of type EventReset.
+var resetEvent = Event{Type: EventReset, Data: []byte("{}")}
+
+// Event is a change event sent to clients.
+type Event struct {
+	// ID is the CloudEvent ID, the same on every instance relaying the
+	// Redis stream.
+	ID   string
+	Type string
+	// Data is the CloudEvents JSON envelope of the change.
+	Data []byte
+}
+
+// subscriber is a connected client.
+type subscriber struct {
+	events chan Event
+	// evicted is closed when the client is disconnected for being too slow.
+	evicted chan struct{}
+}
+
+// Broker fans out change events to connected clients.
+type Broker struct {
+	mu           sync.Mutex
+	replay       []Event // ring buffer of the last events
+	replayStart  int
+	replaySize   int
+	clientBuffer int
+	subscribers  map[*subscriber]struct{}
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+var _ outbox.Publisher = (*Broker)(nil)
+
+// BrokerOption configures the broker.
+type BrokerOption func(*Broker)
+
+// WithReplaySize sets the number of events kept for resumption.
+// Values lower or equal to zero are ignored.
+func WithReplaySize(size int) BrokerOption {
+	return func(b *Broker) {
+		if size > 0 {
+			b.replaySize = size
+		}
+	}
+}
+
+// WithClientBuffer sets the number of events queued per client before it is
+// disconnected. Values lower or equal to zero are ignored.
+func WithClientBuffer(size int) BrokerOption {
+	return func(b *Broker) {
+		if size > 0 {
+			b.clientBuffer = size
+		}
+	}
+}
+
+// NewBroker creates a new broker.
+func NewBroker(opts ...BrokerOption) *Broker {
+	b := &Broker{
+		replaySize:   DefaultReplaySize,
+		clientBuffer: DefaultClientBuffer,
+		subscribers:  map[*subscriber]struct{}{},
+		closed:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Publish sends a change event to connected clients. It never blocks:
+// clients whose queue is full are disconnected.
+func (b *Broker) Publish(_ context.Context, ev outbox.CloudEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("could not encode event %s: %w", ev.ID, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event := Event{ID: ev.ID, Type: ev.Type, Data: data}
+	if len(b.replay) < b.replaySize {
+		b.replay = append(b.replay, event)
+	} else {
+		b.replay[b.replayStart] = event
+		b.replayStart = (b.replayStart + 1) % b.replaySize
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			close(sub.evicted)
+			delete(b.subscribers, sub)
+		}
+	}
+	return nil
+}
+
+// subscribe registers a client and returns the buffered events following
+// the event lastID, none when lastID is empty. A reset event is returned
+// instead when lastID isn't in the buffer: it is older than the buffer, or
+// was relayed by another process.
+func (b *Broker) subscribe(lastID string) (*subscriber, []Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastID != "" {
+		// Copy the ring in order, it is overwritten once the lock is released.
+		ordered := make([]Event, 0, len(b.replay))
+		ordered = append(ordered, b.replay[b.replayStart:]...)
+		ordered = append(ordered, b.replay[:b.replayStart]...)
+		missed = []Event{resetEvent}
+		for i := len(ordered) - 1; i >= 0; i-- {
+			if ordered[i].ID == lastID {
+				missed = ordered[i+1:]
+				break
+			}
+		}
+	}
+
+	sub := &subscriber{
+		events:  make(chan Event, b.clientBuffer),
+		evicted: make(chan struct{}),
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, missed
+}
+
+// unsubscribe removes a disconnected client.
+func (b *Broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+}
+
+// Close disconnects clients, open streams would otherwise delay the server
+// shutdown.
+func (b *Broker) Close() {
+	b.closeOnce.Do(func() { close(b.closed) })
+}
@@ -0,0 +1,8 @@
+// Package events streams catalog changes to clients with Server-Sent Events.
+//
+// The broker keeps the last events in a bounded replay buffer so that
+// reconnecting clients resume from their Last-Event-ID, the CloudEvent ID of
+// the last event they received. Each client has a bounded queue: a client
+// too slow to drain it is disconnected rather than blocking the publisher,
+// and resumes on reconnection.
+package events
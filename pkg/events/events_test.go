@@ -0,0 +1,207 @@
+package events_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// stream connects to the change stream and returns the received events.
+// Lines are read until the connection is closed by the server.
+func stream(t *testing.T, url, lastEventID string) (<-chan sseEvent, *http.Response) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	received := make(chan sseEvent, 100)
+	go func() {
+		defer close(received)
+		var ev sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id:"):
+				ev.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+			case strings.HasPrefix(line, "event: "):
+				ev.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				ev.data = strings.TrimPrefix(line, "data: ")
+			case line == ": heartbeat":
+				received <- sseEvent{event: "heartbeat"}
+			case line == "" && ev.event != "":
+				received <- ev
+				ev = sseEvent{}
+			}
+		}
+	}()
+	return received, resp
+}
+
+// newServer serves the change stream of broker. The server is closed after
+// the client connections, which are closed in cleanups registered later.
+func newServer(t *testing.T, broker *events.Broker, heartbeat time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(events.NewHandler(broker, heartbeat).Stream))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func next(t *testing.T, received <-chan sseEvent) sseEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-received:
+		require.True(t, ok, "stream closed")
+		return ev
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "no event received")
+		return sseEvent{}
+	}
+}
+
+func publish(t *testing.T, broker *events.Broker, eventType string) {
+	t.Helper()
+	require.NoError(t, broker.Publish(context.Background(), outbox.CloudEvent{ID: uuid.NewString(), Type: eventType}))
+}
+
+func TestStream(t *testing.T) {
+	broker := events.NewBroker()
+	srv := newServer(t, broker, 0)
+
+	received, resp := stream(t, srv.URL, "")
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	publish(t, broker, "AuthorCreated")
+	ev := next(t, received)
+	assert.Equal(t, "AuthorCreated", ev.event)
+	assert.Contains(t, ev.data, `"type":"AuthorCreated"`)
+	assert.NotEmpty(t, ev.id)
+}
+
+func TestStream_Resume(t *testing.T) {
+	broker := events.NewBroker(events.WithReplaySize(3))
+	srv := newServer(t, broker, 0)
+
+	received, _ := stream(t, srv.URL, "")
+	publish(t, broker, "AuthorCreated")
+	first := next(t, received)
+
+	publish(t, broker, "BookCreated")
+	publish(t, broker, "AuthorDeleted")
+
+	// Resume after the first event.
+	resumed, _ := stream(t, srv.URL, first.id)
+	assert.Equal(t, "BookCreated", next(t, resumed).event)
+	assert.Equal(t, "AuthorDeleted", next(t, resumed).event)
+
+	// Events older than the buffer are lost, the client is reset rather
+	// than sent the buffer.
+	for range 3 {
+		publish(t, broker, "BookCreated")
+	}
+	resumed, _ = stream(t, srv.URL, first.id)
+	reset := next(t, resumed)
+	assert.Equal(t, events.EventReset, reset.event)
+	assert.Empty(t, reset.id, "the last event ID of the client is cleared")
+	publish(t, broker, "AuthorUpdated")
+	assert.Equal(t, "AuthorUpdated", next(t, resumed).event)
+
+	// Events of a previous process aren't in the buffer.
+	restarted := events.NewBroker()
+	srvRestarted := newServer(t, restarted, 0)
+	publish(t, restarted, "AuthorCreated")
+	resumed, _ = stream(t, srvRestarted.URL, first.id)
+	assert.Equal(t, events.EventReset, next(t, resumed).event)
+}
+
+func TestStream_ResumeUpToDate(t *testing.T) {
+	broker := events.NewBroker()
+	srv := newServer(t, broker, 0)
+
+	received, _ := stream(t, srv.URL, "")
+	publish(t, broker, "AuthorCreated")
+	last := next(t, received)
+
+	// Nothing was missed, the next event is the first one sent.
+	resumed, _ := stream(t, srv.URL, last.id)
+	publish(t, broker, "BookCreated")
+	assert.Equal(t, "BookCreated", next(t, resumed).event)
+}
+
+func TestStream_Heartbeat(t *testing.T) {
+	srv := newServer(t, events.NewBroker(), 10*time.Millisecond)
+
+	received, _ := stream(t, srv.URL, "")
+	assert.Equal(t, "heartbeat", next(t, received).event)
+}
+
+func TestStream_SlowClientDisconnected(t *testing.T) {
+	broker := events.NewBroker(events.WithClientBuffer(1))
+	handler := events.NewHandler(broker, 0)
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.Stream(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	// The client never reads the body: once the connection buffers are full
+	// the handler blocks writing, its queue fills up and it is disconnected.
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for range 10000 {
+			publish(t, broker, strings.Repeat("x", 1000))
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "publisher blocked by a slow client")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "slow client not disconnected")
+	}
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := events.NewBroker()
+	srv := newServer(t, broker, 0)
+
+	received, _ := stream(t, srv.URL, "")
+	broker.Close()
+	select {
+	case _, ok := <-received:
+		assert.False(t, ok)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "stream not closed")
+	}
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultHeartbeatInterval is the default interval between two heartbeats.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+const (
+	// retryDelay is the reconnection delay advised to clients.
+	retryDelay = 3 * time.Second
+	// writeTimeout disconnects clients that stopped reading: a blocked write
+	// would otherwise keep the handler from noticing the eviction.
+	writeTimeout = 10 * time.Second
+)
+
+// Handler handles HTTP requests for the change stream.
+type Handler struct {
+	broker    *Broker
+	heartbeat time.Duration
+}
+
+// NewHandler creates a new events handler. Heartbeats are comments sent
+// every interval to keep idle connections open through proxies, zero means
+// the default.
+func NewHandler(broker *Broker, heartbeat time.Duration) *Handler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+	return &Handler{broker: broker, heartbeat: heartbeat}
+}
+
+// Stream handles GET /events. Clients resume with the Last-Event-ID header
+// sent by EventSource on reconnection, or the lastEventId query parameter,
+// and get an EventReset event if the events they missed are lost.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	sub, missed := h.broker.subscribe(lastEventID)
+	defer h.broker.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering of nginx.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	_, _ = fmt.Fprintf(w, "retry: %d\n\n", retryDelay.Milliseconds())
+	for _, ev := range missed {
+		if err := writeEvent(w, ev); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.broker.closed:
+			return
+		case <-sub.evicted:
+			// The client reconnects and resumes from its last event.
+			return
+		case ev := <-sub.events:
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := writeEvent(w, ev); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes ev in the text/event-stream format. Data is single-line
+// JSON and doesn't need to be split.
+func writeEvent(w io.Writer, ev Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+	return err //nolint:wrapcheck // the client is gone
+}
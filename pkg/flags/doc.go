@@ -0,0 +1,9 @@
+// Package flags provides feature flags, to roll out features gradually.
+//
+// Flags are defined with a default by the code gating features, in the
+// configuration (on or off), and in the feature_flags table, which
+// overrides the other sources and adds rules: a flag can be on for some
+// callers, for requests with a header, or for a percentage of callers.
+// The middleware of the Store evaluates the flags of each request and
+// exposes them in its context (see Enabled and Require).
+package flags
@@ -0,0 +1,189 @@
+package flags
+
+import (
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// Limits of the flag definitions.
+const (
+	MaxNameLength        = 64
+	MaxDescriptionLength = 500
+	MaxPercentage        = 100
+)
+
+// Source is where a flag is defined.
+type Source string
+
+// Sources of the flags, by increasing precedence.
+const (
+	SourceDefault  Source = "default"
+	SourceConfig   Source = "config"
+	SourceDatabase Source = "database"
+)
+
+// validName matches the names of flags, e.g. books or new-search.
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
+// Flag is a feature flag. A disabled flag is off; an enabled flag is on for
+// the callers of Identities, for the requests with one of Headers, and for
+// Percentage percent of the other callers.
+type Flag struct {
+	Name        string
+	Description string
+	Enabled     bool
+	Percentage  int
+	Identities  []string
+	// Headers maps header names to the value turning the flag on.
+	Headers   map[string]string
+	Source    Source
+	UpdatedBy string
+	UpdatedAt time.Time
+}
+
+// UpdateFlagRequest is the request to define a flag in the database,
+// replacing its previous definition.
+type UpdateFlagRequest struct {
+	Description string `json:"description,omitempty"`
+	Enabled     *bool  `json:"enabled"`
+	// Percentage of the callers for which the flag is on (default 100).
+	Percentage *int              `json:"percentage,omitempty"`
+	Identities []string          `json:"identities,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+}
+
+// ValidateName validates the name of a flag.
+func ValidateName(name string) error {
+	if len(name) > MaxNameLength || !validName.MatchString(name) {
+		return apperror.NewValidationError(
+			"Invalid feature flag name",
+			map[string]string{
+				"field":   "name",
+				"pattern": validName.String(),
+				"max":     strconv.Itoa(MaxNameLength),
+			},
+		)
+	}
+	return nil
+}
+
+// Validate validates the update flag request.
+func (r *UpdateFlagRequest) Validate() error {
+	if r.Enabled == nil {
+		return apperror.NewValidationError(
+			"Feature flag enabled is required",
+			map[string]string{"field": "enabled"},
+		)
+	}
+	if len(r.Description) > MaxDescriptionLength {
+		return apperror.NewValidationError(
+			"Feature flag description too long",
+			map[string]string{"field": "description", "max": strconv.Itoa(MaxDescriptionLength)},
+		)
+	}
+	if r.Percentage != nil && (*r.Percentage < 0 || *r.Percentage > MaxPercentage) {
+		return apperror.NewValidationError(
+			"Feature flag percentage must be between 0 and 100",
+			map[string]string{"field": "percentage", "value": strconv.Itoa(*r.Percentage)},
+		)
+	}
+	if slices.Contains(r.Identities, "") {
+		return apperror.NewValidationError(
+			"Feature flag identities contain an empty identity",
+			map[string]string{"field": "identities"},
+		)
+	}
+	for header := range r.Headers {
+		if !validHeader(header) {
+			return apperror.NewValidationError(
+				"Invalid feature flag header name",
+				map[string]string{"field": "headers", "value": header},
+			)
+		}
+	}
+	return nil
+}
+
+// ToFlag converts request to the domain flag name.
+func (r *UpdateFlagRequest) ToFlag(name string) (*Flag, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	percentage := MaxPercentage
+	if r.Percentage != nil {
+		percentage = *r.Percentage
+	}
+	headers := make(map[string]string, len(r.Headers))
+	for header, value := range r.Headers {
+		headers[http.CanonicalHeaderKey(header)] = value
+	}
+	return &Flag{
+		Name:        name,
+		Description: r.Description,
+		Enabled:     *r.Enabled,
+		Percentage:  percentage,
+		Identities:  slices.Compact(slices.Sorted(slices.Values(r.Identities))),
+		Headers:     headers,
+		Source:      SourceDatabase,
+	}, nil
+}
+
+// FlagResponse is the response format.
+type FlagResponse struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Enabled     bool              `json:"enabled"`
+	Percentage  int               `json:"percentage"`
+	Identities  []string          `json:"identities"`
+	Headers     map[string]string `json:"headers"`
+	Source      Source            `json:"source"`
+	UpdatedBy   string            `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+}
+
+// ToResponse converts domain flag to response. Only database flags have an
+// update time.
+func (f *Flag) ToResponse() *FlagResponse {
+	resp := &FlagResponse{
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     f.Enabled,
+		Percentage:  f.Percentage,
+		Identities:  slices.Clone(f.Identities),
+		Headers:     maps.Clone(f.Headers),
+		Source:      f.Source,
+		UpdatedBy:   f.UpdatedBy,
+	}
+	if resp.Identities == nil {
+		resp.Identities = []string{}
+	}
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	if f.Source == SourceDatabase {
+		resp.UpdatedAt = &f.UpdatedAt
+	}
+	return resp
+}
+
+// validHeader reports whether name is an HTTP header name (RFC 9110 token).
+func validHeader(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c > '~' || c <= ' ' || slices.Contains([]rune(`"(),/:;<=>?@[\]{}`), c) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"slices"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+)
+
+// Subject is what flags are evaluated for: the caller of a request.
+type Subject struct {
+	// Identity is the caller ID (middleware.CallerHeader), empty when anonymous.
+	Identity string
+	// IP replaces the identity of anonymous callers in percentage rollouts.
+	IP     string
+	Header http.Header
+}
+
+// SubjectOf returns the subject of r.
+func SubjectOf(r *http.Request) Subject {
+	return Subject{Identity: middleware.Caller(r), IP: middleware.ClientIP(r), Header: r.Header}
+}
+
+// Evaluate reports whether the flag is on for s. Percentage rollouts are
+// sticky: a caller keeps the same result while the percentage doesn't
+// decrease.
+func (f *Flag) Evaluate(s Subject) bool {
+	switch {
+	case !f.Enabled:
+		return false
+	case s.Identity != "" && slices.Contains(f.Identities, s.Identity):
+		return true
+	}
+	for header, value := range f.Headers {
+		if s.Header.Get(header) == value {
+			return true
+		}
+	}
+	if f.Percentage >= MaxPercentage {
+		return true
+	}
+	key := s.Identity
+	if key == "" {
+		key = s.IP
+	}
+	return bucket(f.Name, key) < f.Percentage
+}
+
+// bucket assigns key to one of the 100 buckets of a flag, so that callers
+// are rolled out in a different order for each flag.
+func bucket(name, key string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + "/" + key))
+	return int(h.Sum32() % MaxPercentage)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the evaluated flags.
+func NewContext(ctx context.Context, flags map[string]bool) context.Context {
+	return context.WithValue(ctx, contextKey{}, flags)
+}
+
+// FromContext returns the flags evaluated for the request of ctx.
+func FromContext(ctx context.Context) map[string]bool {
+	flags, _ := ctx.Value(contextKey{}).(map[string]bool)
+	return flags
+}
+
+// Enabled reports whether the flag name is on for the request of ctx.
+// Flags are off in contexts without evaluated flags.
+func Enabled(ctx context.Context, name string) bool {
+	return FromContext(ctx)[name]
+}
+
+// Require gates routes behind the flag name: requests for which it is off
+// get a NOT_FOUND error, as if the routes didn't exist.
+func Require(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !Enabled(r.Context(), name) {
+				apperror.WriteError(w, apperror.NewNotFoundError("Not found"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
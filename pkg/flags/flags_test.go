@@ -0,0 +1,262 @@
+package flags_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepository is an in-memory flags.Repository.
+type fakeRepository struct {
+	mu    sync.Mutex
+	flags map[string]*flags.Flag
+}
+
+func newFakeRepository(list ...*flags.Flag) *fakeRepository {
+	r := &fakeRepository{flags: map[string]*flags.Flag{}}
+	for _, flag := range list {
+		flag.Source = flags.SourceDatabase
+		r.flags[flag.Name] = flag
+	}
+	return r
+}
+
+func (r *fakeRepository) List(_ context.Context) ([]*flags.Flag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*flags.Flag, 0, len(r.flags))
+	for _, flag := range r.flags {
+		list = append(list, flag)
+	}
+	return list, nil
+}
+
+func (r *fakeRepository) Upsert(_ context.Context, flag *flags.Flag) (*flags.Flag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	flag.UpdatedAt = time.Now()
+	r.flags[flag.Name] = flag
+	return flag, nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.flags[name]; !ok {
+		return apperror.NewNotFoundError("Feature flag not found")
+	}
+	delete(r.flags, name)
+	return nil
+}
+
+func TestFlag_Evaluate(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Beta", "yes")
+	tests := []struct {
+		name    string
+		flag    flags.Flag
+		subject flags.Subject
+		want    bool
+	}{
+		{"disabled", flags.Flag{Percentage: 100, Identities: []string{"alice"}}, flags.Subject{Identity: "alice"}, false},
+		{"enabled", flags.Flag{Enabled: true, Percentage: 100}, flags.Subject{}, true},
+		{"no percentage", flags.Flag{Enabled: true}, flags.Subject{Identity: "bob"}, false},
+		{"identity", flags.Flag{Enabled: true, Identities: []string{"alice"}}, flags.Subject{Identity: "alice"}, true},
+		{"other identity", flags.Flag{Enabled: true, Identities: []string{"alice"}}, flags.Subject{Identity: "bob"}, false},
+		{"header", flags.Flag{Enabled: true, Headers: map[string]string{"X-Beta": "yes"}}, flags.Subject{Header: header}, true},
+		{"header value", flags.Flag{Enabled: true, Headers: map[string]string{"X-Beta": "no"}}, flags.Subject{Header: header}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.flag.Evaluate(tt.subject))
+		})
+	}
+}
+
+func TestFlag_EvaluatePercentage(t *testing.T) {
+	flag := flags.Flag{Name: "newsearch", Enabled: true, Percentage: 30}
+	on := map[string]bool{}
+	for i := range 1000 {
+		id := "user-" + strconv.Itoa(i)
+		if flag.Evaluate(flags.Subject{Identity: id}) {
+			on[id] = true
+		}
+	}
+	assert.InDelta(t, 300, len(on), 60)
+
+	// sticky: raising the percentage keeps the callers already on
+	flag.Percentage = 60
+	for id := range on {
+		assert.True(t, flag.Evaluate(flags.Subject{Identity: id}), id)
+	}
+
+	// anonymous callers are rolled out by IP
+	flag.Percentage = 50
+	ip := flags.Subject{IP: "192.0.2.1"}
+	for range 10 {
+		assert.Equal(t, flag.Evaluate(ip), flag.Evaluate(ip))
+	}
+}
+
+func TestUpdateFlagRequest_ToFlag(t *testing.T) {
+	enabled, negative := true, -1
+	tests := []struct {
+		name    string
+		flag    string
+		req     flags.UpdateFlagRequest
+		wantErr bool
+	}{
+		{"valid", "new-search", flags.UpdateFlagRequest{Enabled: &enabled}, false},
+		{"invalid name", "New Search", flags.UpdateFlagRequest{Enabled: &enabled}, true},
+		{"name too long", strings.Repeat("a", flags.MaxNameLength+1), flags.UpdateFlagRequest{Enabled: &enabled}, true},
+		{"enabled missing", "books", flags.UpdateFlagRequest{}, true},
+		{"percentage", "books", flags.UpdateFlagRequest{Enabled: &enabled, Percentage: &negative}, true},
+		{"empty identity", "books", flags.UpdateFlagRequest{Enabled: &enabled, Identities: []string{""}}, true},
+		{"invalid header", "books", flags.UpdateFlagRequest{Enabled: &enabled, Headers: map[string]string{"X Beta": "1"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.req.ToFlag(tt.flag)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, apperror.IsValidationError(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	flag, err := (&flags.UpdateFlagRequest{
+		Enabled:    &enabled,
+		Identities: []string{"bob", "alice", "bob"},
+		Headers:    map[string]string{"x-beta": "yes"},
+	}).ToFlag("books")
+	require.NoError(t, err)
+	assert.Equal(t, flags.MaxPercentage, flag.Percentage)
+	assert.Equal(t, []string{"alice", "bob"}, flag.Identities)
+	assert.Equal(t, map[string]string{"X-Beta": "yes"}, flag.Headers)
+}
+
+func TestStore_Precedence(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRepository(&flags.Flag{Name: "db", Enabled: true, Percentage: 100})
+	store := flags.NewStore(repo,
+		flags.WithDefaults(map[string]bool{"books": true, "configured": true}),
+		flags.WithConfig(map[string]bool{"configured": false, "db": false}))
+
+	sources := func() map[string]flags.Source {
+		list, err := store.List(ctx)
+		require.NoError(t, err)
+		sources := map[string]flags.Source{}
+		for _, flag := range list {
+			sources[flag.Name] = flag.Source
+		}
+		return sources
+	}
+	assert.Equal(t, map[string]flags.Source{
+		"books": flags.SourceDefault, "configured": flags.SourceConfig, "db": flags.SourceConfig,
+	}, sources())
+
+	require.NoError(t, store.Refresh(ctx))
+	assert.Equal(t, flags.SourceDatabase, sources()["db"])
+	assert.Equal(t, map[string]bool{"books": true, "configured": false, "db": true}, store.Evaluate(flags.Subject{}))
+
+	store.SetConfig(nil)
+	assert.Equal(t, map[string]bool{"books": true, "configured": true, "db": true}, store.Evaluate(flags.Subject{}))
+}
+
+func TestStore_Run(t *testing.T) {
+	repo := newFakeRepository()
+	store := flags.NewStore(repo, flags.WithRefreshInterval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go store.Run(ctx)
+
+	// changed by another instance
+	_, err := repo.Upsert(ctx, &flags.Flag{Name: "newsearch", Enabled: true, Percentage: 100, Source: flags.SourceDatabase})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return store.Evaluate(flags.Subject{})["newsearch"]
+	}, time.Second, 10*time.Millisecond)
+}
+
+// newServer serves the administration of store and a route gated by the
+// books flag.
+func newServer(store *flags.Store) http.Handler {
+	handler := flags.NewHandler(store)
+	r := chi.NewRouter()
+	r.Use(store.Middleware)
+	r.With(flags.Require("books")).Get("/books", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Get("/admin/flags", handler.List)
+	r.Get("/admin/flags/{name}", handler.Get)
+	r.Put("/admin/flags/{name}", handler.Update)
+	r.Delete("/admin/flags/{name}", handler.Delete)
+	return r
+}
+
+func do(t *testing.T, h http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	repo := newFakeRepository()
+	store := flags.NewStore(repo, flags.WithDefaults(map[string]bool{"books": true}))
+	srv := newServer(store)
+	admin := http.Header{middleware.CallerHeader: {"admin"}}
+	alice := http.Header{middleware.CallerHeader: {"alice"}}
+
+	assert.Equal(t, http.StatusOK, do(t, srv, http.MethodGet, "/books", "", nil).Code)
+
+	// disabled except for alice
+	rec := do(t, srv, http.MethodPut, "/admin/flags/books", `{"enabled":true,"percentage":0,"identities":["alice"]}`, admin)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"source":"database"`)
+	assert.Contains(t, rec.Body.String(), `"updated_by":"admin"`)
+	assert.Equal(t, "admin", repo.flags["books"].UpdatedBy)
+
+	rec = do(t, srv, http.MethodGet, "/books", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), string(apperror.ErrCodeNotFound))
+	assert.Equal(t, http.StatusOK, do(t, srv, http.MethodGet, "/books", "", alice).Code)
+
+	rec = do(t, srv, http.MethodGet, "/admin/flags", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"identities":["alice"]`)
+
+	assert.Equal(t, http.StatusBadRequest,
+		do(t, srv, http.MethodPut, "/admin/flags/books", `{"enabled":true,"percentage":101}`, admin).Code)
+	assert.Equal(t, http.StatusBadRequest, do(t, srv, http.MethodPut, "/admin/flags/books", `{`, admin).Code)
+
+	// deleting restores the default
+	assert.Equal(t, http.StatusNoContent, do(t, srv, http.MethodDelete, "/admin/flags/books", "", admin).Code)
+	assert.Equal(t, http.StatusOK, do(t, srv, http.MethodGet, "/books", "", nil).Code)
+	rec = do(t, srv, http.MethodGet, "/admin/flags/books", "", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"source":"default"`)
+
+	assert.Equal(t, http.StatusNotFound, do(t, srv, http.MethodDelete, "/admin/flags/books", "", admin).Code)
+	assert.Equal(t, http.StatusNotFound, do(t, srv, http.MethodGet, "/admin/flags/unknown", "", nil).Code)
+}
@@ -0,0 +1,89 @@
+package flags
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+)
+
+// Handler handles HTTP requests for the administration of the flags.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new feature flag handler.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List handles GET /admin/flags.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	flags, err := h.service.List(r.Context())
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	responses := make([]*FlagResponse, len(flags))
+	for i, flag := range flags {
+		responses[i] = flag.ToResponse()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Get handles GET /admin/flags/{name}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	flag, err := h.service.Get(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(flag.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Update handles PUT /admin/flags/{name}, the caller is recorded as the
+// author of the change.
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	var req UpdateFlagRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	flag, err := h.service.Update(r.Context(), chi.URLParam(r, "name"), &req, middleware.Caller(r))
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(flag.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Delete handles DELETE /admin/flags/{name}.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Delete(r.Context(), chi.URLParam(r, "name")); err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
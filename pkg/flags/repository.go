@@ -0,0 +1,90 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// Repository defines the interface for feature flag data access.
+type Repository interface {
+	List(ctx context.Context) ([]*Flag, error)
+	// Upsert creates or replaces the flag.
+	Upsert(ctx context.Context, flag *Flag) (*Flag, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// repositoryImpl wraps sqlc-generated queries.
+type repositoryImpl struct {
+	queries repository.Querier
+}
+
+// NewRepository creates a new feature flag repository.
+func NewRepository(queries repository.Querier) Repository {
+	return &repositoryImpl{queries: queries}
+}
+
+func (r *repositoryImpl) List(ctx context.Context) ([]*Flag, error) {
+	dbFlags, err := r.queries.ListFeatureFlags(ctx)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	flags := make([]*Flag, len(dbFlags))
+	for i, dbFlag := range dbFlags {
+		if flags[i], err = toFlag(dbFlag); err != nil {
+			return nil, err
+		}
+	}
+	return flags, nil
+}
+
+func (r *repositoryImpl) Upsert(ctx context.Context, flag *Flag) (*Flag, error) {
+	headers, err := json.Marshal(flag.Headers)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	dbFlag, err := r.queries.UpsertFeatureFlag(ctx, repository.UpsertFeatureFlagParams{
+		Name:        flag.Name,
+		Description: flag.Description,
+		Enabled:     flag.Enabled,
+		Percentage:  int16(flag.Percentage), //nolint:gosec // validated, at most MaxPercentage
+		Identities:  flag.Identities,
+		Headers:     headers,
+		UpdatedBy:   flag.UpdatedBy,
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return toFlag(dbFlag)
+}
+
+func (r *repositoryImpl) Delete(ctx context.Context, name string) error {
+	deleted, err := r.queries.DeleteFeatureFlag(ctx, name)
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	if deleted == 0 {
+		return apperror.NewNotFoundError("Feature flag not defined in the database")
+	}
+	return nil
+}
+
+func toFlag(dbFlag repository.FeatureFlag) (*Flag, error) {
+	var headers map[string]string
+	if err := json.Unmarshal(dbFlag.Headers, &headers); err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return &Flag{
+		Name:        dbFlag.Name,
+		Description: dbFlag.Description,
+		Enabled:     dbFlag.Enabled,
+		Percentage:  int(dbFlag.Percentage),
+		Identities:  dbFlag.Identities,
+		Headers:     headers,
+		Source:      SourceDatabase,
+		UpdatedBy:   dbFlag.UpdatedBy,
+		UpdatedAt:   dbFlag.UpdatedAt,
+	}, nil
+}
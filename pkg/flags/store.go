@@ -0,0 +1,207 @@
+package flags
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// DefaultRefreshInterval is the interval between two reloads of the
+// database flags, changed by other instances.
+const DefaultRefreshInterval = 10 * time.Second
+
+// Service provides the administration of the flags.
+type Service interface {
+	// List returns the effective flags, sorted by name.
+	List(ctx context.Context) ([]*Flag, error)
+	Get(ctx context.Context, name string) (*Flag, error)
+	// Update defines the flag in the database, overriding its default and
+	// configuration.
+	Update(ctx context.Context, name string, req *UpdateFlagRequest, updatedBy string) (*Flag, error)
+	// Delete removes the flag from the database, restoring its default or
+	// configuration.
+	Delete(ctx context.Context, name string) error
+}
+
+// Store holds the flags of every source and evaluates them. The database
+// flags are kept in memory, and reloaded by Run.
+type Store struct {
+	repo            Repository
+	refreshInterval time.Duration
+	defaults        map[string]bool
+
+	// mu serializes the changes of the sources.
+	mu       sync.Mutex
+	config   map[string]bool
+	database map[string]*Flag
+	// flags are the effective flags, merged from the sources.
+	flags atomic.Pointer[map[string]*Flag]
+}
+
+var _ Service = (*Store)(nil)
+
+// StoreOption configures a Store.
+type StoreOption func(*Store)
+
+// WithDefaults sets the default of the flags gating features, used when
+// they are neither configured nor in the database.
+func WithDefaults(defaults map[string]bool) StoreOption {
+	return func(s *Store) {
+		s.defaults = maps.Clone(defaults)
+	}
+}
+
+// WithConfig sets the configured flags, see SetConfig.
+func WithConfig(config map[string]bool) StoreOption {
+	return func(s *Store) {
+		s.config = maps.Clone(config)
+	}
+}
+
+// WithRefreshInterval sets the interval between two reloads of the
+// database flags. Values lower or equal to zero are ignored.
+func WithRefreshInterval(interval time.Duration) StoreOption {
+	return func(s *Store) {
+		if interval > 0 {
+			s.refreshInterval = interval
+		}
+	}
+}
+
+// NewStore creates a store of the flags. The database flags are loaded by
+// Refresh or Run.
+func NewStore(repo Repository, opts ...StoreOption) *Store {
+	s := &Store{
+		repo:            repo,
+		refreshInterval: DefaultRefreshInterval,
+		database:        map[string]*Flag{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.merge()
+	return s
+}
+
+// SetConfig replaces the configured flags, e.g. on configuration reload.
+func (s *Store) SetConfig(config map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = maps.Clone(config)
+	s.merge()
+}
+
+// Refresh reloads the database flags.
+func (s *Store) Refresh(ctx context.Context) error {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.database = make(map[string]*Flag, len(list))
+	for _, flag := range list {
+		s.database[flag.Name] = flag
+	}
+	s.merge()
+	return nil
+}
+
+// Run reloads the database flags periodically until ctx is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("feature flags refresh failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// merge computes the effective flags, s.mu being held.
+func (s *Store) merge() {
+	flags := make(map[string]*Flag, len(s.defaults)+len(s.config)+len(s.database))
+	for name, enabled := range s.defaults {
+		flags[name] = &Flag{Name: name, Enabled: enabled, Percentage: MaxPercentage, Source: SourceDefault}
+	}
+	for name, enabled := range s.config {
+		flags[name] = &Flag{Name: name, Enabled: enabled, Percentage: MaxPercentage, Source: SourceConfig}
+	}
+	maps.Copy(flags, s.database)
+	s.flags.Store(&flags)
+}
+
+// Evaluate returns the flags for s.
+func (s *Store) Evaluate(subject Subject) map[string]bool {
+	flags := *s.flags.Load()
+	evaluated := make(map[string]bool, len(flags))
+	for name, flag := range flags {
+		evaluated[name] = flag.Evaluate(subject)
+	}
+	return evaluated
+}
+
+// Middleware evaluates the flags for each request and exposes them in its
+// context, see Enabled.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), s.Evaluate(SubjectOf(r)))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (s *Store) List(_ context.Context) ([]*Flag, error) {
+	return slices.SortedFunc(maps.Values(*s.flags.Load()), func(a, b *Flag) int {
+		return strings.Compare(a.Name, b.Name)
+	}), nil
+}
+
+func (s *Store) Get(_ context.Context, name string) (*Flag, error) {
+	flag, ok := (*s.flags.Load())[name]
+	if !ok {
+		return nil, apperror.NewNotFoundError("Feature flag not found")
+	}
+	return flag, nil
+}
+
+func (s *Store) Update(ctx context.Context, name string, req *UpdateFlagRequest, updatedBy string) (*Flag, error) {
+	flag, err := req.ToFlag(name)
+	if err != nil {
+		return nil, err
+	}
+	flag.UpdatedBy = updatedBy
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated, err := s.repo.Upsert(ctx, flag)
+	if err != nil {
+		return nil, err
+	}
+	s.database[name] = updated
+	s.merge()
+	return updated, nil
+}
+
+func (s *Store) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+	delete(s.database, name)
+	s.merge()
+	return nil
+}
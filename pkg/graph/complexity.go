@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listSize is the number of items assumed for list fields when estimating
+// the complexity of a query.
+const listSize = 10
+
+// complexity estimates the cost of executing an operation: each field
+// costs 1, and the selections of list fields are counted listSize times.
+// The estimation stops once it exceeds limit. Documents which can't be
+// parsed or whose operation is ambiguous cost 0, graphql-go reports them.
+type complexity struct {
+	schema *ast.Schema
+	limit  int
+}
+
+func (c *complexity) estimate(query, operationName string) int {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0
+	}
+
+	var op *ast.OperationDefinition
+	switch {
+	case operationName != "":
+		op = doc.Operations.ForName(operationName)
+	case len(doc.Operations) == 1:
+		op = doc.Operations[0]
+	}
+	if op == nil {
+		return 0
+	}
+
+	root := c.schema.Query
+	if op.Operation == ast.Mutation {
+		root = c.schema.Mutation
+	}
+	return c.selectionSet(doc, op.SelectionSet, root, map[string]bool{})
+}
+
+func (c *complexity) selectionSet(doc *ast.QueryDocument, set ast.SelectionSet, typ *ast.Definition, spread map[string]bool) int {
+	if typ == nil {
+		return 0
+	}
+	cost := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			cost += c.field(doc, s, typ, spread)
+		case *ast.InlineFragment:
+			fragmentType := typ
+			if s.TypeCondition != "" {
+				fragmentType = c.schema.Types[s.TypeCondition]
+			}
+			cost += c.selectionSet(doc, s.SelectionSet, fragmentType, spread)
+		case *ast.FragmentSpread:
+			fragment := doc.Fragments.ForName(s.Name)
+			if fragment == nil || spread[s.Name] {
+				// Unknown and cyclic fragments are rejected by validation.
+				continue
+			}
+			spread[s.Name] = true
+			cost += c.selectionSet(doc, fragment.SelectionSet, c.schema.Types[fragment.TypeCondition], spread)
+			delete(spread, s.Name)
+		}
+		if cost > c.limit {
+			return cost
+		}
+	}
+	return cost
+}
+
+func (c *complexity) field(doc *ast.QueryDocument, f *ast.Field, typ *ast.Definition, spread map[string]bool) int {
+	definition := typ.Fields.ForName(f.Name)
+	if definition == nil || len(f.SelectionSet) == 0 {
+		return 1
+	}
+	cost := c.selectionSet(doc, f.SelectionSet, c.schema.Types[definition.Type.Name()], spread)
+	if isList(definition.Type) {
+		cost *= listSize
+	}
+	return 1 + cost
+}
+
+func isList(t *ast.Type) bool {
+	return t != nil && t.Elem != nil
+}
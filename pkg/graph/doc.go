@@ -0,0 +1,8 @@
+// Package graph serves the authors and books over GraphQL.
+//
+// Resolvers delegate to the authors and books services. The lookups of
+// Author.books and Book.author are batched per request by loaders so that
+// listing n authors with their books runs two queries instead of n+1.
+// Queries are rejected when they are nested too deeply or when their
+// estimated complexity exceeds a limit.
+package graph
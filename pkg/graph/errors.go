@@ -0,0 +1,38 @@
+package graph
+
+import (
+	"log/slog"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// resolverError exposes an error as the JSON API does: its message, with
+// the error code and details as extensions.
+type resolverError struct {
+	resp apperror.ErrorResponse
+}
+
+// toError converts the errors of the services, nil stays nil.
+func toError(err error) error {
+	if err == nil {
+		return nil
+	}
+	resp, _ := apperror.NewErrorResponse(err)
+	if resp.Code == apperror.ErrCodeInternal {
+		slog.Error("graphql resolver failed", "error", err)
+	}
+	return &resolverError{resp: resp}
+}
+
+func (e *resolverError) Error() string {
+	return e.resp.Message
+}
+
+// Extensions implements the resolver error extensions of graphql-go.
+func (e *resolverError) Extensions() map[string]any {
+	extensions := map[string]any{"code": e.resp.Code}
+	if len(e.resp.Details) > 0 {
+		extensions["details"] = e.resp.Details
+	}
+	return extensions
+}
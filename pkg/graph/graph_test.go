@@ -0,0 +1,267 @@
+package graph_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/sgaunet/template-api/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuthorsService serves fixed authors and counts batched lookups.
+type fakeAuthorsService struct {
+	authors.Service
+
+	authors  []*authors.Author
+	getByIDs atomic.Int32
+}
+
+func (s *fakeAuthorsService) List(_ context.Context) ([]*authors.Author, error) {
+	return s.authors, nil
+}
+
+func (s *fakeAuthorsService) GetByIDs(_ context.Context, ids []int64) ([]*authors.Author, error) {
+	s.getByIDs.Add(1)
+	var found []*authors.Author
+	for _, author := range s.authors {
+		for _, id := range ids {
+			if author.ID == id {
+				found = append(found, author)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *fakeAuthorsService) Create(_ context.Context, req *authors.CreateAuthorRequest) (*authors.Author, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return &authors.Author{ID: 99, Name: req.Name, Bio: req.Bio}, nil
+}
+
+// fakeBooksService serves fixed books and counts batched lookups.
+type fakeBooksService struct {
+	books.Service
+
+	books         []*books.Book
+	listByAuthors atomic.Int32
+}
+
+func (s *fakeBooksService) List(_ context.Context) ([]*books.Book, error) {
+	return s.books, nil
+}
+
+func (s *fakeBooksService) ListByAuthors(_ context.Context, authorIDs []int64) ([]*books.Book, error) {
+	s.listByAuthors.Add(1)
+	var found []*books.Book
+	for _, book := range s.books {
+		for _, id := range authorIDs {
+			if book.AuthorID == id {
+				found = append(found, book)
+			}
+		}
+	}
+	return found, nil
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newServices() (*fakeAuthorsService, *fakeBooksService) {
+	authorsService := &fakeAuthorsService{authors: []*authors.Author{
+		{ID: 1, Name: "Frank Herbert", Bio: "Dune"},
+		{ID: 2, Name: "Jane Austen"},
+		{ID: 3, Name: "Homer"},
+	}}
+	booksService := &fakeBooksService{books: []*books.Book{
+		{ID: 10, Title: "Dune", AuthorID: 1},
+		{ID: 11, Title: "Dune Messiah", AuthorID: 1},
+		{ID: 12, Title: "Emma", AuthorID: 2},
+	}}
+	return authorsService, booksService
+}
+
+func query(t *testing.T, h *graph.Handler, q string) response {
+	t.Helper()
+	body, err := json.Marshal(graph.Request{Query: q})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.Serve(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestHandler_AuthorsWithBooks(t *testing.T) {
+	authorsService, booksService := newServices()
+	h := graph.NewHandler(authorsService, booksService)
+
+	resp := query(t, h, `{ authors { id name books { title author { name } } } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"authors": [
+		{"id": "1", "name": "Frank Herbert", "books": [
+			{"title": "Dune", "author": {"name": "Frank Herbert"}},
+			{"title": "Dune Messiah", "author": {"name": "Frank Herbert"}}
+		]},
+		{"id": "2", "name": "Jane Austen", "books": [{"title": "Emma", "author": {"name": "Jane Austen"}}]},
+		{"id": "3", "name": "Homer", "books": []}
+	]}`, string(resp.Data))
+	assert.Equal(t, int32(1), booksService.listByAuthors.Load(), "books are loaded in one batch")
+	assert.Equal(t, int32(1), authorsService.getByIDs.Load(), "authors are loaded in one batch")
+}
+
+func TestHandler_Author(t *testing.T) {
+	authorsService, booksService := newServices()
+	h := graph.NewHandler(authorsService, booksService)
+
+	resp := query(t, h, `{ a: author(id: "2") { name } b: author(id: "42") { name } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"a": {"name": "Jane Austen"}, "b": null}`, string(resp.Data))
+	assert.Equal(t, int32(1), authorsService.getByIDs.Load())
+
+	resp = query(t, h, `{ author(id: "abc") { name } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, string(apperror.ErrCodeValidation), resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_LoaderMaxBatch(t *testing.T) {
+	authorsService, booksService := newServices()
+	h := graph.NewHandler(authorsService, booksService, graph.WithLoaderMaxBatch(2))
+
+	resp := query(t, h, `{ a: author(id: "1") { name } b: author(id: "2") { name } c: author(id: "3") { name } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"a": {"name": "Frank Herbert"}, "b": {"name": "Jane Austen"}, "c": {"name": "Homer"}}`,
+		string(resp.Data))
+	assert.Equal(t, int32(2), authorsService.getByIDs.Load(), "3 authors in batches of 2")
+}
+
+func TestHandler_BooksFlag(t *testing.T) {
+	authorsService, booksService := newServices()
+	h := graph.NewHandler(authorsService, booksService, graph.WithBooksFlag("books"))
+	queryWithFlags := func(q string, enabled bool) response {
+		t.Helper()
+		body, err := json.Marshal(graph.Request{Query: q})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		req = req.WithContext(flags.NewContext(req.Context(), map[string]bool{"books": enabled}))
+		rec := httptest.NewRecorder()
+		h.Serve(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp
+	}
+
+	resp := queryWithFlags(`{ books { title } }`, true)
+	require.Empty(t, resp.Errors)
+
+	for _, q := range []string{
+		`{ books { title } }`,
+		`{ author(id: "1") { books { title } } }`,
+		`mutation { createBook(input: {title: "Dune", authorId: "1"}) { id } }`,
+	} {
+		resp = queryWithFlags(q, false)
+		require.Len(t, resp.Errors, 1, q)
+		assert.Equal(t, string(apperror.ErrCodeNotFound), resp.Errors[0].Extensions["code"], q)
+	}
+}
+
+func TestHandler_CreateAuthor(t *testing.T) {
+	h := graph.NewHandler(newServices())
+
+	resp := query(t, h, `mutation { createAuthor(input: {name: "Ursula Le Guin"}) { id name bio } }`)
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"createAuthor": {"id": "99", "name": "Ursula Le Guin", "bio": ""}}`, string(resp.Data))
+
+	resp = query(t, h, `mutation { createAuthor(input: {name: "Ann"}) { id } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, string(apperror.ErrCodeValidation), resp.Errors[0].Extensions["code"])
+	assert.Equal(t, map[string]any{"field": "name", "min": "5", "value": "3"}, resp.Errors[0].Extensions["details"])
+}
+
+func TestHandler_Limits(t *testing.T) {
+	authorsService, booksService := newServices()
+	h := graph.NewHandler(authorsService, booksService, graph.WithMaxDepth(4), graph.WithMaxComplexity(1_000_000))
+	resp := query(t, h, `{ authors { books { author { books { title } } } } }`)
+	require.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0].Message, "depth")
+
+	h = graph.NewHandler(authorsService, booksService, graph.WithMaxComplexity(100))
+	resp = query(t, h, `{ authors { name books { title author { name } } } }`)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "query complexity exceeds the limit of 100", resp.Errors[0].Message)
+	assert.Equal(t, string(apperror.ErrCodeValidation), resp.Errors[0].Extensions["code"])
+
+	resp = query(t, h, `{ authors { name books { title } } author(id: "1") { name } }`)
+	require.Len(t, resp.Errors, 1, "list selections are counted once per assumed item")
+
+	resp = query(t, h, `{ authors { name } author(id: "1") { name bio } }`)
+	assert.Empty(t, resp.Errors)
+}
+
+func TestHandler_InvalidRequest(t *testing.T) {
+	h := graph.NewHandler(newServices())
+	rec := httptest.NewRecorder()
+	h.Serve(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestLoader(t *testing.T) {
+	var batches [][]int
+	var mu sync.Mutex
+	loader := graph.NewLoader(func(_ context.Context, keys []int) (map[int]string, error) {
+		mu.Lock()
+		batches = append(batches, keys)
+		mu.Unlock()
+		values := map[int]string{}
+		for _, key := range keys {
+			if key%2 == 0 {
+				values[key] = "even"
+			}
+		}
+		return values, nil
+	}, 10*time.Millisecond, 3)
+
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range 5 {
+		wg.Go(func() {
+			value, err := loader.Load(context.Background(), i)
+			assert.NoError(t, err)
+			results[i] = value
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"even", "", "even", "", "even"}, results)
+
+	// Loaded keys are cached.
+	value, err := loader.Load(context.Background(), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "even", value)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, batches, 2, "5 keys in batches of 3")
+	assert.Len(t, batches[0], 3)
+	assert.Len(t, batches[1], 2)
+}
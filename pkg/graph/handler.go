@@ -0,0 +1,146 @@
+package graph
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Schema is the GraphQL schema of the API.
+//
+//go:embed schema.graphql
+var Schema string
+
+// Default limits of queries.
+const (
+	DefaultMaxDepth      = 8
+	DefaultMaxComplexity = 2000
+)
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Handler handles GraphQL requests.
+type Handler struct {
+	authors    authors.Service
+	books      books.Service
+	schema     *graphql.Schema
+	complexity *complexity
+	maxDepth   int
+	maxBatch   int
+	booksFlag  string
+}
+
+// Option configures the handler.
+type Option func(*Handler)
+
+// WithMaxDepth sets the maximum nesting of fields in a query.
+// Values lower or equal to zero are ignored.
+func WithMaxDepth(depth int) Option {
+	return func(h *Handler) {
+		if depth > 0 {
+			h.maxDepth = depth
+		}
+	}
+}
+
+// WithMaxComplexity sets the maximum estimated complexity of a query.
+// Values lower or equal to zero are ignored.
+func WithMaxComplexity(complexity int) Option {
+	return func(h *Handler) {
+		if complexity > 0 {
+			h.complexity.limit = complexity
+		}
+	}
+}
+
+// WithLoaderMaxBatch sets the maximum number of keys the loaders fetch in
+// one call of the services, it must not exceed their batch limits.
+// Values lower or equal to zero are ignored.
+func WithLoaderMaxBatch(size int) Option {
+	return func(h *Handler) {
+		if size > 0 {
+			h.maxBatch = size
+		}
+	}
+}
+
+// WithBooksFlag gates the books behind the feature flag name, evaluated
+// for each request by flags.Store.Middleware, as the books routes: while
+// it is off, the books fields and mutations fail with NOT_FOUND errors.
+func WithBooksFlag(name string) Option {
+	return func(h *Handler) {
+		h.booksFlag = name
+	}
+}
+
+// NewHandler creates a GraphQL handler resolving with the services.
+func NewHandler(authorsService authors.Service, booksService books.Service, opts ...Option) *Handler {
+	h := &Handler{
+		authors:    authorsService,
+		books:      booksService,
+		complexity: &complexity{schema: gqlparser.MustLoadSchema(&ast.Source{Input: Schema}), limit: DefaultMaxComplexity},
+		maxDepth:   DefaultMaxDepth,
+		maxBatch:   DefaultLoaderMaxBatch,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.schema = graphql.MustParseSchema(Schema,
+		&resolver{authors: authorsService, books: booksService},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(h.maxDepth),
+	)
+	return h
+}
+
+// Serve handles POST /graphql. Errors of the query are returned in the
+// errors of the response, with the error code of the JSON API in their
+// extensions.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+	if req.Query == "" {
+		apperror.WriteError(w, apperror.NewValidationError(
+			"Query is empty",
+			map[string]string{"field": "query"},
+		))
+		return
+	}
+
+	var resp *graphql.Response
+	if cost := h.complexity.estimate(req.Query, req.OperationName); cost > h.complexity.limit {
+		resp = &graphql.Response{Errors: []*gqlerrors.QueryError{{
+			Message:    fmt.Sprintf("query complexity exceeds the limit of %d", h.complexity.limit),
+			Extensions: map[string]any{"code": apperror.ErrCodeValidation},
+		}}}
+	} else {
+		ctx := withLoaders(r.Context(), newLoaders(h.authors, h.books, h.maxBatch))
+		ctx = withBooksEnabled(ctx, h.booksFlag == "" || flags.Enabled(ctx, h.booksFlag))
+		resp = h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
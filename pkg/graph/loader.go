@@ -0,0 +1,112 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Default batching of loaders.
+const (
+	DefaultLoaderWait     = 2 * time.Millisecond
+	DefaultLoaderMaxBatch = 100
+)
+
+// Loader batches the lookups of keys made within a short window into a
+// single fetch. Results are kept for the lifetime of the loader, which is
+// created for each request.
+type Loader[K comparable, V any] struct {
+	fetch    func(ctx context.Context, keys []K) (map[K]V, error)
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	calls   map[K]*call[V]
+	pending []K
+}
+
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// NewLoader creates a loader fetching batches of at most maxBatch keys.
+// fetch omits the keys without value from the map, they load as the zero
+// value of V.
+func NewLoader[K comparable, V any](
+	fetch func(ctx context.Context, keys []K) (map[K]V, error), wait time.Duration, maxBatch int,
+) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		calls:    map[K]*call[V]{},
+	}
+}
+
+// Load returns the value of key, waiting for the batch it belongs to.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	c := l.enqueue(ctx, key)
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// Prefetch adds keys to the next batch without waiting, so that the
+// resolvers of a list loading them share a single fetch even when they
+// don't run concurrently.
+func (l *Loader[K, V]) Prefetch(ctx context.Context, keys ...K) {
+	for _, key := range keys {
+		l.enqueue(ctx, key)
+	}
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *call[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.calls[key]; ok {
+		return c
+	}
+	c := &call[V]{done: make(chan struct{})}
+	l.calls[key] = c
+	l.pending = append(l.pending, key)
+
+	switch {
+	case len(l.pending) >= l.maxBatch:
+		keys := l.pending
+		l.pending = nil
+		go l.dispatch(ctx, keys)
+	case len(l.pending) == 1:
+		time.AfterFunc(l.wait, func() {
+			l.mu.Lock()
+			keys := l.pending
+			l.pending = nil
+			l.mu.Unlock()
+			l.dispatch(ctx, keys)
+		})
+	}
+	return c
+}
+
+// dispatch fetches keys and completes their calls.
+func (l *Loader[K, V]) dispatch(ctx context.Context, keys []K) {
+	if len(keys) == 0 {
+		// Already dispatched because the batch was full.
+		return
+	}
+	values, err := l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		c := l.calls[key]
+		c.value, c.err = values[key], err
+		close(c.done)
+	}
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+)
+
+// loaders are the loaders of a request.
+type loaders struct {
+	authors       *Loader[int64, *authors.Author]
+	booksByAuthor *Loader[int64, []*books.Book]
+}
+
+type loadersKey struct{}
+
+func newLoaders(authorsService authors.Service, booksService books.Service, maxBatch int) *loaders {
+	return &loaders{
+		authors: NewLoader(func(ctx context.Context, ids []int64) (map[int64]*authors.Author, error) {
+			list, err := authorsService.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err //nolint:wrapcheck // converted by toError
+			}
+			byID := make(map[int64]*authors.Author, len(list))
+			for _, author := range list {
+				byID[author.ID] = author
+			}
+			return byID, nil
+		}, DefaultLoaderWait, maxBatch),
+		booksByAuthor: NewLoader(func(ctx context.Context, authorIDs []int64) (map[int64][]*books.Book, error) {
+			list, err := booksService.ListByAuthors(ctx, authorIDs)
+			if err != nil {
+				return nil, err //nolint:wrapcheck // converted by toError
+			}
+			byAuthor := make(map[int64][]*books.Book, len(authorIDs))
+			for _, book := range list {
+				byAuthor[book.AuthorID] = append(byAuthor[book.AuthorID], book)
+			}
+			return byAuthor, nil
+		}, DefaultLoaderWait, maxBatch),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
+
+type booksEnabledKey struct{}
+
+func withBooksEnabled(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, booksEnabledKey{}, enabled)
+}
+
+// requireBooks returns a NOT_FOUND error while the books are off for the
+// request of ctx, see WithBooksFlag.
+func requireBooks(ctx context.Context) error {
+	if enabled, _ := ctx.Value(booksEnabledKey{}).(bool); !enabled {
+		return toError(apperror.NewNotFoundError("Not found"))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"context"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+)
+
+// resolver resolves the Query and Mutation root types.
+type resolver struct {
+	authors authors.Service
+	books   books.Service
+}
+
+func (r *resolver) Authors(ctx context.Context) ([]*authorResolver, error) {
+	list, err := r.authors.List(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if graphql.HasSelectedField(ctx, "books") && requireBooks(ctx) == nil {
+		ids := make([]int64, len(list))
+		for i, author := range list {
+			ids[i] = author.ID
+		}
+		loadersFrom(ctx).booksByAuthor.Prefetch(ctx, ids...)
+	}
+	return authorResolvers(list), nil
+}
+
+func (r *resolver) Author(ctx context.Context, args struct{ ID graphql.ID }) (*authorResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	author, err := loadersFrom(ctx).authors.Load(ctx, id)
+	if err != nil || author == nil {
+		return nil, toError(err)
+	}
+	return &authorResolver{author: author}, nil
+}
+
+func (r *resolver) Books(ctx context.Context) ([]*bookResolver, error) {
+	if err := requireBooks(ctx); err != nil {
+		return nil, err
+	}
+	list, err := r.books.List(ctx)
+	if err != nil {
+		return nil, toError(err)
+	}
+	if graphql.HasSelectedField(ctx, "author") {
+		ids := make([]int64, len(list))
+		for i, book := range list {
+			ids[i] = book.AuthorID
+		}
+		loadersFrom(ctx).authors.Prefetch(ctx, ids...)
+	}
+	return bookResolvers(list), nil
+}
+
+type createAuthorInput struct {
+	Name string
+	Bio  *string
+}
+
+func (r *resolver) CreateAuthor(ctx context.Context, args struct{ Input createAuthorInput }) (*authorResolver, error) {
+	req := &authors.CreateAuthorRequest{Name: args.Input.Name}
+	if args.Input.Bio != nil {
+		req.Bio = *args.Input.Bio
+	}
+	author, err := r.authors.Create(ctx, req)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &authorResolver{author: author}, nil
+}
+
+func (r *resolver) DeleteAuthor(ctx context.Context, args struct{ ID graphql.ID }) (bool, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return false, toError(err)
+	}
+	if err := r.authors.Delete(ctx, id); err != nil {
+		return false, toError(err)
+	}
+	return true, nil
+}
+
+type createBookInput struct {
+	Title    string
+	AuthorID graphql.ID
+}
+
+func (r *resolver) CreateBook(ctx context.Context, args struct{ Input createBookInput }) (*bookResolver, error) {
+	if err := requireBooks(ctx); err != nil {
+		return nil, err
+	}
+	authorID, err := parseID(args.Input.AuthorID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	book, err := r.books.Create(ctx, &books.CreateBookRequest{Title: args.Input.Title, AuthorID: authorID})
+	if err != nil {
+		return nil, toError(err)
+	}
+	return &bookResolver{book: book}, nil
+}
+
+type authorResolver struct {
+	author *authors.Author
+}
+
+func (r *authorResolver) ID() graphql.ID {
+	return formatID(r.author.ID)
+}
+
+func (r *authorResolver) Name() string {
+	return r.author.Name
+}
+
+func (r *authorResolver) Bio() string {
+	return r.author.Bio
+}
+
+func (r *authorResolver) Books(ctx context.Context) ([]*bookResolver, error) {
+	if err := requireBooks(ctx); err != nil {
+		return nil, err
+	}
+	list, err := loadersFrom(ctx).booksByAuthor.Load(ctx, r.author.ID)
+	if err != nil {
+		return nil, toError(err)
+	}
+	return bookResolvers(list), nil
+}
+
+type bookResolver struct {
+	book *books.Book
+}
+
+func (r *bookResolver) ID() graphql.ID {
+	return formatID(r.book.ID)
+}
+
+func (r *bookResolver) Title() string {
+	return r.book.Title
+}
+
+func (r *bookResolver) Author(ctx context.Context) (*authorResolver, error) {
+	author, err := loadersFrom(ctx).authors.Load(ctx, r.book.AuthorID)
+	if err != nil || author == nil {
+		return nil, toError(err)
+	}
+	return &authorResolver{author: author}, nil
+}
+
+func authorResolvers(list []*authors.Author) []*authorResolver {
+	resolvers := make([]*authorResolver, len(list))
+	for i, author := range list {
+		resolvers[i] = &authorResolver{author: author}
+	}
+	return resolvers
+}
+
+func bookResolvers(list []*books.Book) []*bookResolver {
+	resolvers := make([]*bookResolver, len(list))
+	for i, book := range list {
+		resolvers[i] = &bookResolver{book: book}
+	}
+	return resolvers
+}
+
+func parseID(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, apperror.NewValidationError(
+			"Invalid ID",
+			map[string]string{"field": "id", "value": string(id)},
+		)
+	}
+	return n, nil
+}
+
+func formatID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
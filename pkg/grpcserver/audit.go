@@ -0,0 +1,52 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/pkg/audit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// requestIDMetadata is the metadata key of the request ID, as the HTTP
+// header read by the request ID middleware.
+const requestIDMetadata = "x-request-id"
+
+// auditUnaryInterceptor records the origin of the calls, recorded in the
+// audit log with their changes: the caller is read from the metadata
+// named after middleware.CallerHeader.
+func auditUnaryInterceptor(
+	ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	var origin audit.Origin
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		origin.Actor = first(md.Get(strings.ToLower(middleware.CallerHeader)))
+		origin.RequestID = first(md.Get(requestIDMetadata))
+	}
+	origin.IP = peerIP(ctx)
+	return handler(audit.NewContext(ctx, origin), req)
+}
+
+// peerIP returns the IP address of the client of the call of ctx.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	ip := p.Addr.String()
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		return host
+	}
+	return ip
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,58 @@
+package grpcserver
+
+import (
+	"context"
+
+	catalogv1 "github.com/sgaunet/template-api/pkg/api/catalog/v1"
+	"github.com/sgaunet/template-api/pkg/authors"
+)
+
+// authorsServer implements catalogv1.AuthorsServiceServer with authors.Service.
+type authorsServer struct {
+	catalogv1.UnimplementedAuthorsServiceServer
+
+	service authors.Service
+}
+
+func (s *authorsServer) CreateAuthor(ctx context.Context, req *catalogv1.CreateAuthorRequest) (*catalogv1.Author, error) {
+	author, err := s.service.Create(ctx, &authors.CreateAuthorRequest{Name: req.GetName(), Bio: req.GetBio()})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // converted to a status by errorUnaryInterceptor
+	}
+	return toAuthor(author), nil
+}
+
+func (s *authorsServer) GetAuthor(ctx context.Context, req *catalogv1.GetAuthorRequest) (*catalogv1.Author, error) {
+	author, err := s.service.GetByID(ctx, req.GetId())
+	if err != nil {
+		return nil, err //nolint:wrapcheck // converted to a status by errorUnaryInterceptor
+	}
+	return toAuthor(author), nil
+}
+
+func (s *authorsServer) ListAuthors(
+	ctx context.Context, _ *catalogv1.ListAuthorsRequest,
+) (*catalogv1.ListAuthorsResponse, error) {
+	list, err := s.service.List(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // converted to a status by errorUnaryInterceptor
+	}
+	resp := &catalogv1.ListAuthorsResponse{Authors: make([]*catalogv1.Author, len(list))}
+	for i, author := range list {
+		resp.Authors[i] = toAuthor(author)
+	}
+	return resp, nil
+}
+
+func (s *authorsServer) DeleteAuthor(
+	ctx context.Context, req *catalogv1.DeleteAuthorRequest,
+) (*catalogv1.DeleteAuthorResponse, error) {
+	if err := s.service.Delete(ctx, req.GetId()); err != nil {
+		return nil, err //nolint:wrapcheck // converted to a status by errorUnaryInterceptor
+	}
+	return &catalogv1.DeleteAuthorResponse{}, nil
+}
+
+func toAuthor(a *authors.Author) *catalogv1.Author {
+	return &catalogv1.Author{Id: a.ID, Name: a.Name, Bio: a.Bio}
+}
@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"context"
+
+	catalogv1 "github.com/sgaunet/template-api/pkg/api/catalog/v1"
+	"github.com/sgaunet/template-api/pkg/books"
+	"google.golang.org/grpc"
+)
+
+// booksServer implements catalogv1.BooksServiceServer with books.Service.
+type booksServer struct {
+	catalogv1.UnimplementedBooksServiceServer
+
+	service books.Service
+}
+
+func (s *booksServer) CreateBook(ctx context.Context, req *catalogv1.CreateBookRequest) (*catalogv1.Book, error) {
+	book, err := s.service.Create(ctx, &books.CreateBookRequest{Title: req.GetTitle(), AuthorID: req.GetAuthorId()})
+	if err != nil {
+		return nil, err //nolint:wrapcheck // converted to a status by errorUnaryInterceptor
+	}
+	return toBook(book), nil
+}
+
+func (s *booksServer) ListBooks(_ *catalogv1.ListBooksRequest, stream grpc.ServerStreamingServer[catalogv1.Book]) error {
+	//nolint:wrapcheck // converted to a status by errorStreamInterceptor
+	return s.service.Export(stream.Context(), func(book *books.Book) error {
+		return stream.Send(toBook(book))
+	})
+}
+
+func toBook(b *books.Book) *catalogv1.Book {
+	return &catalogv1.Book{Id: b.ID, Title: b.Title, AuthorId: b.AuthorID}
+}
@@ -0,0 +1,126 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo error details.
+const ErrorDomain = "template-api"
+
+var errPanic = errors.New("panic recovered")
+
+// toStatus converts an error to a gRPC status with the same code, message
+// and details as the JSON error responses. Errors which are already a
+// status (e.g. cancellation) are kept.
+func toStatus(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	}
+
+	resp, _ := apperror.NewErrorResponse(err)
+	code := errorCodeToGRPCCode(resp.Code)
+	if code == codes.Internal {
+		slog.Error("grpc call failed", "error", err)
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   string(resp.Code),
+		Domain:   ErrorDomain,
+		Metadata: resp.Details,
+	}}
+	if resp.Code == apperror.ErrCodeValidation {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: resp.Details["field"], Description: resp.Message},
+			},
+		})
+	}
+	s, detailsErr := status.New(code, resp.Message).WithDetails(details...)
+	if detailsErr != nil {
+		return status.New(code, resp.Message)
+	}
+	return s
+}
+
+func errorCodeToGRPCCode(code apperror.ErrorCode) codes.Code {
+	switch code {
+	case apperror.ErrCodeValidation, apperror.ErrCodeBadRequest:
+		return codes.InvalidArgument
+	case apperror.ErrCodeNotFound:
+		return codes.NotFound
+	case apperror.ErrCodeConflict:
+		return codes.AlreadyExists
+	case apperror.ErrCodeUnauthorized:
+		return codes.Unauthenticated
+	case apperror.ErrCodeForbidden:
+		return codes.PermissionDenied
+	case apperror.ErrCodeTooManyRequests:
+		return codes.ResourceExhausted
+	case apperror.ErrCodeInternal:
+		return codes.Internal
+	default:
+		return codes.Internal
+	}
+}
+
+// errorUnaryInterceptor converts the errors of the services to statuses.
+func errorUnaryInterceptor(
+	ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(err).Err()
+	}
+	return resp, nil
+}
+
+// errorStreamInterceptor converts the errors of the services to statuses.
+func errorStreamInterceptor(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatus(err).Err()
+	}
+	return nil
+}
+
+// recoveryUnaryInterceptor recovers from panics and returns an internal error.
+func recoveryUnaryInterceptor(
+	ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			debug.PrintStack()
+			err = toStatus(apperror.NewInternalError(fmt.Errorf("%w: %v", errPanic, r))).Err()
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor recovers from panics and returns an internal error.
+func recoveryStreamInterceptor(
+	srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			debug.PrintStack()
+			err = toStatus(apperror.NewInternalError(fmt.Errorf("%w: %v", errPanic, r))).Err()
+		}
+	}()
+	return handler(srv, ss)
+}
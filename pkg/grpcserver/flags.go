@@ -0,0 +1,64 @@
+package grpcserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// flagGate gates services behind feature flags, evaluated for the caller
+// of each call as for HTTP requests.
+type flagGate struct {
+	store *flags.Store
+	// services are the flags by service name, e.g. catalog.v1.BooksService.
+	services map[string]string
+}
+
+// check returns a NOT_FOUND error, as if the service didn't exist, when
+// the flag of the service of fullMethod is off for the caller of ctx.
+func (g *flagGate) check(ctx context.Context, fullMethod string) error {
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	name, ok := g.services[service]
+	if !ok || g.store.Evaluate(subjectOf(ctx))[name] {
+		return nil
+	}
+	return apperror.NewNotFoundError("Not found")
+}
+
+func (g *flagGate) unaryInterceptor(
+	ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	if err := g.check(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *flagGate) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.check(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// subjectOf returns the caller of the call of ctx: its identity is read
+// from the metadata named after middleware.CallerHeader, and the headers
+// of flags from the metadata.
+func subjectOf(ctx context.Context) flags.Subject {
+	subject := flags.Subject{IP: peerIP(ctx), Header: http.Header{}}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		subject.Identity = first(md.Get(strings.ToLower(middleware.CallerHeader)))
+		for key, values := range md {
+			for _, value := range values {
+				subject.Header.Add(key, value)
+			}
+		}
+	}
+	return subject
+}
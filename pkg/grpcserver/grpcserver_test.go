@@ -0,0 +1,218 @@
+package grpcserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+	catalogv1 "github.com/sgaunet/template-api/pkg/api/catalog/v1"
+	"github.com/sgaunet/template-api/pkg/audit"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/sgaunet/template-api/pkg/grpcserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeAuthorsRepository stores authors in memory.
+type fakeAuthorsRepository struct {
+	authors.Repository
+
+	authors map[int64]*authors.Author
+}
+
+func (r *fakeAuthorsRepository) Create(_ context.Context, author *authors.Author) (*authors.Author, error) {
+	created := *author
+	created.ID = int64(len(r.authors) + 1)
+	r.authors[created.ID] = &created
+	return &created, nil
+}
+
+func (r *fakeAuthorsRepository) GetByID(_ context.Context, id int64) (*authors.Author, error) {
+	author, ok := r.authors[id]
+	if !ok {
+		return nil, apperror.NewNotFoundError("Author not found")
+	}
+	return author, nil
+}
+
+func (r *fakeAuthorsRepository) List(_ context.Context) ([]*authors.Author, error) {
+	list := make([]*authors.Author, 0, len(r.authors))
+	for id := range int64(len(r.authors)) {
+		list = append(list, r.authors[id+1])
+	}
+	return list, nil
+}
+
+func (r *fakeAuthorsRepository) Delete(_ context.Context, _ int64) error {
+	panic("boom")
+}
+
+// fakeBooksService exports a fixed list of books.
+type fakeBooksService struct {
+	books.Service
+
+	books  []*books.Book
+	err    error
+	origin audit.Origin
+}
+
+func (s *fakeBooksService) Create(ctx context.Context, req *books.CreateBookRequest) (*books.Book, error) {
+	s.origin = audit.FromContext(ctx)
+	return &books.Book{ID: 1, Title: req.Title, AuthorID: req.AuthorID}, nil
+}
+
+func (s *fakeBooksService) Export(_ context.Context, fn func(*books.Book) error) error {
+	for _, book := range s.books {
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func newClients(
+	t *testing.T, booksService books.Service, opts ...grpcserver.Option,
+) (catalogv1.AuthorsServiceClient, catalogv1.BooksServiceClient) {
+	t.Helper()
+	repo := &fakeAuthorsRepository{authors: map[int64]*authors.Author{}}
+	srv := grpcserver.NewServer(authors.NewService(repo), booksService, opts...)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Shutdown(context.Background())
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return catalogv1.NewAuthorsServiceClient(conn), catalogv1.NewBooksServiceClient(conn)
+}
+
+func TestAuthorsService(t *testing.T) {
+	client, _ := newClients(t, &fakeBooksService{})
+	ctx := context.Background()
+
+	created, err := client.CreateAuthor(ctx, &catalogv1.CreateAuthorRequest{Name: "Ursula", Bio: "Earthsea"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.GetId())
+	assert.Equal(t, "Ursula", created.GetName())
+
+	got, err := client.GetAuthor(ctx, &catalogv1.GetAuthorRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "Earthsea", got.GetBio())
+
+	list, err := client.ListAuthors(ctx, &catalogv1.ListAuthorsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetAuthors(), 1)
+	assert.Equal(t, created.GetId(), list.GetAuthors()[0].GetId())
+}
+
+func TestAuthorsService_Errors(t *testing.T) {
+	client, _ := newClients(t, &fakeBooksService{})
+	ctx := context.Background()
+
+	_, err := client.CreateAuthor(ctx, &catalogv1.CreateAuthorRequest{Name: "Ann"})
+	s := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	require.Len(t, s.Details(), 2)
+	info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	assert.Equal(t, string(apperror.ErrCodeValidation), info.GetReason())
+	assert.Equal(t, grpcserver.ErrorDomain, info.GetDomain())
+	assert.Equal(t, "name", info.GetMetadata()["field"])
+	badRequest, ok := s.Details()[1].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.GetFieldViolations(), 1)
+	assert.Equal(t, "name", badRequest.GetFieldViolations()[0].GetField())
+
+	_, err = client.GetAuthor(ctx, &catalogv1.GetAuthorRequest{Id: 42})
+	s = status.Convert(err)
+	assert.Equal(t, codes.NotFound, s.Code())
+	require.Len(t, s.Details(), 1)
+	assert.Equal(t, string(apperror.ErrCodeNotFound), s.Details()[0].(*errdetails.ErrorInfo).GetReason())
+
+	// Panics are recovered as internal errors.
+	_, err = client.DeleteAuthor(ctx, &catalogv1.DeleteAuthorRequest{Id: 1})
+	s = status.Convert(err)
+	assert.Equal(t, codes.Internal, s.Code())
+	assert.Equal(t, "An internal error occurred", s.Message())
+}
+
+func TestBooksService_ListBooks(t *testing.T) {
+	_, client := newClients(t, &fakeBooksService{
+		books: []*books.Book{{ID: 1, Title: "Dune", AuthorID: 1}, {ID: 2, Title: "Emma", AuthorID: 2}},
+		err:   errors.New("connection lost"),
+	})
+
+	stream, err := client.ListBooks(context.Background(), &catalogv1.ListBooksRequest{})
+	require.NoError(t, err)
+	var titles []string
+	for {
+		book, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			t.Fatal("expected the stream to fail")
+		}
+		if err != nil {
+			s := status.Convert(err)
+			assert.Equal(t, codes.Internal, s.Code())
+			assert.NotContains(t, s.Message(), "connection lost", "internal errors aren't leaked")
+			break
+		}
+		titles = append(titles, book.GetTitle())
+	}
+	assert.Equal(t, []string{"Dune", "Emma"}, titles)
+}
+
+func TestAuditOrigin(t *testing.T) {
+	service := &fakeBooksService{}
+	_, client := newClients(t, service)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-caller-id", "alice", "x-request-id", "req-1")
+	_, err := client.CreateBook(ctx, &catalogv1.CreateBookRequest{Title: "Dune", AuthorId: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", service.origin.Actor)
+	assert.Equal(t, "req-1", service.origin.RequestID)
+}
+
+func TestFlags_GateBooksService(t *testing.T) {
+	store := flags.NewStore(nil, flags.WithConfig(map[string]bool{"books": false}))
+	authorsClient, booksClient := newClients(t, &fakeBooksService{}, grpcserver.WithFlags(store, "books"))
+	ctx := context.Background()
+
+	_, err := booksClient.CreateBook(ctx, &catalogv1.CreateBookRequest{Title: "Dune", AuthorId: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	stream, err := booksClient.ListBooks(ctx, &catalogv1.ListBooksRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// other services aren't gated
+	_, err = authorsClient.ListAuthors(ctx, &catalogv1.ListAuthorsRequest{})
+	require.NoError(t, err)
+
+	store.SetConfig(map[string]bool{"books": true})
+	_, err = booksClient.CreateBook(ctx, &catalogv1.CreateBookRequest{Title: "Dune", AuthorId: 1})
+	require.NoError(t, err)
+}
@@ -0,0 +1,118 @@
+// Package grpcserver provides the gRPC server exposing the authors and
+// books services.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	catalogv1 "github.com/sgaunet/template-api/pkg/api/catalog/v1"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+const listenAddr = ":3001"
+
+// Server is the gRPC server.
+type Server struct {
+	srv    *grpc.Server
+	health *health.Server
+	addr   string
+}
+
+// Option configures a Server.
+type Option func(*options)
+
+type options struct {
+	gate *flagGate
+}
+
+// WithFlags evaluates the feature flags of store for each call and gates
+// BooksService behind the flag booksFlag, as the books routes of the web
+// server: its calls fail with NOT_FOUND while the flag is off for the
+// caller. Without it, every service is served.
+func WithFlags(store *flags.Store, booksFlag string) Option {
+	return func(o *options) {
+		o.gate = &flagGate{
+			store:    store,
+			services: map[string]string{catalogv1.BooksService_ServiceDesc.ServiceName: booksFlag},
+		}
+	}
+}
+
+// NewServer creates a gRPC server delegating to the services.
+func NewServer(authorsService authors.Service, booksService books.Service, opts ...Option) *Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	unary := []grpc.UnaryServerInterceptor{recoveryUnaryInterceptor, errorUnaryInterceptor, auditUnaryInterceptor}
+	stream := []grpc.StreamServerInterceptor{recoveryStreamInterceptor, errorStreamInterceptor}
+	if o.gate != nil {
+		unary = append(unary, o.gate.unaryInterceptor)
+		stream = append(stream, o.gate.streamInterceptor)
+	}
+	s := &Server{
+		srv: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unary...),
+			grpc.ChainStreamInterceptor(stream...),
+		),
+		health: health.NewServer(),
+		addr:   listenAddr,
+	}
+	catalogv1.RegisterAuthorsServiceServer(s.srv, &authorsServer{service: authorsService})
+	catalogv1.RegisterBooksServiceServer(s.srv, &booksServer{service: booksService})
+	healthpb.RegisterHealthServer(s.srv, s.health)
+	// Lets clients such as grpcurl discover the services.
+	reflection.Register(s.srv)
+	return s
+}
+
+// Start listens on the listen address and serves until Shutdown.
+func (s *Server) Start() error {
+	var lc net.ListenConfig
+	lis, err := lc.Listen(context.Background(), "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("could not start grpc server: %w", err)
+	}
+	return s.Serve(lis)
+}
+
+// Serve serves on lis until Shutdown.
+func (s *Server) Serve(lis net.Listener) error {
+	if err := s.srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("could not serve grpc: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops accepting calls and waits for the running ones, until ctx
+// is done: remaining calls are then cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return fmt.Errorf("could not shutdown grpc server: %w", ctx.Err())
+	}
+}
+
+// SetListenAddr sets the listen address (format expected: ":3001").
+// It won't restart the server if it's already running.
+func (s *Server) SetListenAddr(addr string) {
+	s.addr = addr
+}
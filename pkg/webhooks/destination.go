@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// ErrForbiddenDestination is returned when a webhook would reach an address
+// of the internal network: loopback, private, link-local or unspecified.
+var ErrForbiddenDestination = errors.New("forbidden webhook destination")
+
+// forbidden reports whether partners must not make the server reach addr.
+func forbidden(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast()
+}
+
+// checkDestination resolves the host of a subscription URL and rejects it
+// when one of its addresses is forbidden.
+func checkDestination(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return apperror.NewValidationError("Invalid webhook URL", map[string]string{"field": "url"})
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return apperror.NewValidationError(
+			"Webhook URL host can't be resolved",
+			map[string]string{"field": "url", "host": u.Hostname()},
+		)
+	}
+	for _, addr := range addrs {
+		if forbidden(addr) {
+			return apperror.NewValidationError(
+				"Webhook URL must not target the internal network",
+				map[string]string{"field": "url", "host": u.Hostname(), "address": addr.Unmap().String()},
+			)
+		}
+	}
+	return nil
+}
+
+// dialControl is the net.Dialer control function of the deliveries: it
+// checks the address actually dialled, after resolution, so that a host
+// resolving to another address since its subscription (DNS rebinding) is
+// still rejected.
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, address)
+	}
+	if forbidden(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenDestination, address)
+	}
+	return nil
+}
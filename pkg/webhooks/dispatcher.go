@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sgaunet/template-api/internal/backoff"
+)
+
+// Dispatcher defaults.
+const (
+	DefaultMaxAttempts  = 10
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 20
+	DefaultTimeout      = 10 * time.Second
+	DefaultRetryInitial = 10 * time.Second
+	DefaultRetryMax     = time.Hour
+)
+
+const (
+	// contentType is the content type of CloudEvents JSON envelopes.
+	contentType = "application/cloudevents+json"
+	userAgent   = "template-api-webhooks"
+	// maxErrorLength truncates errors recorded in the delivery log.
+	maxErrorLength = 512
+)
+
+// Dispatcher POSTs pending deliveries to subscribers.
+type Dispatcher struct {
+	repo         Repository
+	client       *http.Client
+	backoff      backoff.Exponential
+	maxAttempts  int
+	batchSize    int
+	pollInterval time.Duration
+	allowPrivate bool
+}
+
+// DispatcherOption configures the dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithMaxAttempts sets the number of attempts before a delivery is dead.
+// Values lower or equal to zero are ignored.
+func WithMaxAttempts(attempts int) DispatcherOption {
+	return func(d *Dispatcher) {
+		if attempts > 0 {
+			d.maxAttempts = attempts
+		}
+	}
+}
+
+// WithRetryBackoff sets the delays between attempts.
+// Values lower or equal to zero are ignored.
+func WithRetryBackoff(initial, maxDelay time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		if initial > 0 && maxDelay > 0 {
+			d.backoff = backoff.New(initial, maxDelay)
+		}
+	}
+}
+
+// WithTimeout sets the timeout of a delivery request.
+// Values lower or equal to zero are ignored.
+func WithTimeout(timeout time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		if timeout > 0 {
+			d.client.Timeout = timeout
+		}
+	}
+}
+
+// WithBatchSize sets the maximum number of deliveries sent concurrently.
+// Values lower or equal to zero are ignored.
+func WithBatchSize(size int) DispatcherOption {
+	return func(d *Dispatcher) {
+		if size > 0 {
+			d.batchSize = size
+		}
+	}
+}
+
+// WithPollInterval sets the interval between two runs.
+// Values lower or equal to zero are ignored.
+func WithPollInterval(interval time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		if interval > 0 {
+			d.pollInterval = interval
+		}
+	}
+}
+
+// WithPrivateDestinations lets deliveries reach the internal network, for
+// development and tests.
+func WithPrivateDestinations() DispatcherOption {
+	return func(d *Dispatcher) {
+		d.allowPrivate = true
+	}
+}
+
+// NewDispatcher creates a new dispatcher. Deliveries to the internal network
+// fail with ErrForbiddenDestination unless WithPrivateDestinations is given;
+// they don't go through the proxy of the environment, which would dial them
+// itself.
+func NewDispatcher(repo Repository, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		repo:         repo,
+		client:       &http.Client{Timeout: DefaultTimeout},
+		backoff:      backoff.New(DefaultRetryInitial, DefaultRetryMax),
+		maxAttempts:  DefaultMaxAttempts,
+		batchSize:    DefaultBatchSize,
+		pollInterval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if !d.allowPrivate {
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // set by net/http
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{Control: dialControl}).DialContext
+		d.client.Transport = transport
+	}
+	return d
+}
+
+// Run dispatches due deliveries until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DispatchOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("webhook dispatch failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce sends a batch of due deliveries concurrently and returns the
+// number of attempts made.
+//
+// Deliveries are leased rather than locked, so that several instances can
+// dispatch without holding a transaction during requests. A delivery whose
+// attempt isn't recorded before the lease expires is sent again, and the late
+// attempt isn't recorded over the new claim.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	leaseUntil := time.Now().Add(2 * d.client.Timeout) //nolint:mnd // leaves time to record the attempt
+	deliveries, err := d.repo.Claim(ctx, leaseUntil, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(deliveries))
+	for i, delivery := range deliveries {
+		wg.Go(func() {
+			err := d.repo.RecordAttempt(ctx, d.deliver(ctx, delivery))
+			if errors.Is(err, ErrLeaseLost) {
+				slog.Warn("webhook delivery lease lost, attempt not recorded", "delivery", delivery.ID,
+					"url", delivery.URL)
+				return
+			}
+			errs[i] = err
+		})
+	}
+	wg.Wait()
+	return len(deliveries), errors.Join(errs...)
+}
+
+// deliver POSTs a delivery and returns the outcome of the attempt.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *PendingDelivery) Attempt {
+	statusCode, err := d.post(ctx, delivery)
+	now := time.Now()
+	attempt := Attempt{
+		DeliveryID:    delivery.ID,
+		Status:        StatusDelivered,
+		StatusCode:    statusCode,
+		NextAttemptAt: now,
+		LeaseUntil:    delivery.LeaseUntil,
+	}
+	if err == nil {
+		attempt.DeliveredAt = &now
+		return attempt
+	}
+
+	attempt.Error = err.Error()
+	if len(attempt.Error) > maxErrorLength {
+		attempt.Error = attempt.Error[:maxErrorLength]
+	}
+	attempts := delivery.Attempts + 1
+	if attempts >= d.maxAttempts {
+		attempt.Status = StatusDead
+		slog.Warn("webhook delivery dead", "delivery", delivery.ID, "url", delivery.URL,
+			"attempts", attempts, "error", err)
+		return attempt
+	}
+	attempt.Status = StatusPending
+	attempt.NextAttemptAt = now.Add(d.backoff.Delay(attempts - 1))
+	return attempt
+}
+
+// errUnexpectedStatus is returned for non-2xx responses.
+var errUnexpectedStatus = errors.New("unexpected status code")
+
+// post sends the signed delivery request.
+func (d *Dispatcher) post(ctx context.Context, delivery *PendingDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("could not create request: %w", err)
+	}
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, delivery.EventID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// Drain the body so that the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,8 @@
+// Package webhooks delivers domain events to partners over HTTP callbacks.
+//
+// Subscriptions register a URL, a secret and the event types to receive.
+// Events relayed from the outbox are queued as deliveries, one per matching
+// subscription, and POSTed by the dispatcher with an HMAC-SHA256 signature.
+// Failed deliveries are retried with exponential backoff until they are
+// dead-lettered.
+package webhooks
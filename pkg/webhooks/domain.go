@@ -0,0 +1,200 @@
+package webhooks
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+)
+
+// EventTypes are the event types subscriptions can receive.
+var EventTypes = []string{
+	authors.EventAuthorCreated,
+	authors.EventAuthorUpdated,
+	authors.EventAuthorDeleted,
+	books.EventBookCreated,
+	books.EventBookUpdated,
+	books.EventBookDeleted,
+}
+
+// MinSecretLength is the minimum length of a subscription secret.
+const MinSecretLength = 16
+
+// Subscription is a webhook subscription.
+type Subscription struct {
+	ID         int64
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  time.Time
+}
+
+// CreateSubscriptionRequest is the request to create a subscription.
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+// Validate validates the create subscription request.
+func (r *CreateSubscriptionRequest) Validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperror.NewValidationError(
+			"Webhook URL must be an absolute http or https URL",
+			map[string]string{"field": "url"},
+		)
+	}
+	if len(r.Secret) < MinSecretLength {
+		return apperror.NewValidationError(
+			"Webhook secret too short",
+			map[string]string{
+				"field": "secret",
+				"min":   strconv.Itoa(MinSecretLength),
+				"value": strconv.Itoa(len(r.Secret)),
+			},
+		)
+	}
+	if len(r.EventTypes) == 0 {
+		return apperror.NewValidationError(
+			"Webhook event types are empty",
+			map[string]string{"field": "event_types"},
+		)
+	}
+	for _, eventType := range r.EventTypes {
+		if !slices.Contains(EventTypes, eventType) {
+			return apperror.NewValidationError(
+				"Unknown webhook event type",
+				map[string]string{
+					"field":    "event_types",
+					"value":    eventType,
+					"expected": strings.Join(EventTypes, ","),
+				},
+			)
+		}
+	}
+	return nil
+}
+
+// ToSubscription converts request to domain subscription.
+func (r *CreateSubscriptionRequest) ToSubscription() (*Subscription, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	eventTypes := slices.Clone(r.EventTypes)
+	slices.Sort(eventTypes)
+	return &Subscription{
+		URL:        r.URL,
+		Secret:     r.Secret,
+		EventTypes: slices.Compact(eventTypes),
+	}, nil
+}
+
+// SubscriptionResponse is the response format, the secret is never returned.
+type SubscriptionResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ToResponse converts domain subscription to response.
+func (s *Subscription) ToResponse() *SubscriptionResponse {
+	return &SubscriptionResponse{
+		ID:         s.ID,
+		URL:        s.URL,
+		EventTypes: s.EventTypes,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+// DeliveryStatus is the state of a delivery.
+type DeliveryStatus string
+
+// Delivery states: pending deliveries are retried until they are delivered
+// or dead after the maximum number of attempts.
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusDead      DeliveryStatus = "dead"
+)
+
+// Delivery is an event delivery to a subscription.
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        uuid.UUID
+	EventType      string
+	Status         DeliveryStatus
+	Attempts       int
+	LastStatusCode int
+	LastError      string
+	NextAttemptAt  time.Time
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+// DeliveryResponse is the delivery log entry format.
+type DeliveryResponse struct {
+	ID             int64          `json:"id"`
+	EventID        string         `json:"event_id"`
+	EventType      string         `json:"event_type"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	LastStatusCode int            `json:"last_status_code,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+}
+
+// ToResponse converts domain delivery to response.
+// The next attempt is only reported for pending deliveries.
+func (d *Delivery) ToResponse() *DeliveryResponse {
+	resp := &DeliveryResponse{
+		ID:             d.ID,
+		EventID:        d.EventID.String(),
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == StatusPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+// PendingDelivery is a delivery claimed by the dispatcher.
+type PendingDelivery struct {
+	ID       int64
+	EventID  uuid.UUID
+	Payload  []byte
+	Attempts int
+	URL      string
+	Secret   string
+	// LeaseUntil is the end of the lease of the claim, until which other
+	// dispatchers don't send the delivery.
+	LeaseUntil time.Time
+}
+
+// Attempt is the outcome of a delivery attempt.
+type Attempt struct {
+	DeliveryID    int64
+	Status        DeliveryStatus
+	StatusCode    int
+	Error         string
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+	// LeaseUntil is the lease of the claim the attempt was made under.
+	LeaseUntil time.Time
+}
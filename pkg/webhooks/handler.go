@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/apperror"
+)
+
+// Handler handles HTTP requests for webhook subscriptions.
+type Handler struct {
+	service Service
+}
+
+// NewHandler creates a new webhook handler.
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Create handles POST /webhooks.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req CreateSubscriptionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid request body"))
+		return
+	}
+	defer func() { _ = r.Body.Close() }()
+
+	sub, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(sub.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// List handles GET /webhooks.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.List(r.Context())
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	responses := make([]*SubscriptionResponse, len(subs))
+	for i, sub := range subs {
+		responses[i] = sub.ToResponse()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Get handles GET /webhooks/{id}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetByID(r.Context(), id)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(sub.ToResponse()); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// Delete handles DELETE /webhooks/{id}.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/{id}/deliveries?limit=N.
+func (h *Handler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := subscriptionID(w, r)
+	if !ok {
+		return
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			apperror.WriteError(w, apperror.NewBadRequestError("Invalid limit"))
+			return
+		}
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		apperror.WriteError(w, err)
+		return
+	}
+
+	responses := make([]*DeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		responses[i] = delivery.ToResponse()
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(responses); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
+
+// subscriptionID parses the {id} URL parameter, writing an error if it's invalid.
+func subscriptionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		apperror.WriteError(w, apperror.NewBadRequestError("Invalid webhook subscription ID"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,24 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/sgaunet/template-api/internal/outbox"
+)
+
+// Publisher queues the deliveries of events relayed from the outbox.
+type Publisher struct {
+	repo Repository
+}
+
+var _ outbox.Publisher = (*Publisher)(nil)
+
+// NewPublisher creates a new webhook publisher.
+func NewPublisher(repo Repository) *Publisher {
+	return &Publisher{repo: repo}
+}
+
+// Publish queues a delivery of ev for each subscription to its type.
+func (p *Publisher) Publish(ctx context.Context, ev outbox.CloudEvent) error {
+	return p.repo.Enqueue(ctx, ev)
+}
@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/internal/repository"
+)
+
+// ErrLeaseLost is returned when recording an attempt of a delivery whose
+// lease expired, and which may have been claimed again by another dispatcher.
+var ErrLeaseLost = errors.New("webhook delivery lease lost")
+
+// Repository defines the interface for webhook data access.
+type Repository interface {
+	Create(ctx context.Context, sub *Subscription) (*Subscription, error)
+	GetByID(ctx context.Context, id int64) (*Subscription, error)
+	List(ctx context.Context) ([]*Subscription, error)
+	Delete(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*Delivery, error)
+	// Enqueue queues a delivery of ev for each subscription to its type.
+	Enqueue(ctx context.Context, ev outbox.CloudEvent) error
+	// Claim leases up to limit due deliveries until leaseUntil.
+	Claim(ctx context.Context, leaseUntil time.Time, limit int) ([]*PendingDelivery, error)
+	// RecordAttempt records an attempt with the lease of its claim,
+	// ErrLeaseLost is returned if it isn't held anymore.
+	RecordAttempt(ctx context.Context, attempt Attempt) error
+}
+
+// repositoryImpl wraps sqlc-generated queries.
+type repositoryImpl struct {
+	queries repository.Querier
+}
+
+// NewRepository creates a new webhook repository.
+func NewRepository(queries repository.Querier) Repository {
+	return &repositoryImpl{queries: queries}
+}
+
+func (r *repositoryImpl) Create(ctx context.Context, sub *Subscription) (*Subscription, error) {
+	dbSub, err := r.queries.CreateWebhookSubscription(ctx, repository.CreateWebhookSubscriptionParams{
+		Url:        sub.URL,
+		Secret:     sub.Secret,
+		EventTypes: sub.EventTypes,
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	return toSubscription(dbSub), nil
+}
+
+func (r *repositoryImpl) GetByID(ctx context.Context, id int64) (*Subscription, error) {
+	dbSub, err := r.queries.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.NewNotFoundError("Webhook subscription not found")
+		}
+		return nil, apperror.NewInternalError(err)
+	}
+	return toSubscription(dbSub), nil
+}
+
+func (r *repositoryImpl) List(ctx context.Context) ([]*Subscription, error) {
+	dbSubs, err := r.queries.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	subs := make([]*Subscription, len(dbSubs))
+	for i, dbSub := range dbSubs {
+		subs[i] = toSubscription(dbSub)
+	}
+	return subs, nil
+}
+
+func (r *repositoryImpl) Delete(ctx context.Context, id int64) error {
+	deleted, err := r.queries.DeleteWebhookSubscription(ctx, id)
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	if deleted == 0 {
+		return apperror.NewNotFoundError("Webhook subscription not found")
+	}
+	return nil
+}
+
+func (r *repositoryImpl) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*Delivery, error) {
+	dbDeliveries, err := r.queries.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{
+		SubscriptionID: subscriptionID,
+		Limit:          int32(limit), //nolint:gosec // bounded by MaxDeliveriesLimit
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	deliveries := make([]*Delivery, len(dbDeliveries))
+	for i, d := range dbDeliveries {
+		deliveries[i] = &Delivery{
+			ID:             d.ID,
+			SubscriptionID: d.SubscriptionID,
+			EventID:        d.EventID,
+			EventType:      d.EventType,
+			Status:         DeliveryStatus(d.Status),
+			Attempts:       int(d.Attempts),
+			LastStatusCode: int(d.LastStatusCode.Int32),
+			LastError:      d.LastError.String,
+			NextAttemptAt:  d.NextAttemptAt,
+			CreatedAt:      d.CreatedAt,
+		}
+		if d.DeliveredAt.Valid {
+			deliveries[i].DeliveredAt = &d.DeliveredAt.Time
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *repositoryImpl) Enqueue(ctx context.Context, ev outbox.CloudEvent) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	eventID, err := uuid.Parse(ev.ID)
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	// Deliveries are unique per subscription and event, events relayed
+	// again are not delivered twice.
+	_, err = r.queries.EnqueueWebhookDeliveries(ctx, repository.EnqueueWebhookDeliveriesParams{
+		EventID:   eventID,
+		EventType: ev.Type,
+		Payload:   payload,
+	})
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	return nil
+}
+
+func (r *repositoryImpl) Claim(ctx context.Context, leaseUntil time.Time, limit int) ([]*PendingDelivery, error) {
+	rows, err := r.queries.ClaimWebhookDeliveries(ctx, repository.ClaimWebhookDeliveriesParams{
+		LeaseUntil:    leaseUntil,
+		MaxDeliveries: int32(limit), //nolint:gosec // bounded by configuration
+	})
+	if err != nil {
+		return nil, apperror.NewInternalError(err)
+	}
+	deliveries := make([]*PendingDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = &PendingDelivery{
+			ID:         row.ID,
+			EventID:    row.EventID,
+			Payload:    row.Payload,
+			Attempts:   int(row.Attempts),
+			URL:        row.Url,
+			Secret:     row.Secret,
+			LeaseUntil: row.LeaseUntil,
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *repositoryImpl) RecordAttempt(ctx context.Context, attempt Attempt) error {
+	params := repository.RecordWebhookDeliveryAttemptParams{
+		ID:             attempt.DeliveryID,
+		Status:         string(attempt.Status),
+		LastStatusCode: sql.NullInt32{Int32: int32(attempt.StatusCode), Valid: attempt.StatusCode != 0}, //nolint:gosec // HTTP status
+		LastError:      sql.NullString{String: attempt.Error, Valid: attempt.Error != ""},
+		NextAttemptAt:  attempt.NextAttemptAt,
+		LeaseUntil:     attempt.LeaseUntil,
+	}
+	if attempt.DeliveredAt != nil {
+		params.DeliveredAt = sql.NullTime{Time: *attempt.DeliveredAt, Valid: true}
+	}
+	updated, err := r.queries.RecordWebhookDeliveryAttempt(ctx, params)
+	if err != nil {
+		return apperror.NewInternalError(err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("could not record attempt of delivery %d: %w", attempt.DeliveryID, ErrLeaseLost)
+	}
+	return nil
+}
+
+func toSubscription(dbSub repository.WebhookSubscription) *Subscription {
+	return &Subscription{
+		ID:         dbSub.ID,
+		URL:        dbSub.Url,
+		Secret:     dbSub.Secret,
+		EventTypes: dbSub.EventTypes,
+		CreatedAt:  dbSub.CreatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package webhooks
+
+import (
+	"context"
+)
+
+// Limits of the delivery log.
+const (
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 500
+)
+
+// Service provides webhook business logic.
+type Service interface {
+	Create(ctx context.Context, req *CreateSubscriptionRequest) (*Subscription, error)
+	GetByID(ctx context.Context, id int64) (*Subscription, error)
+	List(ctx context.Context) ([]*Subscription, error)
+	Delete(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*Delivery, error)
+}
+
+type service struct {
+	repo         Repository
+	allowPrivate bool
+}
+
+// ServiceOption configures the service.
+type ServiceOption func(*service)
+
+// WithPrivateSubscriptions accepts subscriptions to the internal network,
+// for development and tests.
+func WithPrivateSubscriptions() ServiceOption {
+	return func(s *service) {
+		s.allowPrivate = true
+	}
+}
+
+// NewService creates a new webhook service. Subscriptions whose host resolves
+// to the internal network are rejected unless WithPrivateSubscriptions is
+// given.
+func NewService(repo Repository, opts ...ServiceOption) Service {
+	s := &service{repo: repo}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Create(ctx context.Context, req *CreateSubscriptionRequest) (*Subscription, error) {
+	sub, err := req.ToSubscription()
+	if err != nil {
+		return nil, err
+	}
+	if !s.allowPrivate {
+		if err := checkDestination(ctx, sub.URL); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.Create(ctx, sub)
+}
+
+func (s *service) GetByID(ctx context.Context, id int64) (*Subscription, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *service) List(ctx context.Context) ([]*Subscription, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *service) Delete(ctx context.Context, id int64) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// ListDeliveries returns the most recent deliveries of a subscription first.
+// The limit is clamped to MaxDeliveriesLimit, zero means the default.
+func (s *service) ListDeliveries(ctx context.Context, subscriptionID int64, limit int) ([]*Delivery, error) {
+	if _, err := s.repo.GetByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultDeliveriesLimit
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, min(limit, MaxDeliveriesLimit))
+}
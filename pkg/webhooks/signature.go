@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of webhook requests.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+// signaturePrefix identifies the signature algorithm.
+const signaturePrefix = "sha256="
+
+// DefaultTolerance is the maximum age of a request accepted by Verify.
+const DefaultTolerance = 5 * time.Minute
+
+// Errors returned by Verify.
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+)
+
+// Sign returns the signature of body sent at timestamp: the hex-encoded
+// HMAC-SHA256 of "<unix timestamp>.<body>" keyed by secret. Signing the
+// timestamp prevents replaying a request later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a received request,
+// rejecting requests older than tolerance. Receivers can use it to
+// authenticate webhooks.
+func Verify(secret, timestampHeader, signatureHeader string, body []byte, tolerance time.Duration) error {
+	unix, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	timestamp := time.Unix(unix, 0)
+	if age := time.Since(timestamp); age > tolerance || age < -tolerance {
+		return ErrInvalidTimestamp
+	}
+	if !strings.HasPrefix(signatureHeader, signaturePrefix) ||
+		!hmac.Equal([]byte(signatureHeader), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
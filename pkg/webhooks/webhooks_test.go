@@ -0,0 +1,372 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/outbox"
+	"github.com/sgaunet/template-api/pkg/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "0123456789abcdef"
+
+// fakeRepository is an in-memory webhooks.Repository, claims ignore the
+// next attempt time so that retries are immediate.
+type fakeRepository struct {
+	mu         sync.Mutex
+	subs       []*webhooks.Subscription
+	deliveries []*webhooks.Delivery
+	payloads   map[int64][]byte
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{payloads: map[int64][]byte{}}
+}
+
+func (r *fakeRepository) Create(_ context.Context, sub *webhooks.Subscription) (*webhooks.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub.ID = int64(len(r.subs) + 1)
+	r.subs = append(r.subs, sub)
+	return sub, nil
+}
+
+func (r *fakeRepository) GetByID(_ context.Context, id int64) (*webhooks.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sub := range r.subs {
+		if sub.ID == id {
+			return sub, nil
+		}
+	}
+	return nil, apperror.NewNotFoundError("Webhook subscription not found")
+}
+
+func (r *fakeRepository) List(_ context.Context) ([]*webhooks.Subscription, error) {
+	return r.subs, nil
+}
+
+func (r *fakeRepository) Delete(_ context.Context, _ int64) error {
+	return nil
+}
+
+func (r *fakeRepository) ListDeliveries(_ context.Context, subscriptionID int64, limit int) ([]*webhooks.Delivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []*webhooks.Delivery
+	for _, d := range r.deliveries {
+		if d.SubscriptionID == subscriptionID && len(deliveries) < limit {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeRepository) Enqueue(_ context.Context, ev outbox.CloudEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	for _, sub := range r.subs {
+		for _, eventType := range sub.EventTypes {
+			if eventType != ev.Type {
+				continue
+			}
+			d := &webhooks.Delivery{
+				ID:             int64(len(r.deliveries) + 1),
+				SubscriptionID: sub.ID,
+				EventID:        uuid.MustParse(ev.ID),
+				EventType:      ev.Type,
+				Status:         webhooks.StatusPending,
+			}
+			r.deliveries = append(r.deliveries, d)
+			r.payloads[d.ID] = payload
+		}
+	}
+	return nil
+}
+
+func (r *fakeRepository) Claim(ctx context.Context, leaseUntil time.Time, limit int) ([]*webhooks.PendingDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pending []*webhooks.PendingDelivery
+	for _, d := range r.deliveries {
+		if d.Status != webhooks.StatusPending || len(pending) == limit {
+			continue
+		}
+		sub := r.subs[d.SubscriptionID-1]
+		d.NextAttemptAt = leaseUntil
+		pending = append(pending, &webhooks.PendingDelivery{
+			ID:         d.ID,
+			EventID:    d.EventID,
+			Payload:    r.payloads[d.ID],
+			Attempts:   d.Attempts,
+			URL:        sub.URL,
+			Secret:     sub.Secret,
+			LeaseUntil: leaseUntil,
+		})
+	}
+	return pending, ctx.Err()
+}
+
+func (r *fakeRepository) RecordAttempt(_ context.Context, attempt webhooks.Attempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.deliveries[attempt.DeliveryID-1]
+	if d.Status != webhooks.StatusPending || !d.NextAttemptAt.Equal(attempt.LeaseUntil) {
+		return webhooks.ErrLeaseLost
+	}
+	d.Status = attempt.Status
+	d.Attempts++
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+	d.NextAttemptAt = attempt.NextAttemptAt
+	d.DeliveredAt = attempt.DeliveredAt
+	return nil
+}
+
+// receiver is a webhook endpoint verifying signatures and failing the
+// first requests.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	received []outbox.CloudEvent
+	// onRequest, if set, is called before a request is handled.
+	onRequest func()
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rc.onRequest != nil {
+		rc.onRequest()
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = webhooks.Verify(secret, r.Header.Get(webhooks.HeaderTimestamp),
+		r.Header.Get(webhooks.HeaderSignature), body, webhooks.DefaultTolerance)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var ev outbox.CloudEvent
+	if err := json.Unmarshal(body, &ev); err != nil || ev.ID != r.Header.Get(webhooks.HeaderID) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.received = append(rc.received, ev)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func setup(t *testing.T, rc *receiver, opts ...webhooks.DispatcherOption) (*fakeRepository, *webhooks.Dispatcher) {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	repo := newFakeRepository()
+	service := webhooks.NewService(repo, webhooks.WithPrivateSubscriptions())
+	_, err := service.Create(context.Background(), &webhooks.CreateSubscriptionRequest{
+		URL:        srv.URL,
+		Secret:     secret,
+		EventTypes: []string{"AuthorCreated"},
+	})
+	require.NoError(t, err)
+
+	publisher := webhooks.NewPublisher(repo)
+	for _, eventType := range []string{"AuthorCreated", "BookCreated"} {
+		err := publisher.Publish(context.Background(), outbox.CloudEvent{
+			SpecVersion: outbox.SpecVersion,
+			ID:          uuid.NewString(),
+			Type:        eventType,
+			Data:        json.RawMessage(`{"id":1}`),
+		})
+		require.NoError(t, err)
+	}
+
+	opts = append(opts, webhooks.WithPrivateDestinations(), webhooks.WithRetryBackoff(time.Millisecond, time.Millisecond))
+	return repo, webhooks.NewDispatcher(repo, opts...)
+}
+
+func TestDispatcher_Delivered(t *testing.T) {
+	rc := &receiver{}
+	repo, dispatcher := setup(t, rc)
+
+	attempts, err := dispatcher.DispatchOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts, "only subscribed event types are delivered")
+
+	require.Len(t, rc.received, 1)
+	assert.Equal(t, "AuthorCreated", rc.received[0].Type)
+	assert.JSONEq(t, `{"id":1}`, string(rc.received[0].Data))
+
+	deliveries, err := webhooks.NewService(repo).ListDeliveries(context.Background(), 1, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhooks.StatusDelivered, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, deliveries[0].LastStatusCode)
+	assert.NotNil(t, deliveries[0].DeliveredAt)
+}
+
+func TestDispatcher_Retried(t *testing.T) {
+	rc := &receiver{failures: 2}
+	repo, dispatcher := setup(t, rc)
+
+	for range 3 {
+		_, err := dispatcher.DispatchOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	require.Len(t, rc.received, 1)
+	d := repo.deliveries[0]
+	assert.Equal(t, webhooks.StatusDelivered, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	rc := &receiver{failures: 10}
+	repo, dispatcher := setup(t, rc, webhooks.WithMaxAttempts(3))
+
+	for range 5 {
+		_, err := dispatcher.DispatchOnce(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.Empty(t, rc.received)
+	d := repo.deliveries[0]
+	assert.Equal(t, webhooks.StatusDead, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, d.LastStatusCode)
+	assert.Contains(t, d.LastError, "503")
+	assert.Nil(t, d.ToResponse().NextAttemptAt)
+}
+
+func TestDispatcher_LeaseLost(t *testing.T) {
+	rc := &receiver{}
+	repo, dispatcher := setup(t, rc)
+	// Another dispatcher claims the delivery again, as if the lease expired
+	// during the request.
+	var stolen []*webhooks.PendingDelivery
+	rc.onRequest = func() {
+		var err error
+		stolen, err = repo.Claim(context.Background(), time.Now().Add(time.Hour), 1)
+		assert.NoError(t, err)
+	}
+
+	_, err := dispatcher.DispatchOnce(context.Background())
+	require.NoError(t, err, "a lost lease isn't an error")
+
+	require.Len(t, rc.received, 1)
+	require.Len(t, stolen, 1)
+	d := repo.deliveries[0]
+	assert.Equal(t, webhooks.StatusPending, d.Status, "the attempt isn't recorded over the new claim")
+	assert.Equal(t, 0, d.Attempts)
+	assert.Equal(t, stolen[0].LeaseUntil, d.NextAttemptAt)
+}
+
+func TestDispatcher_PrivateDestination(t *testing.T) {
+	rc := &receiver{}
+	repo, _ := setup(t, rc)
+
+	_, err := webhooks.NewDispatcher(repo).DispatchOnce(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, rc.received, "the loopback receiver isn't dialled")
+	d := repo.deliveries[0]
+	assert.Equal(t, webhooks.StatusPending, d.Status)
+	assert.Contains(t, d.LastError, webhooks.ErrForbiddenDestination.Error())
+}
+
+func TestService_Create_PrivateDestination(t *testing.T) {
+	service := webhooks.NewService(newFakeRepository())
+	for _, url := range []string{
+		"http://127.0.0.1:8080/hooks",
+		"http://localhost/hooks",
+		"http://[::1]/hooks",
+		"http://[::ffff:10.0.0.1]/hooks",
+		"http://192.168.1.10/hooks",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hooks",
+	} {
+		t.Run(url, func(t *testing.T) {
+			_, err := service.Create(context.Background(), &webhooks.CreateSubscriptionRequest{
+				URL:        url,
+				Secret:     secret,
+				EventTypes: []string{"AuthorCreated"},
+			})
+			assert.True(t, apperror.IsValidationError(err), "got %v", err)
+		})
+	}
+
+	sub, err := service.Create(context.Background(), &webhooks.CreateSubscriptionRequest{
+		URL:        "https://93.184.215.14/hooks",
+		Secret:     secret,
+		EventTypes: []string{"AuthorCreated"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), sub.ID)
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := webhooks.Sign(secret, now, body)
+
+	assert.NoError(t, webhooks.Verify(secret, timestamp, signature, body, webhooks.DefaultTolerance))
+	assert.ErrorIs(t, webhooks.Verify("another-secret-value", timestamp, signature, body, webhooks.DefaultTolerance),
+		webhooks.ErrInvalidSignature)
+	assert.ErrorIs(t, webhooks.Verify(secret, timestamp, signature, []byte(`{"id":"2"}`), webhooks.DefaultTolerance),
+		webhooks.ErrInvalidSignature)
+	assert.ErrorIs(t, webhooks.Verify(secret, "yesterday", signature, body, webhooks.DefaultTolerance),
+		webhooks.ErrInvalidTimestamp)
+
+	old := now.Add(-time.Hour)
+	assert.ErrorIs(t, webhooks.Verify(secret, strconv.FormatInt(old.Unix(), 10), webhooks.Sign(secret, old, body), body,
+		webhooks.DefaultTolerance), webhooks.ErrInvalidTimestamp)
+}
+
+func TestCreateSubscriptionRequest_Validate(t *testing.T) {
+	valid := webhooks.CreateSubscriptionRequest{
+		URL:        "https://partner.example.com/hooks",
+		Secret:     secret,
+		EventTypes: []string{"AuthorCreated", "BookCreated"},
+	}
+	assert.NoError(t, valid.Validate())
+
+	for name, mutate := range map[string]func(r *webhooks.CreateSubscriptionRequest){
+		"relative url":   func(r *webhooks.CreateSubscriptionRequest) { r.URL = "/hooks" },
+		"ftp url":        func(r *webhooks.CreateSubscriptionRequest) { r.URL = "ftp://partner.example.com" },
+		"short secret":   func(r *webhooks.CreateSubscriptionRequest) { r.Secret = "secret" },
+		"no event types": func(r *webhooks.CreateSubscriptionRequest) { r.EventTypes = nil },
+		"unknown event":  func(r *webhooks.CreateSubscriptionRequest) { r.EventTypes = []string{"AuthorRenamed"} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := valid
+			mutate(&req)
+			assert.True(t, apperror.IsValidationError(req.Validate()))
+		})
+	}
+}
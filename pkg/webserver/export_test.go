@@ -0,0 +1,16 @@
+package webserver
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/openapi"
+)
+
+// Router returns the router of w to the external tests.
+func (w *WebServer) Router() *chi.Mux {
+	return w.router
+}
+
+// OpenAPI returns the OpenAPI document served by w.
+func (w *WebServer) OpenAPI() *openapi.Document {
+	return w.spec
+}
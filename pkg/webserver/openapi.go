@@ -0,0 +1,647 @@
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sgaunet/template-api/internal/apperror"
+	"github.com/sgaunet/template-api/internal/exchange"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/openapi"
+	"github.com/sgaunet/template-api/internal/pagination"
+	"github.com/sgaunet/template-api/internal/scheduler"
+	"github.com/sgaunet/template-api/pkg/audit"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/sgaunet/template-api/pkg/graph"
+	"github.com/sgaunet/template-api/pkg/webhooks"
+)
+
+// apiVersion is the version of the API described by the OpenAPI document.
+const apiVersion = "1.0.0"
+
+const contentTypeJSON = "application/json"
+
+// adminTokenScheme is the security scheme of the administration routes.
+const adminTokenScheme = "adminToken"
+
+// Spec returns the OpenAPI document of the routes registered in initRoutes.
+// Constraints are taken from the domain packages so that the document
+// follows the validation rules.
+func Spec() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "template-api",
+		Version:     apiVersion,
+		Description: "Catalog of authors and books.",
+	})
+	registerSchemas(doc)
+
+	// Health and metrics
+	doc.AddOperation(http.MethodGet, "/", &openapi.Operation{
+		OperationID: "healthCheck",
+		Summary:     "Health check",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": content("The server is up", "text/plain", &openapi.Schema{Type: openapi.TypeString}),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/ready", &openapi.Operation{
+		OperationID: "readinessCheck",
+		Summary:     "Readiness check of the server dependencies (database)",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": content("The server is ready", "text/plain", &openapi.Schema{Type: openapi.TypeString}),
+			"503": content("A dependency is unavailable", "text/plain", &openapi.Schema{Type: openapi.TypeString}),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/debug/vars", &openapi.Operation{
+		OperationID: "getMetrics",
+		Summary:     "Runtime metrics (expvar)",
+		Tags:        []string{"health"},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Runtime metrics", contentTypeJSON, &openapi.Schema{Type: openapi.TypeObject}),
+		}, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodGet, "/openapi.json", &openapi.Operation{
+		OperationID: "getOpenAPI",
+		Summary:     "This OpenAPI document",
+		Tags:        []string{"health"},
+		Responses: map[string]*openapi.Response{
+			"200": content("OpenAPI document", contentTypeJSON, &openapi.Schema{Type: openapi.TypeObject}),
+		},
+	})
+
+	// Authors
+	doc.AddOperation(http.MethodPost, "/authors", &openapi.Operation{
+		OperationID: "createAuthor",
+		Summary:     "Create an author",
+		Tags:        []string{"authors"},
+		RequestBody: jsonBody(openapi.Ref("CreateAuthorRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": content("Created author", contentTypeJSON, openapi.Ref("AuthorResponse")),
+		}, http.StatusBadRequest),
+	})
+	doc.AddOperation(http.MethodGet, "/authors", &openapi.Operation{
+		OperationID: "listAuthors",
+		Summary:     "List authors by name, all of them unless a page is requested",
+		Tags:        []string{"authors"},
+		Parameters:  append(pageParameters(), readPrimaryHeader()),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": page("Authors", openapi.Ref("AuthorResponse")),
+		}, http.StatusBadRequest),
+	})
+	doc.AddOperation(http.MethodGet, "/authors/export", &openapi.Operation{
+		OperationID: "exportAuthors",
+		Summary:     "Export authors as NDJSON (default) or CSV, negotiated with the Accept header",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{readPrimaryHeader()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": exportResponse("Authors", openapi.Ref("AuthorResponse")),
+		}),
+	})
+	doc.AddOperation(http.MethodPost, "/authors/import", &openapi.Operation{
+		OperationID: "importAuthors",
+		Summary:     "Import authors from NDJSON or CSV",
+		Tags:        []string{"authors"},
+		RequestBody: importBody(openapi.Ref("CreateAuthorRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Import report", contentTypeJSON, openapi.Ref("ImportResponse")),
+		}, http.StatusBadRequest),
+	})
+	doc.AddOperation(http.MethodGet, "/authors/{uuid}", &openapi.Operation{
+		OperationID: "getAuthor",
+		Summary:     "Get an author",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{idParameter("uuid", "Author identifier"), readPrimaryHeader()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Author", contentTypeJSON, openapi.Ref("AuthorResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodPut, "/authors/{uuid}", &openapi.Operation{
+		OperationID: "updateAuthor",
+		Summary:     "Replace the name and bio of an author",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{idParameter("uuid", "Author identifier")},
+		RequestBody: jsonBody(openapi.Ref("CreateAuthorRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Updated author", contentTypeJSON, openapi.Ref("AuthorResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodDelete, "/authors/{uuid}", &openapi.Operation{
+		OperationID: "deleteAuthor",
+		Summary:     "Delete an author",
+		Tags:        []string{"authors"},
+		Parameters:  []*openapi.Parameter{idParameter("uuid", "Author identifier")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Author deleted"},
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodPost, "/authors:batch", &openapi.Operation{
+		OperationID: "createAuthors",
+		Summary:     "Create authors in batch, results are reported per item",
+		Tags:        []string{"authors"},
+		RequestBody: jsonBody(arrayOf(openapi.Ref("CreateAuthorRequest"))),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Results by item", contentTypeJSON, arrayOf(openapi.Ref("BatchItemResponse"))),
+		}, http.StatusBadRequest),
+	})
+	doc.AddOperation(http.MethodDelete, "/authors:batch", &openapi.Operation{
+		OperationID: "deleteAuthors",
+		Summary:     "Delete authors in batch, results are reported per item",
+		Tags:        []string{"authors"},
+		RequestBody: jsonBody(arrayOf(&openapi.Schema{Type: openapi.TypeInteger, Format: "int64"})),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Results by item", contentTypeJSON, arrayOf(openapi.Ref("BatchItemResponse"))),
+		}, http.StatusBadRequest),
+	})
+
+	// Books, gated by the books feature flag: NOT_FOUND while it is off
+	doc.AddOperation(http.MethodPost, "/books", &openapi.Operation{
+		OperationID: "createBook",
+		Summary:     "Create a book",
+		Tags:        []string{"books"},
+		RequestBody: jsonBody(openapi.Ref("CreateBookRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": content("Created book", contentTypeJSON, openapi.Ref("BookResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodGet, "/books", &openapi.Operation{
+		OperationID: "listBooks",
+		Summary:     "List books by title, all of them unless a page is requested",
+		Tags:        []string{"books"},
+		Parameters:  append(pageParameters(), readPrimaryHeader()),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": page("Books", openapi.Ref("BookResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodGet, "/books/export", &openapi.Operation{
+		OperationID: "exportBooks",
+		Summary:     "Export books as NDJSON (default) or CSV, negotiated with the Accept header",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{readPrimaryHeader()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": exportResponse("Books", openapi.Ref("BookResponse")),
+		}, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodPost, "/books/import", &openapi.Operation{
+		OperationID: "importBooks",
+		Summary:     "Import books from NDJSON or CSV",
+		Tags:        []string{"books"},
+		RequestBody: importBody(openapi.Ref("CreateBookRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Import report", contentTypeJSON, openapi.Ref("ImportResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+
+	doc.AddOperation(http.MethodGet, "/books/{id}", &openapi.Operation{
+		OperationID: "getBook",
+		Summary:     "Get a book",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{idParameter("id", "Book identifier"), readPrimaryHeader()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Book", contentTypeJSON, openapi.Ref("BookResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodPut, "/books/{id}", &openapi.Operation{
+		OperationID: "updateBook",
+		Summary:     "Replace the title and author of a book",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{idParameter("id", "Book identifier")},
+		RequestBody: jsonBody(openapi.Ref("CreateBookRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Updated book", contentTypeJSON, openapi.Ref("BookResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+	doc.AddOperation(http.MethodDelete, "/books/{id}", &openapi.Operation{
+		OperationID: "deleteBook",
+		Summary:     "Delete a book",
+		Tags:        []string{"books"},
+		Parameters:  []*openapi.Parameter{idParameter("id", "Book identifier")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Book deleted"},
+		}, http.StatusBadRequest, http.StatusNotFound),
+	})
+
+	// Webhook subscriptions
+	doc.AddOperation(http.MethodPost, "/webhooks", &openapi.Operation{
+		OperationID: "createWebhook",
+		Summary:     "Subscribe to events",
+		Tags:        []string{"webhooks"},
+		RequestBody: jsonBody(openapi.Ref("CreateSubscriptionRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"201": content("Created subscription", contentTypeJSON, openapi.Ref("SubscriptionResponse")),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodGet, "/webhooks", &openapi.Operation{
+		OperationID: "listWebhooks",
+		Summary:     "List subscriptions",
+		Tags:        []string{"webhooks"},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Subscriptions", contentTypeJSON, arrayOf(openapi.Ref("SubscriptionResponse"))),
+		}, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodGet, "/webhooks/{id}", &openapi.Operation{
+		OperationID: "getWebhook",
+		Summary:     "Get a subscription",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{idParameter("id", "Subscription identifier")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Subscription", contentTypeJSON, openapi.Ref("SubscriptionResponse")),
+		}, http.StatusBadRequest, http.StatusNotFound, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodDelete, "/webhooks/{id}", &openapi.Operation{
+		OperationID: "deleteWebhook",
+		Summary:     "Delete a subscription",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{idParameter("id", "Subscription identifier")},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Subscription deleted"},
+		}, http.StatusBadRequest, http.StatusNotFound, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodGet, "/webhooks/{id}/deliveries", &openapi.Operation{
+		OperationID: "listWebhookDeliveries",
+		Summary:     "Delivery log of a subscription, most recent first",
+		Tags:        []string{"webhooks"},
+		Parameters: []*openapi.Parameter{
+			idParameter("id", "Subscription identifier"),
+			{
+				Name:        "limit",
+				In:          openapi.InQuery,
+				Description: "Maximum number of deliveries (default " + strconv.Itoa(webhooks.DefaultDeliveriesLimit) + ")",
+				Schema: &openapi.Schema{
+					Type:    openapi.TypeInteger,
+					Minimum: openapi.Ptr(1.0),
+					Maximum: openapi.Ptr(float64(webhooks.MaxDeliveriesLimit)),
+				},
+			},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Deliveries", contentTypeJSON, arrayOf(openapi.Ref("DeliveryResponse"))),
+		}, http.StatusBadRequest, http.StatusNotFound, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+
+	// Change stream
+	doc.AddOperation(http.MethodGet, "/events", &openapi.Operation{
+		OperationID: "streamEvents",
+		Summary:     "Stream of catalog changes (Server-Sent Events)",
+		Tags:        []string{"events"},
+		Parameters: []*openapi.Parameter{
+			{
+				Name:        "Last-Event-ID",
+				In:          openapi.InHeader,
+				Description: "Resume after this event, a reset event is sent if the events since are lost",
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        "lastEventId",
+				In:          openapi.InQuery,
+				Description: "Resume after this event, for clients unable to set headers",
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+		},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Event stream", "text/event-stream", &openapi.Schema{Type: openapi.TypeString}),
+		}),
+	})
+
+	// GraphQL
+	doc.AddOperation(http.MethodPost, "/graphql", &openapi.Operation{
+		OperationID: "graphql",
+		Summary:     "GraphQL queries and mutations on authors and books, errors are returned in the response",
+		Tags:        []string{"graphql"},
+		RequestBody: jsonBody(openapi.Ref("GraphQLRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("GraphQL response", contentTypeJSON, openapi.Ref("GraphQLResponse")),
+		}, http.StatusBadRequest),
+	})
+
+	// Audit log
+	doc.AddOperation(http.MethodGet, "/audit", &openapi.Operation{
+		OperationID: "listAuditEntries",
+		Summary:     "List the changes of authors and books, most recent first, a page at a time",
+		Tags:        []string{"audit"},
+		Parameters: append([]*openapi.Parameter{
+			{
+				Name:        audit.ParamEntityType,
+				In:          openapi.InQuery,
+				Description: "Changes of this entity type",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Enum: entityTypes()},
+			},
+			{
+				Name:        audit.ParamEntityID,
+				In:          openapi.InQuery,
+				Description: "Changes of this entity, with " + audit.ParamEntityType,
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        audit.ParamActor,
+				In:          openapi.InQuery,
+				Description: "Changes made by this caller",
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        audit.ParamFrom,
+				In:          openapi.InQuery,
+				Description: "Changes made at or after this time",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: "date-time"},
+			},
+			{
+				Name:        audit.ParamTo,
+				In:          openapi.InQuery,
+				Description: "Changes made before this time",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: "date-time"},
+			},
+		}, pageParameters()...),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": page("Audit log entries", openapi.Ref("AuditEntryResponse")),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+
+	// Feature flags administration
+	doc.AddOperation(http.MethodGet, "/admin/flags", &openapi.Operation{
+		OperationID: "listFlags",
+		Summary:     "List the feature flags by name, from the code defaults, configuration and database",
+		Tags:        []string{"admin"},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Feature flags", contentTypeJSON, arrayOf(openapi.Ref("FlagResponse"))),
+		}, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodGet, "/admin/flags/{name}", &openapi.Operation{
+		OperationID: "getFlag",
+		Summary:     "Get a feature flag",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{flagNameParameter()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Feature flag", contentTypeJSON, openapi.Ref("FlagResponse")),
+		}, http.StatusNotFound, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodPut, "/admin/flags/{name}", &openapi.Operation{
+		OperationID: "updateFlag",
+		Summary:     "Define a feature flag in the database, overriding its default and configuration",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{flagNameParameter()},
+		RequestBody: jsonBody(openapi.Ref("UpdateFlagRequest")),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": content("Updated feature flag", contentTypeJSON, openapi.Ref("FlagResponse")),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+	doc.AddOperation(http.MethodDelete, "/admin/flags/{name}", &openapi.Operation{
+		OperationID: "deleteFlag",
+		Summary:     "Delete a feature flag from the database, restoring its default or configuration",
+		Tags:        []string{"admin"},
+		Parameters:  []*openapi.Parameter{flagNameParameter()},
+		Responses: withErrors(map[string]*openapi.Response{
+			"204": {Description: "Feature flag deleted"},
+		}, http.StatusNotFound, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+
+	// Scheduled tasks
+	doc.AddOperation(http.MethodGet, "/admin/scheduler/runs", &openapi.Operation{
+		OperationID: "listSchedulerRuns",
+		Summary:     "List the runs of the scheduled tasks, most recent first, a page at a time",
+		Tags:        []string{"admin"},
+		Parameters: append([]*openapi.Parameter{
+			{
+				Name:        scheduler.ParamTask,
+				In:          openapi.InQuery,
+				Description: "Runs of this task, e.g. purge-outbox",
+				Schema:      &openapi.Schema{Type: openapi.TypeString},
+			},
+			{
+				Name:        scheduler.ParamFrom,
+				In:          openapi.InQuery,
+				Description: "Runs scheduled at or after this time",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: "date-time"},
+			},
+			{
+				Name:        scheduler.ParamTo,
+				In:          openapi.InQuery,
+				Description: "Runs scheduled before this time",
+				Schema:      &openapi.Schema{Type: openapi.TypeString, Format: "date-time"},
+			},
+		}, pageParameters()...),
+		Responses: withErrors(map[string]*openapi.Response{
+			"200": page("Runs of the scheduled tasks", openapi.Ref("SchedulerRunResponse")),
+		}, http.StatusBadRequest, http.StatusUnauthorized),
+		Security: adminOnly(),
+	})
+
+	return doc
+}
+
+// registerSchemas adds the payload schemas with the domain constraints.
+func registerSchemas(doc *openapi.Document) {
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		adminTokenScheme: {Type: "http", Scheme: "bearer", Description: "The admintoken setting of the server"},
+	}
+
+	errorResponse := doc.Register("ErrorResponse", apperror.ErrorResponse{})
+	errorResponse.Properties["code"].Enum = []any{
+		apperror.ErrCodeValidation, apperror.ErrCodeNotFound, apperror.ErrCodeConflict,
+		apperror.ErrCodeInternal, apperror.ErrCodeUnauthorized, apperror.ErrCodeForbidden,
+		apperror.ErrCodeBadRequest, apperror.ErrCodeTooManyRequests,
+	}
+
+	createAuthor := doc.Register("CreateAuthorRequest", authors.CreateAuthorRequest{})
+	createAuthor.Description = "Leading and trailing spaces are trimmed before checking lengths."
+	createAuthor.Properties["name"].MinLength = openapi.Ptr(authors.MinNameLength)
+	createAuthor.Properties["name"].MaxLength = openapi.Ptr(authors.MaxNameLength)
+	createAuthor.Properties["bio"].MaxLength = openapi.Ptr(authors.MaxBioLength)
+	// The bio is optional, an absent bio is empty.
+	createAuthor.Required = []string{"name"}
+	doc.Register("AuthorResponse", authors.AuthorResponse{})
+	doc.Register("BatchItemResponse", authors.BatchItemResponse{})
+
+	createBook := doc.Register("CreateBookRequest", books.CreateBookRequest{})
+	createBook.Description = "Leading and trailing spaces are trimmed before checking lengths."
+	createBook.Properties["title"].MinLength = openapi.Ptr(books.MinTitleLength)
+	createBook.Properties["title"].MaxLength = openapi.Ptr(books.MaxTitleLength)
+	doc.Register("BookResponse", books.BookResponse{})
+
+	doc.Register("ImportResponse", exchange.ImportResponse{})
+
+	eventTypes := make([]any, len(webhooks.EventTypes))
+	for i, eventType := range webhooks.EventTypes {
+		eventTypes[i] = eventType
+	}
+	createSubscription := doc.Register("CreateSubscriptionRequest", webhooks.CreateSubscriptionRequest{})
+	createSubscription.Properties["url"].Format = "uri"
+	createSubscription.Properties["secret"].MinLength = openapi.Ptr(webhooks.MinSecretLength)
+	createSubscription.Properties["event_types"].MinItems = openapi.Ptr(1)
+	createSubscription.Properties["event_types"].Items.Enum = eventTypes
+	subscription := doc.Register("SubscriptionResponse", webhooks.SubscriptionResponse{})
+	subscription.Properties["event_types"].Items.Enum = eventTypes
+	doc.Register("GraphQLRequest", graph.Request{}).Properties["query"].MinLength = openapi.Ptr(1)
+	doc.Register("GraphQLResponse", graphql.Response{})
+
+	updateFlag := doc.Register("UpdateFlagRequest", flags.UpdateFlagRequest{})
+	updateFlag.Properties["description"].MaxLength = openapi.Ptr(flags.MaxDescriptionLength)
+	updateFlag.Properties["percentage"].Minimum = openapi.Ptr(0.0)
+	updateFlag.Properties["percentage"].Maximum = openapi.Ptr(float64(flags.MaxPercentage))
+	updateFlag.Properties["identities"].Items.MinLength = openapi.Ptr(1)
+	updateFlag.Properties["headers"].Description = "Header values turning the flag on, by header name"
+	flag := doc.Register("FlagResponse", flags.FlagResponse{})
+	flag.Properties["source"].Enum = []any{flags.SourceDefault, flags.SourceConfig, flags.SourceDatabase}
+
+	auditEntry := doc.Register("AuditEntryResponse", audit.EntryResponse{})
+	auditEntry.Description = "Before and after are null for creations and deletions respectively; " +
+		"updates only keep the changed fields."
+	auditEntry.Properties["entity_type"].Enum = entityTypes()
+	auditEntry.Properties["action"].Enum = []any{audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete}
+
+	run := doc.Register("SchedulerRunResponse", scheduler.RunResponse{})
+	run.Properties["status"].Enum = []any{scheduler.StatusRunning, scheduler.StatusSucceeded, scheduler.StatusFailed}
+
+	delivery := doc.Register("DeliveryResponse", webhooks.DeliveryResponse{})
+	delivery.Properties["status"].Enum = []any{
+		webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusDead,
+	}
+}
+
+func content(description, contentType string, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     map[string]*openapi.MediaType{contentType: {Schema: schema}},
+	}
+}
+
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content:  map[string]*openapi.MediaType{contentTypeJSON: {Schema: schema}},
+	}
+}
+
+// importBody documents NDJSON lines of schema, or CSV with the same columns.
+func importBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content: map[string]*openapi.MediaType{
+			exchange.ContentTypeNDJSON: {Schema: schema},
+			exchange.ContentTypeCSV:    {Schema: &openapi.Schema{Type: openapi.TypeString}},
+		},
+	}
+}
+
+// exportResponse documents NDJSON lines of schema, or CSV with the same columns.
+func exportResponse(description string, schema *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			exchange.ContentTypeNDJSON: {Schema: schema},
+			exchange.ContentTypeCSV:    {Schema: &openapi.Schema{Type: openapi.TypeString}},
+		},
+	}
+}
+
+func arrayOf(items *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{Type: openapi.TypeArray, Items: items}
+}
+
+// pageParameters documents the pagination query parameters.
+func pageParameters() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		{
+			Name:        pagination.ParamLimit,
+			In:          openapi.InQuery,
+			Description: "Maximum number of items of the page (default " + strconv.Itoa(pagination.DefaultLimit) + ")",
+			Schema: &openapi.Schema{
+				Type:    openapi.TypeInteger,
+				Minimum: openapi.Ptr(1.0),
+				Maximum: openapi.Ptr(float64(pagination.MaxLimit)),
+			},
+		},
+		{
+			Name:        pagination.ParamCursor,
+			In:          openapi.InQuery,
+			Description: "Opaque position of the page, from the Link header of the previous page",
+			Schema:      &openapi.Schema{Type: openapi.TypeString},
+		},
+	}
+}
+
+// page documents a page of items, linked to the next one.
+func page(description string, items *openapi.Schema) *openapi.Response {
+	response := content(description, contentTypeJSON, arrayOf(items))
+	response.Headers = map[string]*openapi.Header{
+		"Link": {
+			Description: `URL of the next page with rel="next", absent on the last page`,
+			Schema:      &openapi.Schema{Type: openapi.TypeString},
+		},
+	}
+	return response
+}
+
+func idParameter(name, description string) *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        name,
+		In:          openapi.InPath,
+		Description: description,
+		Required:    true,
+		Schema:      &openapi.Schema{Type: openapi.TypeInteger, Format: "int64"},
+	}
+}
+
+func entityTypes() []any {
+	types := make([]any, len(audit.EntityTypes))
+	for i, entityType := range audit.EntityTypes {
+		types[i] = entityType
+	}
+	return types
+}
+
+func flagNameParameter() *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        "name",
+		In:          openapi.InPath,
+		Description: "Feature flag name",
+		Required:    true,
+		Schema:      &openapi.Schema{Type: openapi.TypeString, MaxLength: openapi.Ptr(flags.MaxNameLength)},
+	}
+}
+
+func readPrimaryHeader() *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        middleware.ReadPrimaryHeader,
+		In:          openapi.InHeader,
+		Description: "Read from the primary database instead of a replica",
+		Schema:      &openapi.Schema{Type: openapi.TypeBoolean},
+	}
+}
+
+// withErrors adds the error responses of statuses, and of the rate limit
+// and internal errors which any operation may return.
+// adminOnly requires the admin token (middleware.AdminToken).
+func adminOnly() []openapi.SecurityRequirement {
+	return []openapi.SecurityRequirement{{adminTokenScheme: {}}}
+}
+
+func withErrors(responses map[string]*openapi.Response, statuses ...int) map[string]*openapi.Response {
+	for _, status := range append(statuses, http.StatusTooManyRequests, http.StatusInternalServerError) {
+		responses[strconv.Itoa(status)] = content(http.StatusText(status), contentTypeJSON, openapi.Ref("ErrorResponse"))
+	}
+	return responses
+}
+
+// serveOpenAPI handles GET /openapi.json.
+func (w *WebServer) serveOpenAPI(rw http.ResponseWriter, _ *http.Request) {
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(w.spec); err != nil {
+		// Response already written, can't send error response
+		return
+	}
+}
@@ -0,0 +1,97 @@
+package webserver_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/openapi"
+	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpec_CoversRoutes(t *testing.T) {
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	routes := map[string]bool{}
+	err = chi.Walk(w.Router(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes[method+" "+route] = true
+		assert.NotNil(t, w.OpenAPI().Operation(method, route),
+			"route %s %s is missing from the OpenAPI document", method, route)
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, route := range w.OpenAPI().Routes() {
+		assert.True(t, routes[route], "%s is documented but not registered", route)
+	}
+}
+
+func TestSpec_Constraints(t *testing.T) {
+	doc := webserver.Spec()
+
+	author := doc.Components.Schemas["CreateAuthorRequest"]
+	require.NotNil(t, author)
+	assert.Equal(t, []string{"name"}, author.Required)
+	assert.Equal(t, authors.MinNameLength, *author.Properties["name"].MinLength)
+	assert.Equal(t, authors.MaxNameLength, *author.Properties["name"].MaxLength)
+	assert.Equal(t, authors.MaxBioLength, *author.Properties["bio"].MaxLength)
+
+	book := doc.Components.Schemas["CreateBookRequest"]
+	require.NotNil(t, book)
+	assert.Equal(t, books.MinTitleLength, *book.Properties["title"].MinLength)
+	assert.Equal(t, books.MaxTitleLength, *book.Properties["title"].MaxLength)
+
+	errorResponse := doc.Components.Schemas["ErrorResponse"]
+	require.NotNil(t, errorResponse)
+	assert.ElementsMatch(t, []string{"code", "message"}, errorResponse.Required)
+	assert.NotEmpty(t, errorResponse.Properties["code"].Enum)
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	w.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&doc))
+	assert.Equal(t, openapi.Version, doc["openapi"])
+	assert.Contains(t, doc["paths"], "/authors/{uuid}")
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	name := schemas["CreateAuthorRequest"].(map[string]any)["properties"].(map[string]any)["name"].(map[string]any)
+	assert.InDelta(t, authors.MinNameLength, name["minLength"], 0)
+}
+
+func TestValidation(t *testing.T) {
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil, webserver.WithResponseValidation())
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	w.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	w.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/authors/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{
+		"code": "VALIDATION_ERROR",
+		"message": "Request does not match the API description",
+		"details": {"/path/uuid": "must be of type integer"}
+	}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	w.Router().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/authors", strings.NewReader(`{"name":"abc"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"/body/name":"must be at least 5 characters"`)
+}
@@ -1,7 +1,13 @@
 package webserver
 
 import (
+	"expvar"
+	"log/slog"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/pkg/flags"
 )
 
 // "github.com/go-redis/redis/v7"
@@ -9,15 +15,96 @@ import (
 func (w *WebServer) initRoutes() {
 	// Health check
 	w.router.Get("/", HealthCheck)
+	w.router.Get("/ready", w.Ready)
+
+	// Runtime metrics (cache hits and misses, memory stats...), for operators
+	w.router.With(middleware.AdminToken(w.adminToken)).Get("/debug/vars", expvar.Handler().ServeHTTP)
+
+	// API description
+	w.router.Get("/openapi.json", w.serveOpenAPI)
 
 	// Authors routes
 	w.router.Post("/authors", w.authorsHandler.Create)
 	w.router.Get("/authors", w.authorsHandler.List)
+	w.router.Get("/authors/export", w.authorsHandler.Export)
+	w.router.Post("/authors/import", w.authorsHandler.Import)
+	w.router.Get("/authors/{uuid}", w.authorsHandler.Get)
+	w.router.Put("/authors/{uuid}", w.authorsHandler.Update)
 	w.router.Delete("/authors/{uuid}", w.authorsHandler.Delete)
+	w.router.Post("/authors:batch", w.authorsHandler.CreateBatch)
+	w.router.Delete("/authors:batch", w.authorsHandler.DeleteBatch)
+
+	// Books routes
+	w.router.Group(func(r chi.Router) {
+		r.Use(w.requireFlag(FeatureBooks))
+		r.Post("/books", w.booksHandler.Create)
+		r.Get("/books", w.booksHandler.List)
+		r.Get("/books/export", w.booksHandler.Export)
+		r.Post("/books/import", w.booksHandler.Import)
+		r.Get("/books/{id}", w.booksHandler.Get)
+		r.Put("/books/{id}", w.booksHandler.Update)
+		r.Delete("/books/{id}", w.booksHandler.Delete)
+	})
+
+	// Change stream
+	w.router.Get("/events", w.eventsHandler.Stream)
+
+	// GraphQL
+	w.router.Post("/graphql", w.graphqlHandler.Serve)
+
+	// Operator routes, requiring the admin token
+	w.router.Group(func(r chi.Router) {
+		r.Use(middleware.AdminToken(w.adminToken))
+
+		// Webhook subscriptions, registered for partners
+		r.Post("/webhooks", w.webhooksHandler.Create)
+		r.Get("/webhooks", w.webhooksHandler.List)
+		r.Get("/webhooks/{id}", w.webhooksHandler.Get)
+		r.Delete("/webhooks/{id}", w.webhooksHandler.Delete)
+		r.Get("/webhooks/{id}/deliveries", w.webhooksHandler.ListDeliveries)
+
+		// Audit log
+		r.Get("/audit", w.auditHandler.List)
+
+		// Feature flags administration
+		r.Get("/admin/flags", w.flagsHandler.List)
+		r.Get("/admin/flags/{name}", w.flagsHandler.Get)
+		r.Put("/admin/flags/{name}", w.flagsHandler.Update)
+		r.Delete("/admin/flags/{name}", w.flagsHandler.Delete)
+
+		// Run history of the scheduled tasks
+		r.Get("/admin/scheduler/runs", w.runsHandler.List)
+	})
+}
+
+// requireFlag gates routes behind the flag name when the server has feature
+// flags, see WithFlags.
+func (w *WebServer) requireFlag(name string) func(http.Handler) http.Handler {
+	if w.flags == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return flags.Require(name)
 }
 
 // HealthCheck is the health check endpoint.
 func HealthCheck(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write([]byte("OK"))
 }
+
+// Ready is the readiness endpoint: it reports whether the dependencies of
+// the server, checked with WithReadinessCheck, are available.
+func (w *WebServer) Ready(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, c := range w.readiness {
+		if err := c.check(r.Context()); err != nil {
+			slog.Warn("readiness check failed", "check", c.name, "error", err)
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = rw.Write([]byte(c.name + " is not ready"))
+			return
+		}
+	}
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte("OK"))
+}
@@ -11,7 +11,15 @@ import (
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/sgaunet/template-api/internal/middleware"
+	"github.com/sgaunet/template-api/internal/openapi"
+	"github.com/sgaunet/template-api/internal/scheduler"
+	"github.com/sgaunet/template-api/pkg/audit"
 	"github.com/sgaunet/template-api/pkg/authors"
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/events"
+	"github.com/sgaunet/template-api/pkg/flags"
+	"github.com/sgaunet/template-api/pkg/graph"
+	"github.com/sgaunet/template-api/pkg/webhooks"
 	// "github.com/go-redis/redis/v7".
 )
 
@@ -20,17 +28,140 @@ const (
 	readHeaderTimeout        = 100 * time.Millisecond
 )
 
+// FeatureBooks is the flag gating the books routes, and the books of the
+// GraphQL and gRPC APIs.
+const FeatureBooks = "books"
+
+// Features are the defaults of the flags gating routes, see WithFlags.
+var Features = map[string]bool{
+	FeatureBooks: true,
+}
+
 // WebServer is the web server.
 type WebServer struct {
-	srv            *http.Server
-	router         *chi.Mux
-	authorsHandler *authors.Handler
+	srv             *http.Server
+	router          *chi.Mux
+	authorsHandler  *authors.Handler
+	booksHandler    *books.Handler
+	webhooksHandler *webhooks.Handler
+	eventsHandler   *events.Handler
+	graphqlHandler  *graph.Handler
+	auditHandler    *audit.Handler
+	flagsHandler    *flags.Handler
+	runsHandler     *scheduler.Handler
+	flags           *flags.Store
+	spec            *openapi.Document
+	readiness       []readinessCheck
+	adminToken      string
+}
+
+// Option configures a WebServer.
+type Option func(*options)
+
+type options struct {
+	validation []middleware.ValidationOption
+	validate   bool
+	readiness  []readinessCheck
+	middleware []func(http.Handler) http.Handler
+	flags      *flags.Store
+	runs       *scheduler.Handler
+	adminToken string
+}
+
+// WithAdminToken sets the bearer token of the administration routes
+// (/debug/vars, /admin, /audit and /webhooks). Without it, they answer 401
+// to every request.
+func WithAdminToken(token string) Option {
+	return func(o *options) {
+		o.adminToken = token
+	}
+}
+
+// WithFlags evaluates the feature flags of store for each request, gates
+// the routes of Features with them, and serves their administration on
+// /admin/flags. Without it, every route is served.
+func WithFlags(store *flags.Store) Option {
+	return func(o *options) {
+		o.flags = store
+	}
+}
+
+// WithSchedulerRuns serves the run history of the scheduled tasks on
+// /admin/scheduler/runs.
+func WithSchedulerRuns(handler *scheduler.Handler) Option {
+	return func(o *options) {
+		o.runs = handler
+	}
+}
+
+// WithMiddleware adds middleware to every route, after the request ID,
+// logging and recovery middleware, e.g. CORS or rate limiting.
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// readinessCheck is a dependency checked by GET /ready.
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// WithReadinessCheck adds a dependency to check before reporting the server
+// ready, e.g. a database ping. GET /ready fails while check returns an error.
+func WithReadinessCheck(name string, check func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.readiness = append(o.readiness, readinessCheck{name: name, check: check})
+	}
+}
+
+// WithRequestValidation validates requests against the OpenAPI document.
+func WithRequestValidation() Option {
+	return func(o *options) {
+		o.validate = true
+	}
+}
+
+// WithResponseValidation validates requests and responses against the
+// OpenAPI document. Responses are buffered, it's meant for tests.
+func WithResponseValidation() Option {
+	return func(o *options) {
+		o.validate = true
+		o.validation = append(o.validation, middleware.WithResponseValidation())
+	}
 }
 
 // NewWebServer creates a new web server.
-func NewWebServer(authorsHandler *authors.Handler) (*WebServer, error) {
+func NewWebServer(
+	authorsHandler *authors.Handler,
+	booksHandler *books.Handler,
+	webhooksHandler *webhooks.Handler,
+	eventsHandler *events.Handler,
+	graphqlHandler *graph.Handler,
+	auditHandler *audit.Handler,
+	opts ...Option,
+) (*WebServer, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	w := &WebServer{
-		authorsHandler: authorsHandler,
+		authorsHandler:  authorsHandler,
+		booksHandler:    booksHandler,
+		webhooksHandler: webhooksHandler,
+		eventsHandler:   eventsHandler,
+		graphqlHandler:  graphqlHandler,
+		auditHandler:    auditHandler,
+		runsHandler:     o.runs,
+		spec:            Spec(),
+		readiness:       o.readiness,
+		flags:           o.flags,
+		adminToken:      o.adminToken,
+	}
+	if o.flags != nil {
+		w.flagsHandler = flags.NewHandler(o.flags)
 	}
 	w.router = chi.NewRouter()
 
@@ -38,7 +169,16 @@ func NewWebServer(authorsHandler *authors.Handler) (*WebServer, error) {
 	w.router.Use(chimiddleware.RequestID)
 	w.router.Use(chimiddleware.Logger)
 	w.router.Use(middleware.Recovery)
+	w.router.Use(audit.Middleware)
+	w.router.Use(o.middleware...)
+	if o.flags != nil {
+		w.router.Use(o.flags.Middleware)
+	}
 	w.router.Use(middleware.JSONContentType)
+	w.router.Use(middleware.PrimaryReads)
+	if o.validate {
+		w.router.Use(middleware.OpenAPIValidation(w.spec, o.validation...))
+	}
 
 	w.initRoutes()
 
@@ -67,6 +207,12 @@ func (w *WebServer) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// Handler returns the HTTP handler of the routes, e.g. to serve them with
+// httptest.
+func (w *WebServer) Handler() http.Handler {
+	return w.router
+}
+
 // SetListenAddr sets the listen address (format expected: ":3000")
 // It won't restart the webserver if it's already running.
 func (w *WebServer) SetListenAddr(addr string) {
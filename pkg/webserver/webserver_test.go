@@ -2,18 +2,25 @@ package webserver_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/sgaunet/template-api/pkg/books"
+	"github.com/sgaunet/template-api/pkg/flags"
 	"github.com/sgaunet/template-api/pkg/webserver"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWebserverStart(t *testing.T) {
 	// mockSvc := authors.NewService(nil)
 	var wg sync.WaitGroup
-	w, err := webserver.NewWebServer(nil)
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -32,7 +39,7 @@ func TestWebserverStart(t *testing.T) {
 func TestWebserverStartTwiceOnSamePort(t *testing.T) {
 	// mockSvc := authors.NewService(nil)
 	var wg sync.WaitGroup
-	w, err := webserver.NewWebServer(nil)
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -55,3 +62,86 @@ func TestWebserverStartTwiceOnSamePort(t *testing.T) {
 	assert.Nil(t, err, "unexpected error: %v", err)
 	wg.Wait()
 }
+
+func TestReady(t *testing.T) {
+	var dbErr error
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil,
+		webserver.WithReadinessCheck("database", func(context.Context) error { return dbErr }))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "OK", rec.Body.String())
+
+	dbErr = errors.New("connection refused")
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "database is not ready", rec.Body.String())
+}
+
+func TestAdminToken(t *testing.T) {
+	w, err := webserver.NewWebServer(nil, nil, nil, nil, nil, nil, webserver.WithAdminToken("s3cret"))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/audit", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"memstats"`)
+}
+
+// fakeBooks is a books.Service without books, only List is implemented.
+type fakeBooks struct {
+	books.Service
+}
+
+func (fakeBooks) List(context.Context) ([]*books.Book, error) {
+	return nil, nil
+}
+
+func TestFlags_GateRoutes(t *testing.T) {
+	store := flags.NewStore(nil, flags.WithDefaults(webserver.Features))
+	w, err := webserver.NewWebServer(nil, books.NewHandler(fakeBooks{}), nil, nil, nil, nil,
+		webserver.WithFlags(store), webserver.WithAdminToken("s3cret"))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	store.SetConfig(map[string]bool{webserver.FeatureBooks: false})
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// other routes aren't gated
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the administration requires the admin token
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec,
+		httptest.NewRequest(http.MethodPut, "/admin/flags/books", strings.NewReader(`{"enabled":true}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/flags/books", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	w.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"source":"config"`)
+}
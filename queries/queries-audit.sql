@@ -0,0 +1,16 @@
+-- name: InsertAuditEntry :exec
+INSERT INTO audit_log (entity_type, entity_id, action, actor, request_id, ip, before, after, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: ListAuditEntries :many
+-- Most recent first, keyset pagination on id. Empty filters match any entry.
+SELECT *
+FROM audit_log
+WHERE (@entity_type::VARCHAR(32) = '' OR entity_type = @entity_type)
+  AND (@entity_id::VARCHAR(64) = '' OR entity_id = @entity_id)
+  AND (@actor::TEXT = '' OR actor = @actor)
+  AND (sqlc.narg(created_from)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg(created_to))
+  AND (@before_id::BIGINT = 0 OR id < @before_id)
+ORDER BY id DESC
+LIMIT @max_rows;
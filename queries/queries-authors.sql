@@ -32,3 +32,48 @@ WHERE id = $1;
 SELECT *
 FROM authors
 ORDER BY name;
+
+-- name: ListAuthorsPage :many
+-- Keyset pagination on (name, id), the order of ListAuthors.
+SELECT *
+FROM authors
+WHERE (name, id) > (@after_name::VARCHAR(32), @after_id::BIGINT)
+ORDER BY name, id
+LIMIT @max_rows;
+
+-- name: CreateAuthors :many
+-- The IDs are allocated before inserting, so that the authors are returned
+-- in input order: RETURNING doesn't guarantee any order.
+WITH input AS (
+    SELECT nextval(pg_get_serial_sequence('authors', 'id')) AS id, t.name, (@bios::TEXT[])[t.ord] AS bio, t.ord
+    FROM unnest(@names::VARCHAR(32)[]) WITH ORDINALITY AS t(name, ord)
+), inserted AS (
+    INSERT INTO authors (id, name, bio)
+    SELECT id, name, bio
+    FROM input
+    RETURNING *
+)
+SELECT inserted.*
+FROM inserted
+JOIN input USING (id)
+ORDER BY input.ord;
+
+-- name: DeleteAuthors :many
+DELETE
+FROM authors
+WHERE id = ANY(@ids::BIGINT[])
+RETURNING id;
+
+-- name: GetAuthorsByIDs :many
+SELECT *
+FROM authors
+WHERE id = ANY(@ids::BIGINT[])
+ORDER BY id;
+
+-- name: LockAuthors :many
+-- Locks the authors about to be changed, returning them as before the change.
+SELECT *
+FROM authors
+WHERE id = ANY(@ids::BIGINT[])
+ORDER BY id
+FOR UPDATE;
@@ -16,7 +16,14 @@ SET title = $2
 WHERE id = $1
 RETURNING *;
 
--- name: DeleteBook :exec
+-- name: UpdateBook :one
+UPDATE books
+SET title     = $2,
+    author_id = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteBook :execrows
 DELETE
 FROM books
 WHERE id = $1;
@@ -25,3 +32,24 @@ WHERE id = $1;
 SELECT *
 FROM books
 ORDER BY title;
+
+-- name: ListBooksPage :many
+-- Keyset pagination on (title, id), the order of ListBooks.
+SELECT *
+FROM books
+WHERE (title, id) > (@after_title::VARCHAR(32), @after_id::BIGINT)
+ORDER BY title, id
+LIMIT @max_rows;
+
+-- name: ListBooksByAuthorIDs :many
+SELECT *
+FROM books
+WHERE author_id = ANY(@author_ids::BIGINT[])
+ORDER BY author_id, title;
+
+-- name: LockBook :one
+-- Locks the book about to be changed, returning it as before the change.
+SELECT *
+FROM books
+WHERE id = $1
+FOR UPDATE;
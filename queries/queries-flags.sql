@@ -0,0 +1,22 @@
+-- name: ListFeatureFlags :many
+SELECT *
+FROM feature_flags
+ORDER BY name;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (name, description, enabled, percentage, identities, headers, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (name) DO UPDATE
+SET description = excluded.description,
+    enabled     = excluded.enabled,
+    percentage  = excluded.percentage,
+    identities  = excluded.identities,
+    headers     = excluded.headers,
+    updated_by  = excluded.updated_by,
+    updated_at  = now()
+RETURNING *;
+
+-- name: DeleteFeatureFlag :execrows
+DELETE
+FROM feature_flags
+WHERE name = $1;
@@ -0,0 +1,53 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (kind, payload, max_attempts, run_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: ClaimJob :one
+-- Claims the next due job, or a running job whose worker died before the
+-- end of its lease.
+UPDATE jobs
+SET status       = 'running',
+    attempts     = attempts + 1,
+    locked_until = @locked_until::TIMESTAMPTZ
+WHERE id = (
+    SELECT id
+    FROM jobs
+    WHERE (status = 'pending' AND run_at <= now())
+       OR (status = 'running' AND locked_until < now())
+    ORDER BY run_at, id
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+-- The outcome of a job is only recorded by the worker holding its lease,
+-- the job may have been claimed again after the lease expired.
+UPDATE jobs
+SET status       = 'succeeded',
+    locked_until = NULL,
+    finished_at  = now()
+WHERE id = @id
+  AND status = 'running'
+  AND locked_until = @lease_until::TIMESTAMPTZ;
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET status       = 'pending',
+    locked_until = NULL,
+    run_at       = @run_at,
+    last_error   = @last_error
+WHERE id = @id
+  AND status = 'running'
+  AND locked_until = @lease_until::TIMESTAMPTZ;
+
+-- name: FailJob :execrows
+UPDATE jobs
+SET status       = 'failed',
+    locked_until = NULL,
+    last_error   = @last_error,
+    finished_at  = now()
+WHERE id = @id
+  AND status = 'running'
+  AND locked_until = @lease_until::TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+-- name: PurgePublishedOutboxEvents :execrows
+DELETE
+FROM outbox
+WHERE published_at < @before::TIMESTAMPTZ;
+
+-- name: PurgeFinishedJobs :execrows
+DELETE
+FROM jobs
+WHERE finished_at < @before::TIMESTAMPTZ;
+
+-- name: PurgeFinishedWebhookDeliveries :execrows
+DELETE
+FROM webhook_deliveries
+WHERE status <> 'pending' AND created_at < @before::TIMESTAMPTZ;
+
+-- name: PurgeSchedulerRuns :execrows
+DELETE
+FROM scheduler_runs
+WHERE finished_at < @before::TIMESTAMPTZ;
+
+-- name: AnalyzeCatalog :exec
+ANALYZE authors, books;
@@ -0,0 +1,20 @@
+-- name: InsertOutboxEvent :exec
+INSERT INTO outbox (event_id, aggregate_type, aggregate_id, event_type, payload, created_at)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: TryOutboxRelayLock :one
+SELECT pg_try_advisory_xact_lock(@lock_id::BIGINT)::boolean AS acquired;
+
+-- name: ClaimOutboxEvents :many
+SELECT *
+FROM outbox
+WHERE published_at IS NULL
+ORDER BY id
+LIMIT $1
+FOR UPDATE;
+
+-- name: MarkOutboxEventsPublished :exec
+UPDATE outbox
+SET published_at = now()
+WHERE id = ANY(@ids::BIGINT[]);
+
@@ -0,0 +1,26 @@
+-- name: StartSchedulerRun :one
+-- Records the run of a task occurrence, no row is returned when the
+-- occurrence already ran.
+INSERT INTO scheduler_runs (task, scheduled_at, instance)
+VALUES ($1, $2, $3)
+ON CONFLICT (task, scheduled_at) DO NOTHING
+RETURNING id;
+
+-- name: FinishSchedulerRun :exec
+UPDATE scheduler_runs
+SET status      = @status,
+    error       = @error,
+    finished_at = now()
+WHERE id = @id;
+
+
+-- name: ListSchedulerRuns :many
+-- Most recent first, keyset pagination on id. Empty filters match any run.
+SELECT *
+FROM scheduler_runs
+WHERE (@task::VARCHAR(64) = '' OR task = @task)
+  AND (sqlc.narg(scheduled_from)::TIMESTAMPTZ IS NULL OR scheduled_at >= sqlc.narg(scheduled_from))
+  AND (sqlc.narg(scheduled_to)::TIMESTAMPTZ IS NULL OR scheduled_at < sqlc.narg(scheduled_to))
+  AND (@before_id::BIGINT = 0 OR id < @before_id)
+ORDER BY id DESC
+LIMIT @max_rows;
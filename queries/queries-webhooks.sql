@@ -0,0 +1,68 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (url, secret, event_types)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT *
+FROM webhook_subscriptions
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT *
+FROM webhook_subscriptions
+ORDER BY id;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE
+FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+SELECT id, @event_id, @event_type, @payload
+FROM webhook_subscriptions
+WHERE @event_type::TEXT = ANY(event_types)
+ON CONFLICT (subscription_id, event_id) DO NOTHING;
+
+-- name: ClaimWebhookDeliveries :many
+WITH claimed AS (
+    UPDATE webhook_deliveries
+    SET next_attempt_at = @lease_until
+    WHERE webhook_deliveries.id IN (
+        SELECT id
+        FROM webhook_deliveries
+        WHERE status = 'pending' AND next_attempt_at <= now()
+        ORDER BY next_attempt_at
+        LIMIT @max_deliveries
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING webhook_deliveries.id, webhook_deliveries.subscription_id, webhook_deliveries.event_id,
+        webhook_deliveries.payload, webhook_deliveries.attempts, webhook_deliveries.next_attempt_at
+)
+SELECT claimed.id, claimed.event_id, claimed.payload, claimed.attempts, claimed.next_attempt_at AS lease_until,
+    s.url, s.secret
+FROM claimed
+JOIN webhook_subscriptions s ON s.id = claimed.subscription_id;
+
+-- name: RecordWebhookDeliveryAttempt :execrows
+-- The attempt is only recorded by the dispatcher holding the lease of the
+-- delivery, it may have been claimed again after the lease expired.
+UPDATE webhook_deliveries
+SET status           = @status,
+    attempts         = attempts + 1,
+    last_status_code = @last_status_code,
+    last_error       = @last_error,
+    next_attempt_at  = @next_attempt_at,
+    delivered_at     = sqlc.narg(delivered_at)
+WHERE id = @id
+  AND status = 'pending'
+  AND next_attempt_at = @lease_until;
+
+-- name: ListWebhookDeliveries :many
+SELECT *
+FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id DESC
+LIMIT $2;